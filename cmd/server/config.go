@@ -25,6 +25,8 @@ app:
     rps: 2
     burst: 4
     enabled: True
+    store: memory
+    by: ip
   smtp:
     host: "smtp.mailtrap.io"
     port: 25
@@ -34,6 +36,48 @@ app:
   cors:
     trusted_origins:
       - http://localhost:8080
+  token:
+    mode: opaque
+    algorithm: EdDSA
+  login:
+    max_failures: 10
+    max_failures_per_ip: 50
+    backoff: 1s
+    lockout: 15m
+  password:
+    algorithm: argon2id
+    memory: 19456
+    iterations: 2
+    parallelism: 1
+    bcrypt_cost: 12
+  password_policy:
+    min_entropy: 36
+    disallow_personal_info: true
+  session:
+    enabled: false
+    secure: true
+    same_site: lax
+  maintenance:
+    enabled: true
+    token_cleanup:
+      interval: 1h
+      jitter: 5m
+      batch_size: 1000
+    rate_limit_cleanup:
+      interval: 10m
+      jitter: 1m
+      batch_size: 1000
+  metrics:
+    enabled: true
+  tracing:
+    enabled: false
+    exporter: otlp
+    endpoint: "http://localhost:4318/v1/traces"
+    sample_ratio: 1
+  health:
+    timeout: 2s
+    smtp: false
+    shutdown_delay: 0s
 `)
 
 func setConfig() *config.Config {
@@ -74,6 +118,8 @@ func setConfig() *config.Config {
 		Rps:     viper.GetFloat64("app.limiter.rps"),
 		Burst:   viper.GetInt("app.limiter.burst"),
 		Enabled: viper.GetBool("app.limiter.enabled"),
+		Store:   viper.GetString("app.limiter.store"),
+		By:      viper.GetString("app.limiter.by"),
 	}
 	cfg.Smtp = config.Smtp{
 		Host:     viper.GetString("app.smtp.host"),
@@ -85,6 +131,80 @@ func setConfig() *config.Config {
 	cfg.Cors = config.Cors{
 		TrustedOrigins: viper.GetStringSlice("app.cors.trusted_origins"),
 	}
+	cfg.Proxy = config.Proxy{
+		Header:  viper.GetString("app.proxy.header"),
+		Trusted: viper.GetStringSlice("app.proxy.trusted"),
+	}
+	cfg.Token = config.Token{
+		Mode:      viper.GetString("app.token.mode"),
+		Algorithm: viper.GetString("app.token.algorithm"),
+	}
+	cfg.Login = config.Login{
+		MaxFailures:      viper.GetInt("app.login.max_failures"),
+		MaxFailuresPerIP: viper.GetInt("app.login.max_failures_per_ip"),
+		Backoff:          viper.GetDuration("app.login.backoff"),
+		Lockout:          viper.GetDuration("app.login.lockout"),
+	}
+	cfg.Password = config.Password{
+		Algorithm:   viper.GetString("app.password.algorithm"),
+		Memory:      viper.GetUint32("app.password.memory"),
+		Iterations:  viper.GetUint32("app.password.iterations"),
+		Parallelism: uint8(viper.GetUint("app.password.parallelism")),
+		BcryptCost:  viper.GetInt("app.password.bcrypt_cost"),
+	}
+	cfg.PasswordPolicy = config.PasswordPolicy{
+		MinEntropy:           viper.GetFloat64("app.password_policy.min_entropy"),
+		DisallowPersonalInfo: viper.GetBool("app.password_policy.disallow_personal_info"),
+		BreachedFile:         viper.GetString("app.password_policy.breached_file"),
+	}
+	cfg.Session = config.Session{
+		Enabled:  viper.GetBool("app.session.enabled"),
+		Secure:   viper.GetBool("app.session.secure"),
+		SameSite: viper.GetString("app.session.same_site"),
+	}
+	cfg.Maintenance = config.Maintenance{
+		Enabled: viper.GetBool("app.maintenance.enabled"),
+		TokenCleanup: config.MaintenanceJob{
+			Interval:  viper.GetDuration("app.maintenance.token_cleanup.interval"),
+			Jitter:    viper.GetDuration("app.maintenance.token_cleanup.jitter"),
+			BatchSize: viper.GetInt("app.maintenance.token_cleanup.batch_size"),
+		},
+		RateLimitCleanup: config.MaintenanceJob{
+			Interval:  viper.GetDuration("app.maintenance.rate_limit_cleanup.interval"),
+			Jitter:    viper.GetDuration("app.maintenance.rate_limit_cleanup.jitter"),
+			BatchSize: viper.GetInt("app.maintenance.rate_limit_cleanup.batch_size"),
+		},
+	}
+	cfg.Metrics = config.Metrics{
+		Enabled:  viper.GetBool("app.metrics.enabled"),
+		Username: viper.GetString("app.metrics.username"),
+		Password: viper.GetString("app.metrics.password"),
+	}
+	cfg.Tracing = config.Tracing{
+		Enabled:     viper.GetBool("app.tracing.enabled"),
+		Exporter:    viper.GetString("app.tracing.exporter"),
+		Endpoint:    viper.GetString("app.tracing.endpoint"),
+		Headers:     viper.GetStringMapString("app.tracing.headers"),
+		File:        viper.GetString("app.tracing.file"),
+		SampleRatio: viper.GetFloat64("app.tracing.sample_ratio"),
+	}
+	cfg.Health = config.Health{
+		Timeout:       viper.GetDuration("app.health.timeout"),
+		SMTP:          viper.GetBool("app.health.smtp"),
+		ShutdownDelay: viper.GetDuration("app.health.shutdown_delay"),
+	}
+	if err := viper.UnmarshalKey("app.token.keys", &cfg.Token.Keys); err != nil {
+		fmt.Printf("failed to load signing keys: %v", err)
+		os.Exit(1)
+	}
+	if err := viper.UnmarshalKey("app.oidc.providers", &cfg.OIDC.Providers); err != nil {
+		fmt.Printf("failed to load identity providers: %v", err)
+		os.Exit(1)
+	}
+	if err := viper.UnmarshalKey("app.limiter.policies", &cfg.Limiter.Policies); err != nil {
+		fmt.Printf("failed to load rate limit policies: %v", err)
+		os.Exit(1)
+	}
 
 	return &cfg
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/unknowntpo/todos/config"
+	"github.com/unknowntpo/todos/pkg/health"
+	"github.com/unknowntpo/todos/pkg/naivepool"
+)
+
+// defaultHealthTimeout is the timeout of the health checks if none is configured.
+const defaultHealthTimeout = 2 * time.Second
+
+// newHealthChecks returns the registry of the checks served at /readyz. The server isn't ready
+// if the database can't be reached, isn't at the migration version the server has migrated it
+// to, or the worker pool isn't running. The SMTP server is checked too if it's configured, but
+// its failure is only reported, since the emails are sent in the background.
+func newHealthChecks(cfg *config.Config, db *sql.DB, migrationVersion uint, pool *naivepool.Pool) *health.Registry {
+	timeout := cfg.Health.Timeout
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+
+	hr := health.NewRegistry(timeout)
+
+	hr.Register("database", true, db.PingContext)
+
+	hr.Register("migrations", true, func(ctx context.Context) error {
+		return checkMigrationVersion(ctx, db, migrationVersion)
+	})
+
+	hr.Register("worker_pool", true, func(ctx context.Context) error {
+		if !pool.Running() {
+			return fmt.Errorf("the worker pool isn't running")
+		}
+		return nil
+	})
+
+	if cfg.Health.SMTP {
+		addr := net.JoinHostPort(cfg.Smtp.Host, strconv.Itoa(cfg.Smtp.Port))
+
+		hr.Register("smtp", false, func(ctx context.Context) error {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		})
+	}
+
+	return hr
+}
+
+// checkMigrationVersion reports whether the database is at the migration version want, and no
+// migration failed halfway. Another version means that another instance of the server has
+// migrated the database, whose schema this one may not work with.
+func checkMigrationVersion(ctx context.Context, db *sql.DB, want uint) error {
+	var (
+		version int64
+		dirty   bool
+	)
+
+	err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		return fmt.Errorf("failed to get the migration version: %v", err)
+	}
+
+	if dirty {
+		return fmt.Errorf("migration %d failed halfway", version)
+	}
+
+	if version != int64(want) {
+		return fmt.Errorf("the database is at migration %d, want %d", version, want)
+	}
+
+	return nil
+}
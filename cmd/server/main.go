@@ -3,21 +3,38 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"expvar"
 	"fmt"
+	"net/http"
 	"os"
 	"runtime"
 	"strings"
 	"time"
 
 	"github.com/unknowntpo/todos/config"
+	"github.com/unknowntpo/todos/internal/domain"
 	"github.com/unknowntpo/todos/internal/logger"
 	"github.com/unknowntpo/todos/internal/logger/zerolog"
 	"github.com/unknowntpo/todos/internal/mailer"
+	"github.com/unknowntpo/todos/internal/middleware"
+	_rateLimitMemory "github.com/unknowntpo/todos/internal/ratelimit/memory"
+	_rateLimitPostgres "github.com/unknowntpo/todos/internal/ratelimit/postgres"
 	"github.com/unknowntpo/todos/internal/testutil"
+	"github.com/unknowntpo/todos/internal/tracing"
+	"github.com/unknowntpo/todos/pkg/breached"
+	"github.com/unknowntpo/todos/pkg/health"
+	"github.com/unknowntpo/todos/pkg/jwt"
 	"github.com/unknowntpo/todos/pkg/naivepool"
+	"github.com/unknowntpo/todos/pkg/oidc"
+	"github.com/unknowntpo/todos/pkg/passhash"
 
-	_ "github.com/lib/pq"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 var (
@@ -33,6 +50,11 @@ type application struct {
 	pool     *naivepool.Pool
 	mailer   *mailer.Mailer
 	logger   logger.Logger
+	keys     *jwt.KeySet               // keys signs the authentication tokens, it's nil in the opaque token mode.
+	oidc     map[string]*oidc.Provider // oidc holds the external identity providers keyed by their names.
+	limiter  domain.RateLimiter
+	tracer   *sdktrace.TracerProvider // tracer exports the spans, it's nil if tracing is disabled.
+	health   *health.Registry         // health holds the readiness checks served at /readyz.
 }
 
 // @title TODOS API
@@ -47,6 +69,38 @@ func main() {
 	// Use zerolog
 	logger := zerolog.New(os.Stdout)
 
+	keys, err := newSigningKeys(&cfg.Token)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	domain.PasswordHashParams, err = newPasswordHashParams(&cfg.Password)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	domain.PasswordPolicy, err = newPasswordPolicy(&cfg.PasswordPolicy)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	if err := middleware.ValidateTrustedProxies(&cfg.Proxy); err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	if err := middleware.ValidateMetricsAuth(&cfg.Metrics); err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	tracer, err := newTracer(&cfg.Tracing, cfg.Env, logger)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+	if tracer != nil {
+		otel.SetTracerProvider(tracer)
+	}
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
 	// set up db.
 	db, err := openDBWithRetry(cfg)
 	if err != nil {
@@ -59,7 +113,18 @@ func main() {
 		logger.PrintFatal(err, nil)
 	}
 
-	if err := mig.Up(); err != nil {
+	if err := mig.Up(); err != nil && err != migrate.ErrNoChange {
+		logger.PrintFatal(err, nil)
+	}
+
+	// The version the database has been migrated to is the one the readiness check expects.
+	migrationVersion, _, err := mig.Version()
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	limiter, err := newRateLimiter(&cfg.Limiter, db)
+	if err != nil {
 		logger.PrintFatal(err, nil)
 	}
 
@@ -89,12 +154,19 @@ func main() {
 		return time.Now().Unix()
 	}))
 
+	publishMetrics(db, pool)
+
 	app := &application{
 		config:   cfg,
 		database: db,
 		pool:     pool,
 		mailer:   mailer.New(&cfg.Smtp),
 		logger:   logger,
+		keys:     keys,
+		oidc:     newOIDCProviders(&cfg.OIDC),
+		limiter:  limiter,
+		tracer:   tracer,
+		health:   newHealthChecks(cfg, db, migrationVersion, pool),
 	}
 
 	err = app.serve()
@@ -125,12 +197,15 @@ func openDBWithRetry(cfg *config.Config) (*sql.DB, error) {
 	return db, nil
 }
 
+// openDB opens the database, whose statements are recorded in the traces of the requests.
 func openDB(cfg *config.Config) (*sql.DB, error) {
-	db, err := sql.Open("postgres", cfg.DB.Dsn)
+	connector, err := pq.NewConnector(cfg.DB.Dsn)
 	if err != nil {
 		return nil, err
 	}
 
+	db := sql.OpenDB(tracing.WrapConnector(connector, "postgresql"))
+
 	db.SetMaxOpenConns(cfg.DB.MaxOpenConns)
 	db.SetMaxIdleConns(cfg.DB.MaxIdleConns)
 
@@ -156,3 +231,109 @@ func openDB(cfg *config.Config) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// newSigningKeys returns the key set to sign authentication tokens with in the signed token mode,
+// or nil in the opaque token mode.
+func newSigningKeys(cfg *config.Token) (*jwt.KeySet, error) {
+	switch cfg.Mode {
+	case "", "opaque":
+		return nil, nil
+	case "signed":
+	default:
+		return nil, fmt.Errorf("unknown token mode %q", cfg.Mode)
+	}
+
+	keys := make([]*jwt.Key, 0, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		secret, err := base64.StdEncoding.DecodeString(k.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the secret of signing key %q: %v", k.ID, err)
+		}
+
+		key, err := jwt.NewKey(k.ID, cfg.Algorithm, secret)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return jwt.NewKeySet(keys...)
+}
+
+// newPasswordHashParams returns the parameters to hash new passwords with.
+func newPasswordHashParams(cfg *config.Password) (passhash.Params, error) {
+	p := passhash.Params{
+		Algorithm:   cfg.Algorithm,
+		Memory:      cfg.Memory,
+		Iterations:  cfg.Iterations,
+		Parallelism: cfg.Parallelism,
+		Cost:        cfg.BcryptCost,
+	}
+
+	if err := p.Validate(); err != nil {
+		return passhash.Params{}, err
+	}
+
+	return p, nil
+}
+
+// newPasswordPolicy returns the rules new passwords have to follow, the breached password file
+// is loaded into memory.
+func newPasswordPolicy(cfg *config.PasswordPolicy) ([]domain.PasswordRule, error) {
+	var rules []domain.PasswordRule
+
+	if cfg.DisallowPersonalInfo {
+		rules = append(rules, domain.NoPersonalInfo{})
+	}
+
+	if cfg.MinEntropy > 0 {
+		rules = append(rules, domain.MinEntropy(cfg.MinEntropy))
+	}
+
+	if cfg.BreachedFile != "" {
+		db, err := breached.Open(cfg.BreachedFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load breached passwords: %v", err)
+		}
+		rules = append(rules, domain.NotBreached{Passwords: db})
+	}
+
+	return rules, nil
+}
+
+// newRateLimiter returns the rate limiter keeping the token buckets in the configured store.
+func newRateLimiter(cfg *config.Limiter, db *sql.DB) (domain.RateLimiter, error) {
+	if err := middleware.ValidateRateLimits(cfg); err != nil {
+		return nil, err
+	}
+
+	switch cfg.Store {
+	case "", "memory":
+		return _rateLimitMemory.NewRateLimiter(), nil
+	case "postgres":
+		return _rateLimitPostgres.NewRateLimiter(db), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limiter store %q", cfg.Store)
+	}
+}
+
+// newOIDCProviders returns the identity providers keyed by their names. The providers are
+// discovered lazily, so that an unreachable provider doesn't keep the server from starting. The
+// requests to the providers are recorded in the traces of the logins.
+func newOIDCProviders(cfg *config.OIDC) map[string]*oidc.Provider {
+	providers := make(map[string]*oidc.Provider, len(cfg.Providers))
+	client := &http.Client{Timeout: 10 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)}
+
+	for _, p := range cfg.Providers {
+		providers[p.Name] = oidc.NewProvider(oidc.Config{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}, client)
+	}
+
+	return providers
+}
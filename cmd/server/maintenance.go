@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"expvar"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/unknowntpo/todos/config"
+	"github.com/unknowntpo/todos/internal/logger"
+
+	_rateLimitPostgres "github.com/unknowntpo/todos/internal/ratelimit/postgres"
+	_tokenRepoPostgres "github.com/unknowntpo/todos/internal/token/repository/postgres"
+	_tokenUsecase "github.com/unknowntpo/todos/internal/token/usecase"
+)
+
+// job is a housekeeping task run periodically by the scheduler. run returns the number of items
+// it has processed, e.g. the rows deleted, which is logged and published in the metrics.
+type job struct {
+	name     string
+	interval time.Duration
+	jitter   time.Duration
+	run      func(ctx context.Context) (int64, error)
+}
+
+// locker makes sure that only one of the instances sharing the database runs a job at a time.
+type locker interface {
+	// tryLock takes the lock with the name without waiting, ok is false if another instance
+	// holds it. unlock releases the lock taken.
+	tryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+// scheduler runs the jobs in the background, each one in its own goroutine.
+type scheduler struct {
+	locker  locker
+	logger  logger.Logger
+	jobs    []job
+	metrics *expvar.Map
+	wg      sync.WaitGroup
+}
+
+func newScheduler(l locker, logger logger.Logger) *scheduler {
+	return &scheduler{
+		locker:  l,
+		logger:  logger,
+		metrics: new(expvar.Map).Init(),
+	}
+}
+
+// add adds the job to the scheduler, it must be called before start.
+func (s *scheduler) add(j job) {
+	s.jobs = append(s.jobs, j)
+	s.metrics.Set(j.name, new(expvar.Map).Init())
+}
+
+// start starts running the jobs until ctx is done.
+func (s *scheduler) start(ctx context.Context) {
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go func(j job) {
+			defer s.wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+}
+
+// wait waits for the running jobs to return after ctx passed to start is done.
+func (s *scheduler) wait() {
+	s.wg.Wait()
+}
+
+// loop runs the job after a random delay up to its jitter, and then every interval plus
+// a random delay up to its jitter.
+func (s *scheduler) loop(ctx context.Context, j job) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	delay := func() time.Duration {
+		if j.jitter <= 0 {
+			return 0
+		}
+		return time.Duration(rnd.Int63n(int64(j.jitter)))
+	}
+
+	timer := time.NewTimer(delay())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		s.runOnce(ctx, j)
+
+		timer.Reset(j.interval + delay())
+	}
+}
+
+// runOnce runs the job if no other instance is running it. The run is bounded by the interval,
+// so that a stuck job doesn't hold the lock forever.
+func (s *scheduler) runOnce(ctx context.Context, j job) {
+	m := s.metrics.Get(j.name).(*expvar.Map)
+
+	unlock, ok, err := s.locker.tryLock(ctx, j.name)
+	if err != nil {
+		m.Add("failures", 1)
+		s.logger.PrintError(fmt.Errorf("failed to lock maintenance job: %v", err), map[string]interface{}{
+			"job": j.name,
+		})
+		return
+	}
+	if !ok {
+		m.Add("skipped", 1)
+		return
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, j.interval)
+	defer cancel()
+
+	start := time.Now()
+	n, err := j.run(ctx)
+	elapsed := time.Since(start)
+
+	m.Add("runs", 1)
+	m.Add("processed", n)
+	lastRun := new(expvar.Int)
+	lastRun.Set(start.Unix())
+	m.Set("last_run", lastRun)
+
+	properties := map[string]interface{}{
+		"job":       j.name,
+		"processed": n,
+		"duration":  elapsed.String(),
+	}
+
+	if err != nil {
+		m.Add("failures", 1)
+		s.logger.PrintError(fmt.Errorf("maintenance job failed: %v", err), properties)
+		return
+	}
+
+	s.logger.PrintInfo("maintenance job completed", properties)
+}
+
+// pgLocker takes Postgres advisory locks. A session-level lock belongs to the connection which
+// takes it, so the connection is held until the lock is released.
+type pgLocker struct {
+	db *sql.DB
+}
+
+func (l *pgLocker) tryLock(ctx context.Context, name string) (func(), bool, error) {
+	key := lockKey(name)
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var ok bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&ok)
+	if err != nil || !ok {
+		conn.Close()
+		return nil, false, err
+	}
+
+	unlock := func() {
+		// The context of the job may be done by now.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, key)
+		if err != nil {
+			// Discard the connection rather than returning it to the pool, closing it
+			// releases the lock.
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+
+	return unlock, true, nil
+}
+
+// lockKey maps the name of the job to the key of its advisory lock.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("todos:maintenance:" + name))
+	return int64(h.Sum64())
+}
+
+// newMaintenance returns the scheduler running the housekeeping jobs, its metrics are published
+// as the "maintenance" expvar variable.
+func (app *application) newMaintenance(cfg *config.Maintenance) (*scheduler, error) {
+	s := newScheduler(&pgLocker{db: app.database}, app.logger)
+
+	tokenRepo := _tokenRepoPostgres.NewTokenRepo(app.database)
+	tokenUsecase := _tokenUsecase.NewTokenUsecase(tokenRepo, nil, app.keys, 3*time.Second)
+
+	err := s.addBatched("token_cleanup", &cfg.TokenCleanup, tokenUsecase.DeleteExpired)
+	if err != nil {
+		return nil, err
+	}
+
+	if app.config.Limiter.Store == "postgres" {
+		limiter := _rateLimitPostgres.NewRateLimiter(app.database)
+
+		err := s.addBatched("rate_limit_cleanup", &cfg.RateLimitCleanup, func(ctx context.Context, batchSize int) (int64, error) {
+			return deleteInBatches(ctx, batchSize, limiter.DeleteExpired)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	expvar.Publish("maintenance", s.metrics)
+
+	return s, nil
+}
+
+// addBatched adds the job processing the items in batches with the schedule of cfg.
+func (s *scheduler) addBatched(name string, cfg *config.MaintenanceJob, run func(ctx context.Context, batchSize int) (int64, error)) error {
+	if cfg.Interval <= 0 || cfg.BatchSize <= 0 {
+		return fmt.Errorf("invalid %s schedule: interval %s, batch size %d", name, cfg.Interval, cfg.BatchSize)
+	}
+
+	s.add(job{
+		name:     name,
+		interval: cfg.Interval,
+		jitter:   cfg.Jitter,
+		run: func(ctx context.Context) (int64, error) {
+			return run(ctx, cfg.BatchSize)
+		},
+	})
+
+	return nil
+}
+
+// deleteInBatches calls deleteBatch until it deletes less than batchSize items, and returns
+// the number of items deleted.
+func deleteInBatches(ctx context.Context, batchSize int, deleteBatch func(ctx context.Context, limit int) (int64, error)) (int64, error) {
+	var total int64
+
+	for {
+		n, err := deleteBatch(ctx, batchSize)
+		total += n
+
+		if err != nil || n < int64(batchSize) {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"expvar"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/logger/zerolog"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLocker holds the locks in memory, as if they were held by another instance when taken.
+type fakeLocker struct {
+	mu     sync.Mutex
+	locked map[string]bool
+}
+
+func (l *fakeLocker) tryLock(ctx context.Context, name string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locked[name] {
+		return nil, false, nil
+	}
+	l.locked[name] = true
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.locked, name)
+	}, true, nil
+}
+
+func metric(s *scheduler, job, key string) int64 {
+	v := s.metrics.Get(job).(*expvar.Map).Get(key)
+	if v == nil {
+		return 0
+	}
+	return v.(*expvar.Int).Value()
+}
+
+func TestScheduler(t *testing.T) {
+	t.Run("Run", func(t *testing.T) {
+		logBuf := new(bytes.Buffer)
+		l := &fakeLocker{locked: make(map[string]bool)}
+		s := newScheduler(l, zerolog.New(logBuf))
+
+		s.add(job{name: "cleanup", interval: time.Minute, run: func(ctx context.Context) (int64, error) {
+			assert.True(t, l.locked["cleanup"], "job should run with the lock held")
+			return 42, nil
+		}})
+
+		s.runOnce(context.Background(), s.jobs[0])
+
+		assert.Equal(t, int64(1), metric(s, "cleanup", "runs"))
+		assert.Equal(t, int64(42), metric(s, "cleanup", "processed"))
+		assert.False(t, l.locked["cleanup"], "lock should be released")
+		assert.Contains(t, logBuf.String(), "maintenance job completed")
+	})
+
+	t.Run("Skip when locked by another instance", func(t *testing.T) {
+		l := &fakeLocker{locked: map[string]bool{"cleanup": true}}
+		s := newScheduler(l, zerolog.New(new(bytes.Buffer)))
+
+		s.add(job{name: "cleanup", interval: time.Minute, run: func(ctx context.Context) (int64, error) {
+			t.Error("job should not run")
+			return 0, nil
+		}})
+
+		s.runOnce(context.Background(), s.jobs[0])
+
+		assert.Equal(t, int64(1), metric(s, "cleanup", "skipped"))
+		assert.Equal(t, int64(0), metric(s, "cleanup", "runs"))
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		logBuf := new(bytes.Buffer)
+		s := newScheduler(&fakeLocker{locked: make(map[string]bool)}, zerolog.New(logBuf))
+
+		s.add(job{name: "cleanup", interval: time.Minute, run: func(ctx context.Context) (int64, error) {
+			return 3, errors.New("connection reset")
+		}})
+
+		s.runOnce(context.Background(), s.jobs[0])
+
+		assert.Equal(t, int64(1), metric(s, "cleanup", "failures"))
+		assert.Equal(t, int64(3), metric(s, "cleanup", "processed"))
+		assert.Contains(t, logBuf.String(), "connection reset")
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		s := newScheduler(&fakeLocker{locked: make(map[string]bool)}, zerolog.New(new(bytes.Buffer)))
+
+		ran := make(chan struct{}, 1)
+		s.add(job{name: "cleanup", interval: time.Hour, run: func(ctx context.Context) (int64, error) {
+			ran <- struct{}{}
+			return 0, nil
+		}})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s.start(ctx)
+
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatal("job should run once started")
+		}
+
+		cancel()
+		s.wait()
+	})
+}
+
+func TestLockKey(t *testing.T) {
+	assert.Equal(t, lockKey("token_cleanup"), lockKey("token_cleanup"))
+	assert.NotEqual(t, lockKey("token_cleanup"), lockKey("other"))
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"database/sql"
+
+	"github.com/unknowntpo/todos/pkg/naivepool"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// publishMetrics registers the collectors of the database connection pool and the gauges of
+// the worker pool, which are read when the metrics are scraped. The Go runtime and process
+// metrics are registered by the client library itself.
+func publishMetrics(db *sql.DB, pool *naivepool.Pool) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "todos"))
+
+	prometheus.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "pool_workers",
+			Help: "The number of workers running the background jobs.",
+		}, func() float64 {
+			return float64(pool.Stats().Workers)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "pool_busy_workers",
+			Help: "The number of workers running a job.",
+		}, func() float64 {
+			return float64(pool.Stats().BusyWorkers)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "pool_worker_utilization",
+			Help: "The ratio of the workers running a job.",
+		}, func() float64 {
+			stats := pool.Stats()
+			if stats.Workers == 0 {
+				return 0
+			}
+			return float64(stats.BusyWorkers) / float64(stats.Workers)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "pool_queued_jobs",
+			Help: "The number of background jobs waiting for a worker.",
+		}, func() float64 {
+			return float64(pool.Stats().QueuedJobs)
+		}),
+	)
+}
@@ -15,6 +15,26 @@ import (
 	_userRepoPostgres "github.com/unknowntpo/todos/internal/user/repository/postgres"
 	_userUsecase "github.com/unknowntpo/todos/internal/user/usecase"
 
+	_workspaceAPI "github.com/unknowntpo/todos/internal/workspace/delivery/api"
+	_workspaceRepoPostgres "github.com/unknowntpo/todos/internal/workspace/repository/postgres"
+	_workspaceUsecase "github.com/unknowntpo/todos/internal/workspace/usecase"
+
+	_adminAPI "github.com/unknowntpo/todos/internal/admin/delivery/api"
+	_auditRepoPostgres "github.com/unknowntpo/todos/internal/admin/repository/postgres"
+	_adminUsecase "github.com/unknowntpo/todos/internal/admin/usecase"
+
+	_permissionAPI "github.com/unknowntpo/todos/internal/permission/delivery/api"
+	_permissionRepoPostgres "github.com/unknowntpo/todos/internal/permission/repository/postgres"
+	_permissionUsecase "github.com/unknowntpo/todos/internal/permission/usecase"
+
+	_mfaAPI "github.com/unknowntpo/todos/internal/mfa/delivery/api"
+	_mfaRepoPostgres "github.com/unknowntpo/todos/internal/mfa/repository/postgres"
+	_mfaUsecase "github.com/unknowntpo/todos/internal/mfa/usecase"
+
+	_oidcAPI "github.com/unknowntpo/todos/internal/oidc/delivery/api"
+	_oidcRepoPostgres "github.com/unknowntpo/todos/internal/oidc/repository/postgres"
+	_oidcUsecase "github.com/unknowntpo/todos/internal/oidc/usecase"
+
 	_tokenAPI "github.com/unknowntpo/todos/internal/token/delivery/api"
 	_tokenRepoPostgres "github.com/unknowntpo/todos/internal/token/repository/postgres"
 	_tokenUsecase "github.com/unknowntpo/todos/internal/token/usecase"
@@ -24,6 +44,7 @@ import (
 	"github.com/unknowntpo/todos/internal/reactor"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"github.com/unknowntpo/todos/docs"
 )
@@ -34,29 +55,49 @@ func (app *application) newRoutes() http.Handler {
 	taskRepo := _taskRepoPostgres.NewTaskRepo(app.database)
 	userRepo := _userRepoPostgres.NewUserRepo(app.database)
 	tokenRepo := _tokenRepoPostgres.NewTokenRepo(app.database)
+	personalTokenRepo := _tokenRepoPostgres.NewPersonalTokenRepo(app.database)
+	workspaceRepo := _workspaceRepoPostgres.NewWorkspaceRepo(app.database)
+	permissionRepo := _permissionRepoPostgres.NewPermissionRepo(app.database)
+	auditRepo := _auditRepoPostgres.NewAuditRepo(app.database)
+	mfaRepo := _mfaRepoPostgres.NewMFARepo(app.database)
+	oidcRepo := _oidcRepoPostgres.NewOIDCRepo(app.database)
 
 	// usecase
-	taskUsecase := _taskUsecase.NewTaskUsecase(taskRepo, 3*time.Second)
-	userUsecase := _userUsecase.NewUserUsecase(userRepo, tokenRepo, app.pool, app.mailer, app.logger, 3*time.Second)
-	tokenUsecase := _tokenUsecase.NewTokenUsecase(tokenRepo, 3*time.Second)
+	taskUsecase := _taskUsecase.NewTaskUsecase(taskRepo, userRepo, workspaceRepo, app.pool, app.mailer, app.logger, 3*time.Second)
+	tokenUsecase := _tokenUsecase.NewTokenUsecase(tokenRepo, personalTokenRepo, app.keys, 3*time.Second)
+	mfaUsecase := _mfaUsecase.NewMFAUsecase(mfaRepo, userRepo, 3*time.Second)
+	userUsecase := _userUsecase.NewUserUsecase(userRepo, tokenUsecase, mfaUsecase, permissionRepo, app.pool, app.mailer, app.logger, &app.config.Login, 3*time.Second)
+	oidcUsecase := _oidcUsecase.NewOIDCUsecase(oidcRepo, userRepo, permissionRepo, tokenUsecase, mfaUsecase, app.oidc, 10*time.Second)
+	workspaceUsecase := _workspaceUsecase.NewWorkspaceUsecase(workspaceRepo, userRepo, 3*time.Second)
+	permissionUsecase := _permissionUsecase.NewPermissionUsecase(permissionRepo, 3*time.Second)
+	adminUsecase := _adminUsecase.NewAdminUsecase(userRepo, tokenUsecase, auditRepo, app.pool, app.mailer, app.logger, 3*time.Second)
 
 	// reactor
 	rc := reactor.NewReactor(app.logger)
 
 	// middleware
 
-	genMid := _generalMiddleware.New(app.config, userUsecase, rc)
+	genMid := _generalMiddleware.New(app.config, userUsecase, tokenUsecase, workspaceUsecase, permissionUsecase, app.limiter, rc)
 
 	// delivery
 
-	_healthcheckAPI.NewHealthcheckAPI(router, version, app.config.Env, rc)
+	_healthcheckAPI.NewHealthcheckAPI(router, version, app.config.Env, app.health, rc)
 
 	_taskAPI.NewTaskAPI(router, taskUsecase, genMid, rc)
-	_userAPI.NewUserAPI(router, userUsecase, tokenUsecase, rc)
-	_tokenAPI.NewTokenAPI(router, userUsecase, rc)
+	_workspaceAPI.NewWorkspaceAPI(router, workspaceUsecase, genMid, rc)
+	_permissionAPI.NewPermissionAPI(router, permissionUsecase, genMid, rc)
+	_adminAPI.NewAdminAPI(router, adminUsecase, genMid, rc)
+	_userAPI.NewUserAPI(router, userUsecase, tokenUsecase, genMid, rc)
+	_tokenAPI.NewTokenAPI(router, userUsecase, tokenUsecase, genMid, rc)
+	_mfaAPI.NewMFAAPI(router, mfaUsecase, genMid, rc)
+	_oidcAPI.NewOIDCAPI(router, oidcUsecase, rc)
 
 	router.Handler(http.MethodGet, "/debug/vars", expvar.Handler())
 
+	if app.config.Metrics.Enabled {
+		router.Handler(http.MethodGet, "/metrics", genMid.RequireMetricsAuth(promhttp.Handler()))
+	}
+
 	// Set up http-swagger
 	urlFunc := httpSwagger.URL("http://localhost:4000/swagger/doc.json") //The url pointing to API definition
 	if app.config.Env == "production" {
@@ -70,16 +111,20 @@ func (app *application) newRoutes() http.Handler {
 
 	//return genMid.Metrics(genMid.RecoverPanic(genMid.EnableCORS(genMid.RateLimit(genMid.Authenticate(router)))))
 	//return app.metrics(app.recoverPanic(app.enableCORS(app.rateLimit(app.authenticate(router)))))
-	return chain(router, genMid.Metrics,
+	return chain(genMid.Route(router), genMid.Metrics,
+		genMid.ClientIP,
+		genMid.Trace,
+		genMid.AccessLog,
 		genMid.RecoverPanic,
 		genMid.EnableCORS,
+		genMid.Authenticate,
 		genMid.RateLimit,
-		genMid.Authenticate)
+		genMid.Workspace)
 }
 
 func chain(route http.Handler, handlers ...func(http.Handler) http.Handler) http.Handler {
 	c := route
-	for i := len(handlers) - 1; i > 0; i-- {
+	for i := len(handlers) - 1; i >= 0; i-- {
 		c = handlers[i](c)
 	}
 	return c
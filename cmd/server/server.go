@@ -28,6 +28,17 @@ func (app *application) serve() error {
 
 	app.pool.Start(poolCtx)
 
+	// Starting the housekeeping jobs, they're stopped along with the worker pool.
+	var maintenance *scheduler
+	if app.config.Maintenance.Enabled {
+		var err error
+		maintenance, err = app.newMaintenance(&app.config.Maintenance)
+		if err != nil {
+			return err
+		}
+		maintenance.start(poolCtx)
+	}
+
 	go func() {
 		// Why we need buffered channel ?
 		quit := make(chan os.Signal, 1)
@@ -36,13 +47,18 @@ func (app *application) serve() error {
 
 		s := <-quit
 
-		// Shutdown worker pool.
-		poolCancel()
-
 		app.logger.PrintInfo("shutting down server", map[string]interface{}{
 			"signal": s.String(),
 		})
 
+		// Report the server isn't ready, and keep serving for a while, so that the load
+		// balancers stop sending requests before the server stops accepting them.
+		app.health.Shutdown()
+		time.Sleep(app.config.Health.ShutdownDelay)
+
+		// Shutdown worker pool.
+		poolCancel()
+
 		// do server shutdown routine.
 		serverCtx, serverCancel := context.WithTimeout(context.Background(), 3*time.Second)
 		defer serverCancel()
@@ -53,6 +69,20 @@ func (app *application) serve() error {
 		}
 
 		app.pool.Wait()
+		if maintenance != nil {
+			maintenance.wait()
+		}
+
+		// Export the spans of the jobs which have just finished.
+		if app.tracer != nil {
+			tracerCtx, tracerCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer tracerCancel()
+
+			if err := app.tracer.Shutdown(tracerCtx); err != nil {
+				app.logger.PrintError(fmt.Errorf("failed to export the remaining spans: %v", err), nil)
+			}
+		}
+
 		shutdownErr <- nil
 	}()
 
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/unknowntpo/todos/config"
+	"github.com/unknowntpo/todos/internal/logger"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// newTracer returns the tracer provider exporting the spans by the configured exporter, or nil
+// if tracing is disabled. The errors of the exporter are logged, they don't fail the requests.
+func newTracer(cfg *config.Tracing, env string, log logger.Logger) (*sdktrace.TracerProvider, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("tracing sample ratio %v must be between 0 and 1", cfg.SampleRatio)
+	}
+
+	var exporter sdktrace.SpanExporter
+
+	switch cfg.Exporter {
+	case "", "otlp":
+		if cfg.Endpoint == "" {
+			return nil, fmt.Errorf("the otlp tracing exporter requires an endpoint")
+		}
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid otlp tracing endpoint %q", cfg.Endpoint)
+		}
+
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(u.Host),
+			otlptracehttp.WithHeaders(cfg.Headers),
+		}
+		if u.Path != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+		}
+		if u.Scheme == "http" {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+
+		// The exporter connects lazily, so that an unreachable collector doesn't keep the
+		// server from starting.
+		e, err := otlptracehttp.New(context.Background(), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the otlp tracing exporter: %v", err)
+		}
+		exporter = e
+	case "stdout":
+		e, err := stdouttrace.New()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the stdout tracing exporter: %v", err)
+		}
+		exporter = e
+	case "file":
+		if cfg.File == "" {
+			return nil, fmt.Errorf("the file tracing exporter requires a file")
+		}
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the trace file: %v", err)
+		}
+		e, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the file tracing exporter: %v", err)
+		}
+		exporter = e
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.PrintError(err, nil)
+	}))
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		// The traces continued from the clients are recorded if the clients record them.
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "todos"),
+			attribute.String("service.version", version),
+			attribute.String("deployment.environment", env),
+		)),
+	), nil
+}
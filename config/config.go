@@ -1,13 +1,26 @@
 package config
 
+import "time"
+
 // Config holds configuration of server
 type Config struct {
-	Port    int
-	Env     string
-	DB      DB
-	Limiter Limiter
-	Smtp    Smtp
-	Cors    Cors
+	Port           int
+	Env            string
+	DB             DB
+	Limiter        Limiter
+	Smtp           Smtp
+	Cors           Cors
+	Proxy          Proxy
+	Token          Token
+	OIDC           OIDC
+	Login          Login
+	Password       Password
+	PasswordPolicy PasswordPolicy
+	Session        Session
+	Maintenance    Maintenance
+	Metrics        Metrics
+	Tracing        Tracing
+	Health         Health
 }
 
 type DB struct {
@@ -17,10 +30,29 @@ type DB struct {
 	MaxIdleTime  string
 }
 
+// Limiter is the configuration of rate limiting. Rps and Burst are the default limit, which
+// applies to the requests no policy matches.
 type Limiter struct {
 	Rps     float64
 	Burst   int
 	Enabled bool
+	// Store keeps the token buckets, either "memory", which limits each instance separately,
+	// or "postgres", which limits all the instances sharing the database together.
+	Store string
+	// By is what the default limit is counted by, either "ip" or "user". Anonymous requests
+	// are always counted by IP.
+	By       string
+	Policies []RateLimitPolicy
+}
+
+// RateLimitPolicy is the limit of the requests matching Route, e.g. "POST /v1/tokens/authentication".
+// The method is optional, and the path is an httprouter pattern, where ":name" matches a segment
+// and "*name" matches the rest of the path. The first matching policy applies.
+type RateLimitPolicy struct {
+	Route string
+	Rps   float64
+	Burst int
+	By    string
 }
 
 // Smtp is the configuraion used to set up the dialer in github.com/go-mail/mail/v2.
@@ -35,3 +67,153 @@ type Smtp struct {
 type Cors struct {
 	TrustedOrigins []string
 }
+
+// Proxy is the configuration of the reverse proxies in front of the server. The Header they set,
+// Forwarded, X-Forwarded-For (the default) or X-Real-IP, is only believed when the request comes
+// from one of the Trusted proxies, which are CIDR blocks or single addresses. The other headers
+// are ignored, since the proxies pass them through as the clients sent them.
+type Proxy struct {
+	Header  string
+	Trusted []string
+}
+
+// Token is the configuration of the authentication tokens. In the "opaque" mode, which is
+// the default, authentication tokens are random strings looked up in database on every
+// request. In the "signed" mode, they're signed tokens carrying the user ID, scope and
+// expiry, which are verified without hitting database. Only the activation state of their
+// users is looked up, and cached for a while.
+type Token struct {
+	Mode string
+	// Algorithm is the algorithm to sign tokens with, either EdDSA or HS256.
+	Algorithm string
+	// Keys are the signing keys. The first one signs new tokens, all of them verify tokens.
+	Keys []SigningKey
+}
+
+// SigningKey is the key identified by ID, Secret is base64 encoded, it's the 32-byte
+// Ed25519 seed for EdDSA, or the shared secret for HS256.
+type SigningKey struct {
+	ID     string
+	Secret string
+}
+
+// OIDC is the configuration of the external identity providers users can sign in with.
+type OIDC struct {
+	Providers []OIDCProvider
+}
+
+// OIDCProvider is the client registration at an identity provider. Name appears in the login
+// URLs, e.g. /v1/auth/oidc/<name>/start, and RedirectURL must point to the callback,
+// /v1/auth/oidc/<name>/callback.
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	RedirectURL  string `mapstructure:"redirect_url"`
+	Scopes       []string
+}
+
+// Login is the configuration of the protection against password guessing. Failed logins are
+// counted per email address and per client IP, and forgotten once Lockout has passed since
+// the last one. A zero threshold disables the corresponding lockout.
+type Login struct {
+	// MaxFailures is the number of failed logins after which the email address is locked out.
+	MaxFailures int
+	// MaxFailuresPerIP is the number of failed logins after which the client IP is locked out,
+	// it's higher than MaxFailures because many users may share an IP.
+	MaxFailuresPerIP int
+	// Backoff is how long the email address has to wait after the first failed login,
+	// the wait doubles with every further failure.
+	Backoff time.Duration
+	// Lockout is how long the email address or client IP is locked out.
+	Lockout time.Duration
+}
+
+// Password is the configuration of password hashing, Algorithm is either "argon2id" or "bcrypt".
+// The hashes made with other parameters are replaced when their users log in.
+type Password struct {
+	Algorithm string
+	// Memory is the memory used by Argon2id in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	// BcryptCost is the cost of bcrypt.
+	BcryptCost int
+}
+
+// PasswordPolicy is the configuration of the rules new passwords have to follow besides the length.
+type PasswordPolicy struct {
+	// MinEntropy is the minimum estimated entropy of passwords in bits, zero disables the check.
+	MinEntropy float64
+	// DisallowPersonalInfo refuses the passwords containing the name or email address of the user.
+	DisallowPersonalInfo bool
+	// BreachedFile is the path of the file of breached password hashes, one SHA-1 hash in hex per
+	// line, optionally followed by ":<count>" as in the Pwned Passwords downloads. It's loaded
+	// at startup, empty disables the check.
+	BreachedFile string
+}
+
+// Session is the configuration of the session mode, in which browser clients can ask to be
+// authenticated by HttpOnly cookies, which JavaScript can't read, rather than the
+// Authorization header.
+type Session struct {
+	Enabled bool
+	// Secure restricts the cookies to HTTPS, it should only be turned off for local development.
+	Secure bool
+	// SameSite is the SameSite attribute of the cookies, either "strict", "lax" or "none".
+	SameSite string
+}
+
+// Maintenance is the configuration of the housekeeping jobs the server runs in the background.
+// Every run of a job holds a Postgres advisory lock, so that only one of the instances sharing
+// the database runs it at a time.
+type Maintenance struct {
+	Enabled bool
+	// TokenCleanup deletes the expired tokens.
+	TokenCleanup MaintenanceJob
+	// RateLimitCleanup deletes the token buckets of the rate limiter which are full again,
+	// it only runs with the "postgres" limiter store.
+	RateLimitCleanup MaintenanceJob
+}
+
+// MaintenanceJob is the schedule of a housekeeping job. It runs every Interval plus a random delay
+// up to Jitter, which keeps the instances started together from contending for the lock.
+type MaintenanceJob struct {
+	Interval time.Duration
+	Jitter   time.Duration
+	// BatchSize is the number of rows deleted by each statement.
+	BatchSize int
+}
+
+// Metrics is the configuration of the /metrics endpoint, which serves the metrics in the
+// Prometheus text format. If Username is set, the scrapers have to send it along with Password
+// by HTTP basic authentication.
+type Metrics struct {
+	Enabled  bool
+	Username string
+	Password string
+}
+
+// Tracing is the configuration of the traces of the requests. The spans are exported by
+// Exporter, either "otlp", which sends them to the OpenTelemetry collector at Endpoint along
+// with Headers, "stdout", or "file", which appends them to File as JSON lines. SampleRatio is
+// the ratio of the traces started by the server which are recorded.
+type Tracing struct {
+	Enabled     bool
+	Exporter    string
+	Endpoint    string
+	Headers     map[string]string
+	File        string
+	SampleRatio float64
+}
+
+// Health is the configuration of the readiness checks served at /readyz. Every check fails if it
+// takes longer than Timeout. SMTP adds the check of the SMTP server, which doesn't make the
+// server not ready when it fails. On shutdown, the server reports it isn't ready for
+// ShutdownDelay before it stops accepting requests, so that the load balancers can take it out.
+type Health struct {
+	Timeout       time.Duration
+	SMTP          bool
+	ShutdownDelay time.Duration
+}
@@ -0,0 +1,328 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/helpers"
+	"github.com/unknowntpo/todos/internal/middleware"
+	"github.com/unknowntpo/todos/internal/reactor"
+
+	"github.com/unknowntpo/todos/pkg/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type GetAllUsersResponse struct {
+	Metadata *domain.Metadata `json:"metadata"`
+	Users    []*domain.User   `json:"users"`
+}
+
+type AdminUserResponse struct {
+	User *domain.User `json:"user"`
+}
+
+type AdminMessageResponse struct {
+	Message string `json:"message"`
+}
+
+type ImpersonateResponse struct {
+	Token *domain.Token `json:"token"`
+}
+
+type adminAPI struct {
+	au  domain.AdminUsecase
+	mid *middleware.Middleware
+	rc  *reactor.Reactor
+}
+
+func NewAdminAPI(router *httprouter.Router, au domain.AdminUsecase, mid *middleware.Middleware, rc *reactor.Reactor) {
+	api := &adminAPI{au: au, mid: mid, rc: rc}
+	router.Handler(http.MethodGet, "/v1/admin/users", mid.RequirePermission(domain.PermissionAdmin, http.HandlerFunc(api.GetAllUsers)))
+	router.Handler(http.MethodGet, "/v1/admin/users/:id", mid.RequirePermission(domain.PermissionAdmin, http.HandlerFunc(api.GetUser)))
+	router.Handler(http.MethodDelete, "/v1/admin/users/:id", mid.RequirePermission(domain.PermissionAdmin, http.HandlerFunc(api.DeleteUser)))
+	router.Handler(http.MethodPost, "/v1/admin/users/:id/deactivate", mid.RequirePermission(domain.PermissionAdmin, http.HandlerFunc(api.Deactivate)))
+	router.Handler(http.MethodPost, "/v1/admin/users/:id/reactivate", mid.RequirePermission(domain.PermissionAdmin, http.HandlerFunc(api.Reactivate)))
+	router.Handler(http.MethodPost, "/v1/admin/users/:id/password-reset", mid.RequirePermission(domain.PermissionAdmin, http.HandlerFunc(api.ForcePasswordReset)))
+	router.Handler(http.MethodPost, "/v1/admin/users/:id/impersonate", mid.RequirePermission(domain.PermissionAdmin, http.HandlerFunc(api.Impersonate)))
+}
+
+// GetAllUsers lists the users.
+// @Summary List the users, optionally filtered by name or email.
+// @Description: Requires the admin permission.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param search query string false "only list users whose name or email contains search"
+// @Param sort query string false "sort filter"
+// @Param page query string false "page filter"
+// @Param page_size query string false "page size filter"
+// @Success 200 {object} GetAllUsersResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/admin/users [get]
+func (a *adminAPI) GetAllUsers(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("adminAPI.GetAllUsers")
+
+	var input struct {
+		Search string
+		domain.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+	input.Search = a.rc.ReadString(qs, "search", "")
+	input.Filters.CurrentPage = a.rc.ReadInt(qs, "page", 1, v)
+	input.Filters.PageSize = a.rc.ReadInt(qs, "page_size", 20, v)
+	input.Filters.Sort = a.rc.ReadString(qs, "sort", "id")
+	input.Filters.SortSafelist = []string{"id", "name", "email", "created_at", "-id", "-name", "-email", "-created_at"}
+
+	if domain.ValidateFilters(v, input.Filters); !v.Valid() {
+		a.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+	users, metadata, err := a.au.GetAllUsers(ctx, input.Search, input.Filters)
+	if err != nil {
+		a.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		return
+	}
+
+	err = a.rc.WriteJSON(w, http.StatusOK, &GetAllUsersResponse{Metadata: &metadata, Users: users})
+	if err != nil {
+		a.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// GetUser gets a user by id.
+// @Summary Get a user by id.
+// @Description: Requires the admin permission.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param userID path int true "User ID"
+// @Success 200 {object} AdminUserResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/admin/users/{userID} [get]
+func (a *adminAPI) GetUser(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("adminAPI.GetUser")
+
+	userID, err := a.rc.ReadIDParam(r)
+	if err != nil {
+		a.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	user, err := a.au.GetUser(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			a.rc.NotFoundResponse(w, r)
+		default:
+			a.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = a.rc.WriteJSON(w, http.StatusOK, &AdminUserResponse{user})
+	if err != nil {
+		a.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// Deactivate deactivates a user.
+// @Summary Deactivate a user and revoke all authentication tokens of the user.
+// @Description: Requires the admin permission. The action is recorded in the audit log.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param userID path int true "User ID"
+// @Success 200 {object} AdminUserResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 409 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/admin/users/{userID}/deactivate [post]
+func (a *adminAPI) Deactivate(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("adminAPI.Deactivate")
+
+	a.setActivated(w, r, op, a.au.Deactivate)
+}
+
+// Reactivate reactivates a user.
+// @Summary Reactivate a deactivated user.
+// @Description: Requires the admin permission. The action is recorded in the audit log.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param userID path int true "User ID"
+// @Success 200 {object} AdminUserResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 409 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/admin/users/{userID}/reactivate [post]
+func (a *adminAPI) Reactivate(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("adminAPI.Reactivate")
+
+	a.setActivated(w, r, op, a.au.Reactivate)
+}
+
+// setActivated calls fn to change the activated state of the user in the path
+// and writes the updated user to the response.
+func (a *adminAPI) setActivated(w http.ResponseWriter, r *http.Request, op errors.Op, fn func(ctx context.Context, adminID int64, userID int64) (*domain.User, error)) {
+	admin := helpers.ContextGetUser(r)
+
+	userID, err := a.rc.ReadIDParam(r)
+	if err != nil {
+		a.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	user, err := fn(r.Context(), admin.ID, userID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			a.rc.NotFoundResponse(w, r)
+		case errors.KindIs(err, errors.KindEditConflict):
+			a.rc.EditConflictResponse(w, r)
+		default:
+			a.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = a.rc.WriteJSON(w, http.StatusOK, &AdminUserResponse{user})
+	if err != nil {
+		a.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// ForcePasswordReset forces a user to reset their password.
+// @Summary Force a user to reset their password.
+// @Description: Requires the admin permission. The current password and authentication tokens of the user stop working,
+// @Description: and a password reset token is emailed to the user. The action is recorded in the audit log.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param userID path int true "User ID"
+// @Success 202 {object} AdminMessageResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 409 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/admin/users/{userID}/password-reset [post]
+func (a *adminAPI) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("adminAPI.ForcePasswordReset")
+
+	admin := helpers.ContextGetUser(r)
+
+	userID, err := a.rc.ReadIDParam(r)
+	if err != nil {
+		a.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	err = a.au.ForcePasswordReset(ctx, admin.ID, userID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			a.rc.NotFoundResponse(w, r)
+		case errors.KindIs(err, errors.KindEditConflict):
+			a.rc.EditConflictResponse(w, r)
+		default:
+			a.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = a.rc.WriteJSON(w, http.StatusAccepted, &AdminMessageResponse{"an email will be sent to the user containing password reset instructions"})
+	if err != nil {
+		a.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// DeleteUser deletes a user.
+// @Summary Delete a user along with everything that belongs to the user.
+// @Description: Requires the admin permission. The action is recorded in the audit log.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param userID path int true "User ID"
+// @Success 200 {object} AdminMessageResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/admin/users/{userID} [delete]
+func (a *adminAPI) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("adminAPI.DeleteUser")
+
+	admin := helpers.ContextGetUser(r)
+
+	userID, err := a.rc.ReadIDParam(r)
+	if err != nil {
+		a.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	err = a.au.DeleteUser(ctx, admin.ID, userID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			a.rc.NotFoundResponse(w, r)
+		default:
+			a.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = a.rc.WriteJSON(w, http.StatusOK, &AdminMessageResponse{"user successfully deleted"})
+	if err != nil {
+		a.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// Impersonate issues an authentication token of a user to the admin.
+// @Summary Impersonate a user.
+// @Description: Requires the admin permission. The returned authentication token acts on behalf of the user
+// @Description: and expires in 1 hour. The action is recorded in the audit log.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param userID path int true "User ID"
+// @Success 201 {object} ImpersonateResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/admin/users/{userID}/impersonate [post]
+func (a *adminAPI) Impersonate(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("adminAPI.Impersonate")
+
+	admin := helpers.ContextGetUser(r)
+
+	userID, err := a.rc.ReadIDParam(r)
+	if err != nil {
+		a.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	token, err := a.au.Impersonate(ctx, admin.ID, userID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			a.rc.NotFoundResponse(w, r)
+		default:
+			a.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = a.rc.WriteJSON(w, http.StatusCreated, &ImpersonateResponse{token})
+	if err != nil {
+		a.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
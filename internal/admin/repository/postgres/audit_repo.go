@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+)
+
+type auditRepo struct {
+	DB *sql.DB
+}
+
+func NewAuditRepo(DB *sql.DB) domain.AuditRepository {
+	return &auditRepo{DB}
+}
+
+// Insert appends the entry to the audit log and writes its id and creation time back to entry.
+func (ar *auditRepo) Insert(ctx context.Context, entry *domain.AuditEntry) error {
+	const op errors.Op = "auditRepo.Insert"
+
+	query := `
+        INSERT INTO audit_log (actor_id, action, target_user_id)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at`
+
+	args := []interface{}{entry.ActorID, entry.Action, entry.TargetUserID}
+
+	err := ar.DB.QueryRowContext(ctx, query, args...).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	return nil
+}
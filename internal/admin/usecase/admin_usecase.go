@@ -0,0 +1,329 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/logger"
+	"github.com/unknowntpo/todos/internal/mailer"
+	"github.com/unknowntpo/todos/internal/tracing"
+	"github.com/unknowntpo/todos/pkg/naivepool"
+)
+
+// impersonationTTL is the lifetime of the authentication tokens issued to impersonating admins.
+const impersonationTTL = time.Hour
+
+// passwordResetTTL is the lifetime of the password reset tokens.
+const passwordResetTTL = 45 * time.Minute
+
+type adminUsecase struct {
+	userRepo       domain.UserRepository
+	tokenUsecase   domain.TokenUsecase
+	auditRepo      domain.AuditRepository
+	pool           *naivepool.Pool
+	mailer         *mailer.Mailer
+	logger         logger.Logger
+	contextTimeout time.Duration
+}
+
+func NewAdminUsecase(
+	ur domain.UserRepository,
+	tu domain.TokenUsecase,
+	ar domain.AuditRepository,
+	p *naivepool.Pool,
+	mailer *mailer.Mailer,
+	logger logger.Logger,
+	timeout time.Duration,
+) domain.AdminUsecase {
+	return &adminUsecase{
+		userRepo:       ur,
+		tokenUsecase:   tu,
+		auditRepo:      ar,
+		pool:           p,
+		mailer:         mailer,
+		logger:         logger,
+		contextTimeout: timeout,
+	}
+}
+
+// GetAllUsers returns the users whose name or email contains search.
+func (au *adminUsecase) GetAllUsers(ctx context.Context, search string, filters domain.Filters) ([]*domain.User, domain.Metadata, error) {
+	const op errors.Op = "adminUsecase.GetAllUsers"
+
+	ctx, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+
+	users, metadata, err := au.userRepo.GetAll(ctx, search, filters)
+	if err != nil {
+		return nil, domain.Metadata{}, errors.E(op, err)
+	}
+
+	return users, metadata, nil
+}
+
+// GetUser returns the user with given userID.
+func (au *adminUsecase) GetUser(ctx context.Context, userID int64) (*domain.User, error) {
+	const op errors.Op = "adminUsecase.GetUser"
+
+	ctx, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+
+	user, err := au.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return user, nil
+}
+
+// Deactivate deactivates the user and revokes all authentication, refresh and personal access tokens of the user,
+// so that the user can't access the resources which require an activated account. The activation
+// tokens of the user are deleted too, and the user can't activate the account again until it's
+// reactivated. The deactivation is audited before it's done.
+func (au *adminUsecase) Deactivate(ctx context.Context, adminID int64, userID int64) (*domain.User, error) {
+	const op errors.Op = "adminUsecase.Deactivate"
+
+	ctx, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+
+	user, err := au.setActivated(ctx, adminID, userID, false, domain.AuditActionDeactivateUser)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	err = au.revokeAuthTokens(ctx, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	err = au.tokenUsecase.DeleteAllForUser(ctx, domain.ScopeActivation, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return user, nil
+}
+
+// Reactivate activates the user again. The reactivation is audited before it's done.
+func (au *adminUsecase) Reactivate(ctx context.Context, adminID int64, userID int64) (*domain.User, error) {
+	const op errors.Op = "adminUsecase.Reactivate"
+
+	ctx, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+
+	user, err := au.setActivated(ctx, adminID, userID, true, domain.AuditActionReactivateUser)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return user, nil
+}
+
+// setActivated sets the activated state of the user with given userID, once the action of the
+// admin is audited. The user is marked as deactivated by the admin unless activated is true.
+func (au *adminUsecase) setActivated(ctx context.Context, adminID int64, userID int64, activated bool, action string) (*domain.User, error) {
+	const op errors.Op = "adminUsecase.setActivated"
+
+	user, err := au.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	err = au.audit(ctx, adminID, action, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	user.Activated = activated
+	user.Deactivated = !activated
+
+	err = au.userRepo.Update(ctx, user)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return user, nil
+}
+
+// ForcePasswordReset replaces the password of the user with a random one, revokes all
+// authentication, refresh and personal access tokens of the user and emails a password reset
+// token to the user.
+// The user can't log in until the password has been reset. The reset is audited before it's done.
+func (au *adminUsecase) ForcePasswordReset(ctx context.Context, adminID int64, userID int64) error {
+	const op errors.Op = "adminUsecase.ForcePasswordReset"
+
+	ctx, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+
+	user, err := au.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = au.audit(ctx, adminID, domain.AuditActionResetPassword, userID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return errors.E(op, err)
+	}
+
+	err = user.Password.Set(base32.StdEncoding.EncodeToString(randomBytes))
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = au.userRepo.Update(ctx, user)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = au.revokeAuthTokens(ctx, userID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	token, err := domain.GenerateToken(userID, passwordResetTTL, domain.ScopePasswordReset)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = au.tokenUsecase.Insert(ctx, token)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	log := logger.FromContext(ctx, au.logger)
+
+	const sendOp errors.Op = "adminUsecase.ForcePasswordReset.sendToken"
+
+	au.pool.Schedule(tracing.Job(ctx, sendOp, func(ctx context.Context) {
+		data := map[string]interface{}{
+			"passwordResetToken": token.Plaintext,
+		}
+
+		err := au.mailer.Send(ctx, user.Email, "password_reset.tmpl", data)
+		if err != nil {
+			log.PrintError(
+				errors.E(
+					sendOp,
+					errors.UserEmail(user.Email),
+					errors.KindInternal,
+					errors.Msg("failed to send password reset email"),
+					err,
+				),
+				nil,
+			)
+			return
+		}
+	}))
+
+	return nil
+}
+
+// DeleteUser deletes the user along with everything that belongs to the user.
+// The deletion is audited before it's done.
+func (au *adminUsecase) DeleteUser(ctx context.Context, adminID int64, userID int64) error {
+	const op errors.Op = "adminUsecase.DeleteUser"
+
+	ctx, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+
+	// Only audit the deletion of an existing user.
+	_, err := au.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = au.audit(ctx, adminID, domain.AuditActionDeleteUser, userID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = au.userRepo.Delete(ctx, userID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// Impersonate issues a short-lived authentication token of the user with given userID
+// to the admin with given adminID. The impersonation is audited before the token is issued.
+func (au *adminUsecase) Impersonate(ctx context.Context, adminID int64, userID int64) (*domain.Token, error) {
+	const op errors.Op = "adminUsecase.Impersonate"
+
+	ctx, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+
+	user, err := au.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	// Never issue a token which hasn't been audited.
+	err = au.audit(ctx, adminID, domain.AuditActionImpersonate, user.ID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	token, err := domain.GenerateToken(user.ID, impersonationTTL, domain.ScopeAuthentication)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	err = au.tokenUsecase.Insert(ctx, token)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	logger.FromContext(ctx, au.logger).PrintInfo("admin impersonates user", map[string]interface{}{
+		"admin_id": adminID,
+		"user_id":  user.ID,
+	})
+
+	return token, nil
+}
+
+// revokeAuthTokens deletes all authentication, refresh and personal access tokens of the user.
+func (au *adminUsecase) revokeAuthTokens(ctx context.Context, userID int64) error {
+	const op errors.Op = "adminUsecase.revokeAuthTokens"
+
+	for _, scope := range []string{domain.ScopeAuthentication, domain.ScopeRefresh} {
+		err := au.tokenUsecase.DeleteAllForUser(ctx, scope, userID)
+		if err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	err := au.tokenUsecase.DeleteAllPersonalTokens(ctx, userID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// audit records the action taken by the admin on the user in the audit log.
+func (au *adminUsecase) audit(ctx context.Context, adminID int64, action string, userID int64) error {
+	const op errors.Op = "adminUsecase.audit"
+
+	entry := &domain.AuditEntry{
+		ActorID:      adminID,
+		Action:       action,
+		TargetUserID: userID,
+	}
+
+	err := au.auditRepo.Insert(ctx, entry)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/unknowntpo/todos/config"
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	_repoMock "github.com/unknowntpo/todos/internal/domain/mocks"
+	"github.com/unknowntpo/todos/internal/logger/zerolog"
+	"github.com/unknowntpo/todos/internal/mailer"
+	"github.com/unknowntpo/todos/pkg/naivepool"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeactivate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(_repoMock.UserRepository)
+		tokenUsecase := new(_repoMock.TokenUsecase)
+		auditRepo := new(_repoMock.AuditRepository)
+
+		userRepo.On("GetByID", mock.Anything, int64(2)).Return(&domain.User{ID: 2, Activated: true}, nil)
+		userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *domain.User) bool { return !u.Activated && u.Deactivated })).Return(nil)
+		tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeAuthentication, int64(2)).Return(nil)
+		tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeRefresh, int64(2)).Return(nil)
+		tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeActivation, int64(2)).Return(nil)
+		tokenUsecase.On("DeleteAllPersonalTokens", mock.Anything, int64(2)).Return(nil)
+		auditRepo.On("Insert", mock.Anything, &domain.AuditEntry{ActorID: 1, Action: domain.AuditActionDeactivateUser, TargetUserID: 2}).Return(nil)
+
+		adminUsecase := NewAdminUsecase(userRepo, tokenUsecase, auditRepo, nil, nil, zerolog.New(new(bytes.Buffer)), 3*time.Second)
+
+		user, err := adminUsecase.Deactivate(context.TODO(), 1, 2)
+		assert.NoError(t, err)
+		assert.False(t, user.Activated)
+		assert.True(t, user.Deactivated, "user should be marked as deactivated by an admin")
+
+		userRepo.AssertExpectations(t)
+		tokenUsecase.AssertExpectations(t)
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fail on non-existing user", func(t *testing.T) {
+		userRepo := new(_repoMock.UserRepository)
+		tokenUsecase := new(_repoMock.TokenUsecase)
+		auditRepo := new(_repoMock.AuditRepository)
+
+		userRepo.On("GetByID", mock.Anything, int64(2)).Return(nil, errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound))
+
+		adminUsecase := NewAdminUsecase(userRepo, tokenUsecase, auditRepo, nil, nil, zerolog.New(new(bytes.Buffer)), 3*time.Second)
+
+		_, err := adminUsecase.Deactivate(context.TODO(), 1, 2)
+		assert.True(t, errors.KindIs(err, errors.KindRecordNotFound), "kind of error should be KindRecordNotFound")
+
+		auditRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fail when audit log can't be written", func(t *testing.T) {
+		userRepo := new(_repoMock.UserRepository)
+		tokenUsecase := new(_repoMock.TokenUsecase)
+		auditRepo := new(_repoMock.AuditRepository)
+
+		userRepo.On("GetByID", mock.Anything, int64(2)).Return(&domain.User{ID: 2, Activated: true}, nil)
+		auditRepo.On("Insert", mock.Anything, mock.AnythingOfType("*domain.AuditEntry")).Return(errors.E(errors.KindDatabase, errors.Msg("connection refused")))
+
+		adminUsecase := NewAdminUsecase(userRepo, tokenUsecase, auditRepo, nil, nil, zerolog.New(new(bytes.Buffer)), 3*time.Second)
+
+		_, err := adminUsecase.Deactivate(context.TODO(), 1, 2)
+		assert.Error(t, err)
+
+		userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		tokenUsecase.AssertNotCalled(t, "DeleteAllForUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestReactivate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(_repoMock.UserRepository)
+		tokenUsecase := new(_repoMock.TokenUsecase)
+		auditRepo := new(_repoMock.AuditRepository)
+
+		userRepo.On("GetByID", mock.Anything, int64(2)).Return(&domain.User{ID: 2, Deactivated: true}, nil)
+		userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *domain.User) bool { return u.Activated && !u.Deactivated })).Return(nil)
+		auditRepo.On("Insert", mock.Anything, &domain.AuditEntry{ActorID: 1, Action: domain.AuditActionReactivateUser, TargetUserID: 2}).Return(nil)
+
+		adminUsecase := NewAdminUsecase(userRepo, tokenUsecase, auditRepo, nil, nil, zerolog.New(new(bytes.Buffer)), 3*time.Second)
+
+		user, err := adminUsecase.Reactivate(context.TODO(), 1, 2)
+		assert.NoError(t, err)
+		assert.True(t, user.Activated)
+		assert.False(t, user.Deactivated)
+
+		userRepo.AssertExpectations(t)
+		auditRepo.AssertExpectations(t)
+	})
+}
+
+func TestForcePasswordReset(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(_repoMock.UserRepository)
+		tokenUsecase := new(_repoMock.TokenUsecase)
+		auditRepo := new(_repoMock.AuditRepository)
+
+		pool := naivepool.New(1, 1, 1)
+		poolCtx, poolCancel := context.WithCancel(context.Background())
+		pool.Start(poolCtx)
+		defer pool.Wait()
+		defer poolCancel()
+
+		userRepo.On("GetByID", mock.Anything, int64(2)).Return(&domain.User{ID: 2, Activated: true}, nil)
+		userRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
+		auditRepo.On("Insert", mock.Anything, &domain.AuditEntry{ActorID: 1, Action: domain.AuditActionResetPassword, TargetUserID: 2}).Return(nil)
+		tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeAuthentication, int64(2)).Return(nil)
+		tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeRefresh, int64(2)).Return(nil)
+		tokenUsecase.On("DeleteAllPersonalTokens", mock.Anything, int64(2)).Return(nil)
+		tokenUsecase.On("Insert", mock.Anything, mock.MatchedBy(func(token *domain.Token) bool {
+			return token.UserID == 2 && token.Scope == domain.ScopePasswordReset
+		})).Return(nil)
+
+		adminUsecase := NewAdminUsecase(userRepo, tokenUsecase, auditRepo, pool, mailer.New(&config.Smtp{}), zerolog.New(new(bytes.Buffer)), 3*time.Second)
+
+		err := adminUsecase.ForcePasswordReset(context.TODO(), 1, 2)
+		assert.NoError(t, err)
+
+		userRepo.AssertExpectations(t)
+		tokenUsecase.AssertExpectations(t)
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fail when audit log can't be written", func(t *testing.T) {
+		userRepo := new(_repoMock.UserRepository)
+		tokenUsecase := new(_repoMock.TokenUsecase)
+		auditRepo := new(_repoMock.AuditRepository)
+
+		userRepo.On("GetByID", mock.Anything, int64(2)).Return(&domain.User{ID: 2, Activated: true}, nil)
+		auditRepo.On("Insert", mock.Anything, mock.AnythingOfType("*domain.AuditEntry")).Return(errors.E(errors.KindDatabase, errors.Msg("connection refused")))
+
+		adminUsecase := NewAdminUsecase(userRepo, tokenUsecase, auditRepo, nil, nil, zerolog.New(new(bytes.Buffer)), 3*time.Second)
+
+		err := adminUsecase.ForcePasswordReset(context.TODO(), 1, 2)
+		assert.Error(t, err)
+
+		userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		tokenUsecase.AssertNotCalled(t, "DeleteAllForUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestImpersonate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(_repoMock.UserRepository)
+		tokenUsecase := new(_repoMock.TokenUsecase)
+		auditRepo := new(_repoMock.AuditRepository)
+
+		userRepo.On("GetByID", mock.Anything, int64(2)).Return(&domain.User{ID: 2}, nil)
+		auditRepo.On("Insert", mock.Anything, &domain.AuditEntry{ActorID: 1, Action: domain.AuditActionImpersonate, TargetUserID: 2}).Return(nil)
+		tokenUsecase.On("Insert", mock.Anything, mock.AnythingOfType("*domain.Token")).Return(nil)
+
+		adminUsecase := NewAdminUsecase(userRepo, tokenUsecase, auditRepo, nil, nil, zerolog.New(new(bytes.Buffer)), 3*time.Second)
+
+		token, err := adminUsecase.Impersonate(context.TODO(), 1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), token.UserID)
+		assert.Equal(t, domain.ScopeAuthentication, token.Scope)
+		assert.WithinDuration(t, time.Now().Add(impersonationTTL), token.Expiry, time.Minute)
+
+		auditRepo.AssertExpectations(t)
+		tokenUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Fail when audit log can't be written", func(t *testing.T) {
+		userRepo := new(_repoMock.UserRepository)
+		tokenUsecase := new(_repoMock.TokenUsecase)
+		auditRepo := new(_repoMock.AuditRepository)
+
+		userRepo.On("GetByID", mock.Anything, int64(2)).Return(&domain.User{ID: 2}, nil)
+		auditRepo.On("Insert", mock.Anything, mock.AnythingOfType("*domain.AuditEntry")).Return(errors.E(errors.KindDatabase, errors.Msg("connection refused")))
+
+		adminUsecase := NewAdminUsecase(userRepo, tokenUsecase, auditRepo, nil, nil, zerolog.New(new(bytes.Buffer)), 3*time.Second)
+
+		_, err := adminUsecase.Impersonate(context.TODO(), 1, 2)
+		assert.Error(t, err)
+
+		tokenUsecase.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(_repoMock.UserRepository)
+		auditRepo := new(_repoMock.AuditRepository)
+
+		userRepo.On("GetByID", mock.Anything, int64(2)).Return(&domain.User{ID: 2}, nil)
+		userRepo.On("Delete", mock.Anything, int64(2)).Return(nil)
+		auditRepo.On("Insert", mock.Anything, &domain.AuditEntry{ActorID: 1, Action: domain.AuditActionDeleteUser, TargetUserID: 2}).Return(nil)
+
+		adminUsecase := NewAdminUsecase(userRepo, new(_repoMock.TokenUsecase), auditRepo, nil, nil, zerolog.New(new(bytes.Buffer)), 3*time.Second)
+
+		assert.NoError(t, adminUsecase.DeleteUser(context.TODO(), 1, 2))
+
+		userRepo.AssertExpectations(t)
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fail when audit log can't be written", func(t *testing.T) {
+		userRepo := new(_repoMock.UserRepository)
+		auditRepo := new(_repoMock.AuditRepository)
+
+		userRepo.On("GetByID", mock.Anything, int64(2)).Return(&domain.User{ID: 2}, nil)
+		auditRepo.On("Insert", mock.Anything, mock.AnythingOfType("*domain.AuditEntry")).Return(errors.E(errors.KindDatabase, errors.Msg("connection refused")))
+
+		adminUsecase := NewAdminUsecase(userRepo, new(_repoMock.TokenUsecase), auditRepo, nil, nil, zerolog.New(new(bytes.Buffer)), 3*time.Second)
+
+		assert.Error(t, adminUsecase.DeleteUser(context.TODO(), 1, 2))
+
+		userRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
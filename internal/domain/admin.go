@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Actions recorded in the audit log when an admin manages a user.
+const (
+	AuditActionDeactivateUser = "user.deactivate"
+	AuditActionReactivateUser = "user.reactivate"
+	AuditActionResetPassword  = "user.reset_password"
+	AuditActionDeleteUser     = "user.delete"
+	AuditActionImpersonate    = "user.impersonate"
+)
+
+// AuditEntry records an action taken by an admin on a user. The ids are kept
+// after the users are deleted, so that the audit log is never lost.
+type AuditEntry struct {
+	ID           int64     `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	ActorID      int64     `json:"actor_id"`       // integer ID of the admin who took the action
+	Action       string    `json:"action"`         // One of the AuditAction constants
+	TargetUserID int64     `json:"target_user_id"` // integer ID of the user the action was taken on
+}
+
+// AdminUsecase lets admins manage the users, every method which changes a user
+// takes the id of the acting admin, so that the action can be audited.
+type AdminUsecase interface {
+	GetAllUsers(ctx context.Context, search string, filters Filters) ([]*User, Metadata, error)
+	GetUser(ctx context.Context, userID int64) (*User, error)
+	Deactivate(ctx context.Context, adminID int64, userID int64) (*User, error)
+	Reactivate(ctx context.Context, adminID int64, userID int64) (*User, error)
+	ForcePasswordReset(ctx context.Context, adminID int64, userID int64) error
+	DeleteUser(ctx context.Context, adminID int64, userID int64) error
+	Impersonate(ctx context.Context, adminID int64, userID int64) (*Token, error)
+}
+
+type AuditRepository interface {
+	Insert(ctx context.Context, entry *AuditEntry) error
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"time"
 )
 
 var (
@@ -10,4 +11,18 @@ var (
 	ErrEditConflict       = errors.New("edit conflict")       // Edit conflict while manipulating database.
 	ErrInvalidCredentials = errors.New("invalid credentials") // Edit conflict while manipulating database.
 	ErrFailedValidation   = errors.New("failed validation")   //  Failed validation error.
+	ErrNotPermitted       = errors.New("not permitted")       // The user doesn't have the necessary permissions.
+	ErrDuplicateSlug      = errors.New("duplicate slug")      // Duplicate workspace slug error.
+	ErrInactiveAccount    = errors.New("inactive account")    // The user account hasn't been activated.
+	ErrRateLimitExceeded  = errors.New("rate limit exceeded") // The operation has been performed too often.
 )
+
+// RetryAfterError is ErrRateLimitExceeded which tells how long the client has to wait
+// before trying again.
+type RetryAfterError struct {
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string { return ErrRateLimitExceeded.Error() }
+
+func (e *RetryAfterError) Unwrap() error { return ErrRateLimitExceeded }
@@ -145,6 +145,9 @@ const (
 	KindFailedValidation               //  Failed validation error.
 	KindInternal                       // Internal server error.
 	KindDatabase                       // Error happened while querying database, this should be treated as subset of internal error and logged it carefully.
+	KindNotPermitted                   // The user doesn't have the necessary permissions to perform the operation.
+	KindInactiveAccount                // The user account hasn't been activated.
+	KindRateLimitExceeded              // The operation has been performed too often.
 )
 
 func (k Kind) String() string {
@@ -165,6 +168,12 @@ func (k Kind) String() string {
 		return "kind internal server error"
 	case KindDatabase:
 		return "kind database error"
+	case KindNotPermitted:
+		return "kind not permitted"
+	case KindInactiveAccount:
+		return "kind inactive account"
+	case KindRateLimitExceeded:
+		return "kind rate limit exceeded"
 	}
 	return "unknown error kind"
 }
@@ -293,3 +302,30 @@ func KindIs(err error, kind Kind) bool {
 		panic(fmt.Sprintf("want err has the type *Error, got %T", err))
 	}
 }
+
+// KindOf returns the outer-most kind specified in the error chain of err,
+// or KindOther if there's none or err doesn't have the type *Error.
+func KindOf(err error) Kind {
+	e, ok := err.(*Error)
+	for ok && e != nil {
+		if e.Kind != KindOther {
+			return e.Kind
+		}
+		e, ok = e.Err.(*Error)
+	}
+	return KindOther
+}
+
+// Origin returns the inner-most operation specified in the error chain of err,
+// which is where the error happened, or "" if err doesn't have the type *Error.
+func Origin(err error) Op {
+	var op Op
+	e, ok := err.(*Error)
+	for ok && e != nil {
+		if e.Op != "" {
+			op = e.Op
+		}
+		e, ok = e.Err.(*Error)
+	}
+	return op
+}
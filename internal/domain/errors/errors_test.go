@@ -149,3 +149,23 @@ func TestKindIs(t *testing.T) {
 		_ = KindIs(sql.ErrNoRows, KindInternal)
 	})
 }
+
+func TestKindOf(t *testing.T) {
+	inner := E(Op("inner error"), KindFailedValidation, errors.New("something goes wrong"))
+	outer := E(Op("outer error"), E(Op("middle error"), inner))
+	assert.Equal(t, KindFailedValidation, KindOf(outer), "the outer error with no kind specified should inherit inner error's kind")
+
+	outer = E(Op("outer error"), KindInternal, inner)
+	assert.Equal(t, KindInternal, KindOf(outer), "the outer-most kind should be taken")
+
+	assert.Equal(t, KindOther, KindOf(sql.ErrNoRows))
+	assert.Equal(t, KindOther, KindOf(nil))
+}
+
+func TestOrigin(t *testing.T) {
+	inner := E(Op("inner error"), errors.New("something goes wrong"))
+	outer := E(Op("outer error"), E(KindInternal, inner))
+	assert.Equal(t, Op("inner error"), Origin(outer))
+
+	assert.Equal(t, Op(""), Origin(sql.ErrNoRows))
+}
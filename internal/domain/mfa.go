@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"strings"
+	"time"
+)
+
+// MFAChallengeTTL is the lifetime of the MFA challenge token issued on login, within which the user
+// has to provide the second factor.
+const MFAChallengeTTL = 5 * time.Minute
+
+// TOTP is the TOTP (RFC 6238) secret of a user. It only protects the login of the user once
+// it's confirmed by the first code generated from it. LastUsedStep is the time step of the
+// last accepted code, so that a code can't be used twice.
+type TOTP struct {
+	UserID       int64
+	Secret       string
+	Confirmed    bool
+	LastUsedStep int64
+}
+
+// TOTPEnrollment is what the user needs to add the TOTP secret to an authenticator app.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type MFAUsecase interface {
+	EnrollTOTP(ctx context.Context, userID int64) (*TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID int64, code string) (recoveryCodes []string, err error)
+	DisableTOTP(ctx context.Context, userID int64, password string) error
+	Enabled(ctx context.Context, userID int64) (bool, error)
+	Verify(ctx context.Context, userID int64, code string) error
+}
+
+type MFARepository interface {
+	GetTOTP(ctx context.Context, userID int64) (*TOTP, error)
+	InsertTOTP(ctx context.Context, totp *TOTP) error
+	ConfirmTOTP(ctx context.Context, userID int64, step int64) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) error
+	DeleteTOTP(ctx context.Context, userID int64) error
+	InsertRecoveryCodes(ctx context.Context, userID int64, hashes [][]byte) error
+	DeleteRecoveryCode(ctx context.Context, userID int64, hash []byte) error
+}
+
+// RecoveryCodeCount is the number of recovery codes generated when TOTP is confirmed.
+const RecoveryCodeCount = 10
+
+// GenerateRecoveryCode generates a one-time recovery code which looks like "abcde-fghij".
+func GenerateRecoveryCode() (string, error) {
+	randomBytes := make([]byte, 7)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes))[:10]
+
+	return code[:5] + "-" + code[5:], nil
+}
+
+// IsRecoveryCode reports whether the code looks like a recovery code rather than a TOTP code.
+func IsRecoveryCode(code string) bool {
+	return strings.Contains(code, "-")
+}
+
+// HashRecoveryCode returns the hash of the recovery code stored in database. Recovery codes
+// are case insensitive, since users may type them in by hand.
+func HashRecoveryCode(code string) []byte {
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hash[:]
+}
@@ -28,6 +28,20 @@ func (_m *TaskRepository) Delete(ctx context.Context, userID int64, taskID int64
 	return r0
 }
 
+// DeleteShare provides a mock function with given fields: ctx, taskID, userID
+func (_m *TaskRepository) DeleteShare(ctx context.Context, taskID int64, userID int64) error {
+	ret := _m.Called(ctx, taskID, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, taskID, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetAll provides a mock function with given fields: ctx, userID, title, filters
 func (_m *TaskRepository) GetAll(ctx context.Context, userID int64, title string, filters domain.Filters) ([]*domain.Task, domain.Metadata, error) {
 	ret := _m.Called(ctx, userID, title, filters)
@@ -58,6 +72,66 @@ func (_m *TaskRepository) GetAll(ctx context.Context, userID int64, title string
 	return r0, r1, r2
 }
 
+// GetAllAssigned provides a mock function with given fields: ctx, userID, assigneeID, title, filters
+func (_m *TaskRepository) GetAllAssigned(ctx context.Context, userID int64, assigneeID int64, title string, filters domain.Filters) ([]*domain.Task, domain.Metadata, error) {
+	ret := _m.Called(ctx, userID, assigneeID, title, filters)
+
+	var r0 []*domain.Task
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, domain.Filters) []*domain.Task); ok {
+		r0 = rf(ctx, userID, assigneeID, title, filters)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Task)
+		}
+	}
+
+	var r1 domain.Metadata
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, domain.Filters) domain.Metadata); ok {
+		r1 = rf(ctx, userID, assigneeID, title, filters)
+	} else {
+		r1 = ret.Get(1).(domain.Metadata)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int64, int64, string, domain.Filters) error); ok {
+		r2 = rf(ctx, userID, assigneeID, title, filters)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetAllShared provides a mock function with given fields: ctx, userID, title, filters
+func (_m *TaskRepository) GetAllShared(ctx context.Context, userID int64, title string, filters domain.Filters) ([]*domain.Task, domain.Metadata, error) {
+	ret := _m.Called(ctx, userID, title, filters)
+
+	var r0 []*domain.Task
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, domain.Filters) []*domain.Task); ok {
+		r0 = rf(ctx, userID, title, filters)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Task)
+		}
+	}
+
+	var r1 domain.Metadata
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, domain.Filters) domain.Metadata); ok {
+		r1 = rf(ctx, userID, title, filters)
+	} else {
+		r1 = ret.Get(1).(domain.Metadata)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int64, string, domain.Filters) error); ok {
+		r2 = rf(ctx, userID, title, filters)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetByID provides a mock function with given fields: ctx, userID, taskID
 func (_m *TaskRepository) GetByID(ctx context.Context, userID int64, taskID int64) (*domain.Task, error) {
 	ret := _m.Called(ctx, userID, taskID)
@@ -81,6 +155,29 @@ func (_m *TaskRepository) GetByID(ctx context.Context, userID int64, taskID int6
 	return r0, r1
 }
 
+// GetShares provides a mock function with given fields: ctx, taskID
+func (_m *TaskRepository) GetShares(ctx context.Context, taskID int64) ([]*domain.TaskShare, error) {
+	ret := _m.Called(ctx, taskID)
+
+	var r0 []*domain.TaskShare
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*domain.TaskShare); ok {
+		r0 = rf(ctx, taskID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.TaskShare)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, taskID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Insert provides a mock function with given fields: ctx, userID, task
 func (_m *TaskRepository) Insert(ctx context.Context, userID int64, task *domain.Task) error {
 	ret := _m.Called(ctx, userID, task)
@@ -95,6 +192,20 @@ func (_m *TaskRepository) Insert(ctx context.Context, userID int64, task *domain
 	return r0
 }
 
+// InsertShare provides a mock function with given fields: ctx, share
+func (_m *TaskRepository) InsertShare(ctx context.Context, share *domain.TaskShare) error {
+	ret := _m.Called(ctx, share)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.TaskShare) error); ok {
+		r0 = rf(ctx, share)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: ctx, task
 func (_m *TaskRepository) Update(ctx context.Context, task *domain.Task) error {
 	ret := _m.Called(ctx, task)
@@ -108,3 +219,17 @@ func (_m *TaskRepository) Update(ctx context.Context, task *domain.Task) error {
 
 	return r0
 }
+
+// UpdateAssignee provides a mock function with given fields: ctx, taskID, assigneeID
+func (_m *TaskRepository) UpdateAssignee(ctx context.Context, taskID int64, assigneeID int64) error {
+	ret := _m.Called(ctx, taskID, assigneeID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, taskID, assigneeID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -14,6 +14,29 @@ type TaskUsecase struct {
 	mock.Mock
 }
 
+// Assign provides a mock function with given fields: ctx, userID, taskID, email
+func (_m *TaskUsecase) Assign(ctx context.Context, userID int64, taskID int64, email string) (*domain.Task, error) {
+	ret := _m.Called(ctx, userID, taskID, email)
+
+	var r0 *domain.Task
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) *domain.Task); ok {
+		r0 = rf(ctx, userID, taskID, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string) error); ok {
+		r1 = rf(ctx, userID, taskID, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Delete provides a mock function with given fields: ctx, userID, taskID
 func (_m *TaskUsecase) Delete(ctx context.Context, userID int64, taskID int64) error {
 	ret := _m.Called(ctx, userID, taskID)
@@ -58,6 +81,66 @@ func (_m *TaskUsecase) GetAll(ctx context.Context, userID int64, title string, f
 	return r0, r1, r2
 }
 
+// GetAllAssigned provides a mock function with given fields: ctx, userID, assigneeID, title, filters
+func (_m *TaskUsecase) GetAllAssigned(ctx context.Context, userID int64, assigneeID int64, title string, filters domain.Filters) ([]*domain.Task, domain.Metadata, error) {
+	ret := _m.Called(ctx, userID, assigneeID, title, filters)
+
+	var r0 []*domain.Task
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, domain.Filters) []*domain.Task); ok {
+		r0 = rf(ctx, userID, assigneeID, title, filters)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Task)
+		}
+	}
+
+	var r1 domain.Metadata
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, domain.Filters) domain.Metadata); ok {
+		r1 = rf(ctx, userID, assigneeID, title, filters)
+	} else {
+		r1 = ret.Get(1).(domain.Metadata)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int64, int64, string, domain.Filters) error); ok {
+		r2 = rf(ctx, userID, assigneeID, title, filters)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetAllShared provides a mock function with given fields: ctx, userID, title, filters
+func (_m *TaskUsecase) GetAllShared(ctx context.Context, userID int64, title string, filters domain.Filters) ([]*domain.Task, domain.Metadata, error) {
+	ret := _m.Called(ctx, userID, title, filters)
+
+	var r0 []*domain.Task
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, domain.Filters) []*domain.Task); ok {
+		r0 = rf(ctx, userID, title, filters)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Task)
+		}
+	}
+
+	var r1 domain.Metadata
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, domain.Filters) domain.Metadata); ok {
+		r1 = rf(ctx, userID, title, filters)
+	} else {
+		r1 = ret.Get(1).(domain.Metadata)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int64, string, domain.Filters) error); ok {
+		r2 = rf(ctx, userID, title, filters)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetByID provides a mock function with given fields: ctx, userID, taskID
 func (_m *TaskUsecase) GetByID(ctx context.Context, userID int64, taskID int64) (*domain.Task, error) {
 	ret := _m.Called(ctx, userID, taskID)
@@ -81,6 +164,29 @@ func (_m *TaskUsecase) GetByID(ctx context.Context, userID int64, taskID int64)
 	return r0, r1
 }
 
+// GetShares provides a mock function with given fields: ctx, userID, taskID
+func (_m *TaskUsecase) GetShares(ctx context.Context, userID int64, taskID int64) ([]*domain.TaskShare, error) {
+	ret := _m.Called(ctx, userID, taskID)
+
+	var r0 []*domain.TaskShare
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) []*domain.TaskShare); ok {
+		r0 = rf(ctx, userID, taskID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.TaskShare)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userID, taskID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Insert provides a mock function with given fields: ctx, userID, task
 func (_m *TaskUsecase) Insert(ctx context.Context, userID int64, task *domain.Task) error {
 	ret := _m.Called(ctx, userID, task)
@@ -95,13 +201,73 @@ func (_m *TaskUsecase) Insert(ctx context.Context, userID int64, task *domain.Ta
 	return r0
 }
 
-// Update provides a mock function with given fields: ctx, task
-func (_m *TaskUsecase) Update(ctx context.Context, task *domain.Task) error {
-	ret := _m.Called(ctx, task)
+// Share provides a mock function with given fields: ctx, userID, taskID, email, role
+func (_m *TaskUsecase) Share(ctx context.Context, userID int64, taskID int64, email string, role string) (*domain.TaskShare, error) {
+	ret := _m.Called(ctx, userID, taskID, email, role)
+
+	var r0 *domain.TaskShare
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) *domain.TaskShare); ok {
+		r0 = rf(ctx, userID, taskID, email, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TaskShare)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, string) error); ok {
+		r1 = rf(ctx, userID, taskID, email, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Unassign provides a mock function with given fields: ctx, userID, taskID
+func (_m *TaskUsecase) Unassign(ctx context.Context, userID int64, taskID int64) (*domain.Task, error) {
+	ret := _m.Called(ctx, userID, taskID)
+
+	var r0 *domain.Task
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *domain.Task); ok {
+		r0 = rf(ctx, userID, taskID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userID, taskID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Unshare provides a mock function with given fields: ctx, userID, taskID, shareUserID
+func (_m *TaskUsecase) Unshare(ctx context.Context, userID int64, taskID int64, shareUserID int64) error {
+	ret := _m.Called(ctx, userID, taskID, shareUserID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) error); ok {
+		r0 = rf(ctx, userID, taskID, shareUserID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, userID, task
+func (_m *TaskUsecase) Update(ctx context.Context, userID int64, task *domain.Task) error {
+	ret := _m.Called(ctx, userID, task)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *domain.Task) error); ok {
-		r0 = rf(ctx, task)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *domain.Task) error); ok {
+		r0 = rf(ctx, userID, task)
 	} else {
 		r0 = ret.Error(0)
 	}
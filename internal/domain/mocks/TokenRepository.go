@@ -14,6 +14,20 @@ type TokenRepository struct {
 	mock.Mock
 }
 
+// Delete provides a mock function with given fields: ctx, scope, tokenPlaintext
+func (_m *TokenRepository) Delete(ctx context.Context, scope string, tokenPlaintext string) error {
+	ret := _m.Called(ctx, scope, tokenPlaintext)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, scope, tokenPlaintext)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteAllForUser provides a mock function with given fields: ctx, scope, userID
 func (_m *TokenRepository) DeleteAllForUser(ctx context.Context, scope string, userID int64) error {
 	ret := _m.Called(ctx, scope, userID)
@@ -28,6 +42,101 @@ func (_m *TokenRepository) DeleteAllForUser(ctx context.Context, scope string, u
 	return r0
 }
 
+// DeleteExpired provides a mock function with given fields: ctx, limit
+func (_m *TokenRepository) DeleteExpired(ctx context.Context, limit int) (int64, error) {
+	ret := _m.Called(ctx, limit)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, int) int64); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteFamily provides a mock function with given fields: ctx, scope, family
+func (_m *TokenRepository) DeleteFamily(ctx context.Context, scope string, family []byte) error {
+	ret := _m.Called(ctx, scope, family)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) error); ok {
+		r0 = rf(ctx, scope, family)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteForUser provides a mock function with given fields: ctx, scope, userID, tokenID
+func (_m *TokenRepository) DeleteForUser(ctx context.Context, scope string, userID int64, tokenID int64) error {
+	ret := _m.Called(ctx, scope, userID, tokenID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int64) error); ok {
+		r0 = rf(ctx, scope, userID, tokenID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Get provides a mock function with given fields: ctx, scope, tokenPlaintext
+func (_m *TokenRepository) Get(ctx context.Context, scope string, tokenPlaintext string) (*domain.Token, error) {
+	ret := _m.Called(ctx, scope, tokenPlaintext)
+
+	var r0 *domain.Token
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.Token); ok {
+		r0 = rf(ctx, scope, tokenPlaintext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Token)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, scope, tokenPlaintext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllForUser provides a mock function with given fields: ctx, scope, userID
+func (_m *TokenRepository) GetAllForUser(ctx context.Context, scope string, userID int64) ([]*domain.Session, error) {
+	ret := _m.Called(ctx, scope, userID)
+
+	var r0 []*domain.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) []*domain.Session); ok {
+		r0 = rf(ctx, scope, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, scope, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Insert provides a mock function with given fields: ctx, token
 func (_m *TokenRepository) Insert(ctx context.Context, token *domain.Token) error {
 	ret := _m.Called(ctx, token)
@@ -41,3 +150,31 @@ func (_m *TokenRepository) Insert(ctx context.Context, token *domain.Token) erro
 
 	return r0
 }
+
+// Rotate provides a mock function with given fields: ctx, token
+func (_m *TokenRepository) Rotate(ctx context.Context, token *domain.Token) error {
+	ret := _m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Token) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Touch provides a mock function with given fields: ctx, scope, tokenPlaintext
+func (_m *TokenRepository) Touch(ctx context.Context, scope string, tokenPlaintext string) error {
+	ret := _m.Called(ctx, scope, tokenPlaintext)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, scope, tokenPlaintext)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -7,6 +7,7 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 	domain "github.com/unknowntpo/todos/internal/domain"
+	jwt "github.com/unknowntpo/todos/pkg/jwt"
 )
 
 // TokenUsecase is an autogenerated mock type for the TokenUsecase type
@@ -14,6 +15,57 @@ type TokenUsecase struct {
 	mock.Mock
 }
 
+// AuthenticatePersonalToken provides a mock function with given fields: ctx, tokenPlaintext
+func (_m *TokenUsecase) AuthenticatePersonalToken(ctx context.Context, tokenPlaintext string) (*domain.PersonalAccessToken, error) {
+	ret := _m.Called(ctx, tokenPlaintext)
+
+	var r0 *domain.PersonalAccessToken
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.PersonalAccessToken); ok {
+		r0 = rf(ctx, tokenPlaintext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.PersonalAccessToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenPlaintext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreatePersonalToken provides a mock function with given fields: ctx, token
+func (_m *TokenUsecase) CreatePersonalToken(ctx context.Context, token *domain.PersonalAccessToken) error {
+	ret := _m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.PersonalAccessToken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: ctx, scope, tokenPlaintext
+func (_m *TokenUsecase) Delete(ctx context.Context, scope string, tokenPlaintext string) error {
+	ret := _m.Called(ctx, scope, tokenPlaintext)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, scope, tokenPlaintext)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteAllForUser provides a mock function with given fields: ctx, scope, userID
 func (_m *TokenUsecase) DeleteAllForUser(ctx context.Context, scope string, userID int64) error {
 	ret := _m.Called(ctx, scope, userID)
@@ -28,6 +80,115 @@ func (_m *TokenUsecase) DeleteAllForUser(ctx context.Context, scope string, user
 	return r0
 }
 
+// DeleteAllPersonalTokens provides a mock function with given fields: ctx, userID
+func (_m *TokenUsecase) DeleteAllPersonalTokens(ctx context.Context, userID int64) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteExpired provides a mock function with given fields: ctx, batchSize
+func (_m *TokenUsecase) DeleteExpired(ctx context.Context, batchSize int) (int64, error) {
+	ret := _m.Called(ctx, batchSize)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, int) int64); ok {
+		r0 = rf(ctx, batchSize)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, batchSize)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeletePersonalToken provides a mock function with given fields: ctx, userID, tokenID
+func (_m *TokenUsecase) DeletePersonalToken(ctx context.Context, userID int64, tokenID int64) error {
+	ret := _m.Called(ctx, userID, tokenID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userID, tokenID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteSession provides a mock function with given fields: ctx, userID, sessionID
+func (_m *TokenUsecase) DeleteSession(ctx context.Context, userID int64, sessionID int64) error {
+	ret := _m.Called(ctx, userID, sessionID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userID, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetPersonalTokens provides a mock function with given fields: ctx, userID
+func (_m *TokenUsecase) GetPersonalTokens(ctx context.Context, userID int64) ([]*domain.PersonalAccessToken, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []*domain.PersonalAccessToken
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*domain.PersonalAccessToken); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.PersonalAccessToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSessions provides a mock function with given fields: ctx, userID, currentTokenPlaintext
+func (_m *TokenUsecase) GetSessions(ctx context.Context, userID int64, currentTokenPlaintext string) ([]*domain.Session, error) {
+	ret := _m.Called(ctx, userID, currentTokenPlaintext)
+
+	var r0 []*domain.Session
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) []*domain.Session); ok {
+		r0 = rf(ctx, userID, currentTokenPlaintext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, userID, currentTokenPlaintext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Insert provides a mock function with given fields: ctx, token
 func (_m *TokenUsecase) Insert(ctx context.Context, token *domain.Token) error {
 	ret := _m.Called(ctx, token)
@@ -41,3 +202,120 @@ func (_m *TokenUsecase) Insert(ctx context.Context, token *domain.Token) error {
 
 	return r0
 }
+
+// IssueAuthTokens provides a mock function with given fields: ctx, userID
+func (_m *TokenUsecase) IssueAuthTokens(ctx context.Context, userID int64) (*domain.Token, *domain.Token, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *domain.Token
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.Token); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Token)
+		}
+	}
+
+	var r1 *domain.Token
+	if rf, ok := ret.Get(1).(func(context.Context, int64) *domain.Token); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*domain.Token)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int64) error); ok {
+		r2 = rf(ctx, userID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// JWKS provides a mock function with given fields:
+func (_m *TokenUsecase) JWKS() *jwt.JWKS {
+	ret := _m.Called()
+
+	var r0 *jwt.JWKS
+	if rf, ok := ret.Get(0).(func() *jwt.JWKS); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*jwt.JWKS)
+		}
+	}
+
+	return r0
+}
+
+// Refresh provides a mock function with given fields: ctx, refreshTokenPlaintext
+func (_m *TokenUsecase) Refresh(ctx context.Context, refreshTokenPlaintext string) (*domain.Token, *domain.Token, error) {
+	ret := _m.Called(ctx, refreshTokenPlaintext)
+
+	var r0 *domain.Token
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Token); ok {
+		r0 = rf(ctx, refreshTokenPlaintext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Token)
+		}
+	}
+
+	var r1 *domain.Token
+	if rf, ok := ret.Get(1).(func(context.Context, string) *domain.Token); ok {
+		r1 = rf(ctx, refreshTokenPlaintext)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*domain.Token)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, refreshTokenPlaintext)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Touch provides a mock function with given fields: ctx, tokenPlaintext
+func (_m *TokenUsecase) Touch(ctx context.Context, tokenPlaintext string) error {
+	ret := _m.Called(ctx, tokenPlaintext)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, tokenPlaintext)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Verify provides a mock function with given fields: tokenPlaintext
+func (_m *TokenUsecase) Verify(tokenPlaintext string) (*domain.Token, error) {
+	ret := _m.Called(tokenPlaintext)
+
+	var r0 *domain.Token
+	if rf, ok := ret.Get(0).(func(string) *domain.Token); ok {
+		r0 = rf(tokenPlaintext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Token)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tokenPlaintext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
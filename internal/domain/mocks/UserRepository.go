@@ -14,6 +14,50 @@ type UserRepository struct {
 	mock.Mock
 }
 
+// Delete provides a mock function with given fields: ctx, userID
+func (_m *UserRepository) Delete(ctx context.Context, userID int64) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAll provides a mock function with given fields: ctx, search, filters
+func (_m *UserRepository) GetAll(ctx context.Context, search string, filters domain.Filters) ([]*domain.User, domain.Metadata, error) {
+	ret := _m.Called(ctx, search, filters)
+
+	var r0 []*domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Filters) []*domain.User); ok {
+		r0 = rf(ctx, search, filters)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.User)
+		}
+	}
+
+	var r1 domain.Metadata
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.Filters) domain.Metadata); ok {
+		r1 = rf(ctx, search, filters)
+	} else {
+		r1 = ret.Get(1).(domain.Metadata)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, domain.Filters) error); ok {
+		r2 = rf(ctx, search, filters)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetByEmail provides a mock function with given fields: ctx, email
 func (_m *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	ret := _m.Called(ctx, email)
@@ -37,6 +81,29 @@ func (_m *UserRepository) GetByEmail(ctx context.Context, email string) (*domain
 	return r0, r1
 }
 
+// GetByID provides a mock function with given fields: ctx, userID
+func (_m *UserRepository) GetByID(ctx context.Context, userID int64) (*domain.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetForToken provides a mock function with given fields: ctx, tokenScope, tokenPlaintext
 func (_m *UserRepository) GetForToken(ctx context.Context, tokenScope string, tokenPlaintext string) (*domain.User, error) {
 	ret := _m.Called(ctx, tokenScope, tokenPlaintext)
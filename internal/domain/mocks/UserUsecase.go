@@ -60,6 +60,98 @@ func (_m *UserUsecase) Authenticate(ctx context.Context, tokenScope string, toke
 	return r0, r1
 }
 
+// CompleteLogin provides a mock function with given fields: ctx, challengePlaintext, code
+func (_m *UserUsecase) CompleteLogin(ctx context.Context, challengePlaintext string, code string) (*domain.Token, *domain.Token, error) {
+	ret := _m.Called(ctx, challengePlaintext, code)
+
+	var r0 *domain.Token
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.Token); ok {
+		r0 = rf(ctx, challengePlaintext, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Token)
+		}
+	}
+
+	var r1 *domain.Token
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) *domain.Token); ok {
+		r1 = rf(ctx, challengePlaintext, code)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*domain.Token)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(ctx, challengePlaintext, code)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ConfirmEmailChange provides a mock function with given fields: ctx, tokenPlaintext
+func (_m *UserUsecase) ConfirmEmailChange(ctx context.Context, tokenPlaintext string) (*domain.User, error) {
+	ret := _m.Called(ctx, tokenPlaintext)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(ctx, tokenPlaintext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenPlaintext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteAccount provides a mock function with given fields: ctx, user, password
+func (_m *UserUsecase) DeleteAccount(ctx context.Context, user *domain.User, password string) error {
+	ret := _m.Called(ctx, user, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, string) error); ok {
+		r0 = rf(ctx, user, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByID provides a mock function with given fields: ctx, userID
+func (_m *UserUsecase) GetByID(ctx context.Context, userID int64) (*domain.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Insert provides a mock function with given fields: ctx, user
 func (_m *UserUsecase) Insert(ctx context.Context, user *domain.User) error {
 	ret := _m.Called(ctx, user)
@@ -75,7 +167,7 @@ func (_m *UserUsecase) Insert(ctx context.Context, user *domain.User) error {
 }
 
 // Login provides a mock function with given fields: ctx, email, password
-func (_m *UserUsecase) Login(ctx context.Context, email string, password string) (*domain.Token, error) {
+func (_m *UserUsecase) Login(ctx context.Context, email string, password string) (*domain.Token, *domain.Token, error) {
 	ret := _m.Called(ctx, email, password)
 
 	var r0 *domain.Token
@@ -87,14 +179,23 @@ func (_m *UserUsecase) Login(ctx context.Context, email string, password string)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+	var r1 *domain.Token
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) *domain.Token); ok {
 		r1 = rf(ctx, email, password)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*domain.Token)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(ctx, email, password)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // Register provides a mock function with given fields: ctx, user
@@ -111,6 +212,57 @@ func (_m *UserUsecase) Register(ctx context.Context, user *domain.User) error {
 	return r0
 }
 
+// RequestPasswordReset provides a mock function with given fields: ctx, email
+func (_m *UserUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+	ret := _m.Called(ctx, email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResendActivation provides a mock function with given fields: ctx, email
+func (_m *UserUsecase) ResendActivation(ctx context.Context, email string) error {
+	ret := _m.Called(ctx, email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: ctx, tokenPlaintext, password
+func (_m *UserUsecase) ResetPassword(ctx context.Context, tokenPlaintext string, password string) (*domain.User, error) {
+	ret := _m.Called(ctx, tokenPlaintext, password)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.User); ok {
+		r0 = rf(ctx, tokenPlaintext, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tokenPlaintext, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ctx, user
 func (_m *UserUsecase) Update(ctx context.Context, user *domain.User) error {
 	ret := _m.Called(ctx, user)
@@ -124,3 +276,17 @@ func (_m *UserUsecase) Update(ctx context.Context, user *domain.User) error {
 
 	return r0
 }
+
+// UpdateAccount provides a mock function with given fields: ctx, user, changes
+func (_m *UserUsecase) UpdateAccount(ctx context.Context, user *domain.User, changes *domain.UserUpdate) error {
+	ret := _m.Called(ctx, user, changes)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, *domain.UserUpdate) error); ok {
+		r0 = rf(ctx, user, changes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
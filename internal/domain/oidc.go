@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"time"
+
+	"github.com/unknowntpo/todos/pkg/oidc"
+)
+
+// OIDCLoginStateTTL is how long the user has to sign in at the identity provider.
+const OIDCLoginStateTTL = 10 * time.Minute
+
+// OIDCIdentity links the account of a user at an identity provider to the user.
+type OIDCIdentity struct {
+	Provider string
+	Subject  string
+	UserID   int64
+}
+
+// OIDCLoginState is kept between redirecting the user to the identity provider and the callback.
+// The plaintext is the state parameter of the authorization request, only its hash is stored.
+type OIDCLoginState struct {
+	Provider  string
+	Nonce     string
+	Verifier  string
+	Expiry    time.Time
+	Plaintext string
+	Hash      []byte
+}
+
+type OIDCUsecase interface {
+	StartLogin(ctx context.Context, provider string) (authURL string, state string, err error)
+	FinishLogin(ctx context.Context, provider, state, code string) (access *Token, refresh *Token, err error)
+}
+
+type OIDCRepository interface {
+	InsertState(ctx context.Context, state *OIDCLoginState) error
+	TakeState(ctx context.Context, provider, statePlaintext string) (*OIDCLoginState, error)
+	GetIdentity(ctx context.Context, provider, subject string) (*OIDCIdentity, error)
+	InsertIdentity(ctx context.Context, identity *OIDCIdentity) error
+}
+
+// GenerateOIDCLoginState generates the state, nonce and PKCE code verifier of a login
+// with the provider.
+func GenerateOIDCLoginState(provider string) (*OIDCLoginState, error) {
+	state := &OIDCLoginState{
+		Provider: provider,
+		Expiry:   time.Now().Add(OIDCLoginStateTTL),
+	}
+
+	var err error
+	for _, s := range []*string{&state.Plaintext, &state.Nonce, &state.Verifier} {
+		*s, err = oidc.GenerateRandom()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hash := sha256.Sum256([]byte(state.Plaintext))
+	state.Hash = hash[:]
+
+	return state, nil
+}
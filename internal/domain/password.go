@@ -1,10 +1,14 @@
 package domain
 
 import (
-	"github.com/pkg/errors"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/unknowntpo/todos/pkg/passhash"
 )
 
+// PasswordHashParams are the algorithm and parameters new password hashes are made with.
+// It's set from the configuration at startup, the hashes made with other parameters still
+// match, and are replaced on the next login.
+var PasswordHashParams = passhash.DefaultParams
+
 // Password is a struct containing the plaintext and hashed
 // versions of the password for a user. The plaintext field is a *pointer* to a string,
 // so that we're able to distinguish between a plaintext password not being present in
@@ -14,10 +18,10 @@ type Password struct {
 	Hash      []byte
 }
 
-// Set calculates the bcrypt hash of a plaintext password, and stores both
+// Set calculates the hash of a plaintext password with PasswordHashParams, and stores both
 // the hash and the plaintext versions in the struct.
 func (p *Password) Set(plaintextPassword string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(plaintextPassword), 12)
+	hash, err := passhash.Hash([]byte(plaintextPassword), PasswordHashParams)
 	if err != nil {
 		return err
 	}
@@ -30,17 +34,13 @@ func (p *Password) Set(plaintextPassword string) error {
 
 // Matches checks whether the provided plaintext password matches the
 // hashed password stored in the struct, returning true if it matches and false
-// otherwise.
+// otherwise. Both Argon2id and bcrypt hashes are supported.
 func (p *Password) Matches(plaintextPassword string) (bool, error) {
-	err := bcrypt.CompareHashAndPassword(p.Hash, []byte(plaintextPassword))
-	if err != nil {
-		switch {
-		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
-			return false, nil
-		default:
-			return false, err
-		}
-	}
+	return passhash.Verify(p.Hash, []byte(plaintextPassword))
+}
 
-	return true, nil
+// NeedsRehash reports whether the hash is made with other parameters than PasswordHashParams,
+// so that it should be replaced by calling Set once the plaintext password is known.
+func (p *Password) NeedsRehash() bool {
+	return passhash.NeedsRehash(p.Hash, PasswordHashParams)
 }
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/unknowntpo/todos/pkg/validator"
+)
+
+// PasswordRule is a rule new passwords have to follow besides the length checked by
+// ValidatePasswordPlaintext. Check returns the reason the password of the user is refused,
+// or the empty string if it's accepted.
+type PasswordRule interface {
+	Check(password string, user *User) string
+}
+
+// PasswordPolicy holds the rules new passwords are checked against by ValidatePasswordPolicy.
+// It's set from the configuration at startup.
+var PasswordPolicy []PasswordRule
+
+// ValidatePasswordPolicy checks the new password of the user against PasswordPolicy, the first rule
+// refusing it is reported as the error of the password field.
+func ValidatePasswordPolicy(v *validator.Validator, password string, user *User) {
+	for _, rule := range PasswordPolicy {
+		if reason := rule.Check(password, user); reason != "" {
+			v.AddError("password", reason)
+			return
+		}
+	}
+}
+
+// MinEntropy refuses the passwords whose entropy estimated by PasswordEntropy is less than
+// the given number of bits.
+type MinEntropy float64
+
+func (m MinEntropy) Check(password string, user *User) string {
+	if PasswordEntropy(password) < float64(m) {
+		return "is too easy to guess, make it longer or use more kinds of characters"
+	}
+	return ""
+}
+
+// PasswordEntropy estimates the entropy of the password in bits, as if each character were
+// picked at random from the character classes the password uses. The characters repeating or
+// continuing a sequence from the previous one, as in "aaa" or "123", count for one bit only.
+func PasswordEntropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	for _, c := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if c.used {
+			pool += c.size
+		}
+	}
+
+	if pool == 0 {
+		return 0
+	}
+
+	perChar := math.Log2(float64(pool))
+
+	var bits float64
+	var prev rune
+	for i, r := range []rune(password) {
+		if d := r - prev; i > 0 && d >= -1 && d <= 1 {
+			bits++
+		} else {
+			bits += perChar
+		}
+		prev = r
+	}
+
+	return bits
+}
+
+// NoPersonalInfo refuses the passwords containing the name or email address of the user,
+// which are the first things to try when guessing it.
+type NoPersonalInfo struct{}
+
+// minPersonalInfoLength is the length of the shortest part of the name or email address
+// which is looked for, shorter ones are too likely to appear by chance.
+const minPersonalInfoLength = 3
+
+func (NoPersonalInfo) Check(password string, user *User) string {
+	if user == nil {
+		return ""
+	}
+
+	password = strings.ToLower(password)
+
+	isSeparator := func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}
+
+	local := user.Email
+	if i := strings.LastIndexByte(local, '@'); i >= 0 {
+		local = local[:i]
+	}
+
+	parts := strings.FieldsFunc(strings.ToLower(user.Name), isSeparator)
+	parts = append(parts, strings.ToLower(local))
+	parts = append(parts, strings.FieldsFunc(strings.ToLower(local), isSeparator)...)
+
+	for _, part := range parts {
+		if len(part) >= minPersonalInfoLength && strings.Contains(password, part) {
+			return "must not contain your name or email address"
+		}
+	}
+
+	return ""
+}
+
+// BreachedPasswords is the set of passwords known to have been exposed in data breaches.
+type BreachedPasswords interface {
+	Contains(password string) bool
+}
+
+// NotBreached refuses the passwords known to have been exposed in data breaches,
+// which are tried first by credential stuffing.
+type NotBreached struct {
+	Passwords BreachedPasswords
+}
+
+func (nb NotBreached) Check(password string, user *User) string {
+	if nb.Passwords.Contains(password) {
+		return "has appeared in a data breach, please choose another one"
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/unknowntpo/todos/pkg/validator"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordEntropy(t *testing.T) {
+	tests := []struct {
+		password string
+		min, max float64
+	}{
+		{"", 0, 0},
+		{"aaaaaaaa", 11, 12},
+		{"12345678", 10, 11},
+		{"abcdefgh", 11, 12},
+		{"pa55word", 37, 38},
+		{"correct horse battery staple", 130, 170},
+		{"Tr0ub4dor&3", 60, 75},
+	}
+
+	for _, tt := range tests {
+		got := PasswordEntropy(tt.password)
+		assert.True(t, got >= tt.min && got <= tt.max, "%q: got %.1f bits, want between %.0f and %.0f", tt.password, got, tt.min, tt.max)
+	}
+}
+
+func TestNoPersonalInfo(t *testing.T) {
+	user := &User{Name: "Alice Smith", Email: "alice.wonder@example.com"}
+
+	for _, password := range []string{"ALICE2021!", "smith-rocks", "wonderwall", "xxalice.wonderxx"} {
+		assert.NotEmpty(t, NoPersonalInfo{}.Check(password, user), password)
+	}
+
+	for _, password := range []string{"correct horse battery staple", "example.com-is-not-personal", "al1ce smi7h"} {
+		assert.Empty(t, NoPersonalInfo{}.Check(password, user), password)
+	}
+
+	// Short parts are too likely to appear by chance.
+	assert.Empty(t, NoPersonalInfo{}.Check("xojoxo", &User{Name: "Jo", Email: "jo@example.com"}))
+}
+
+type breachedSet map[string]bool
+
+func (b breachedSet) Contains(password string) bool { return b[password] }
+
+func TestValidatePasswordPolicy(t *testing.T) {
+	defer func(rules []PasswordRule) { PasswordPolicy = rules }(PasswordPolicy)
+
+	PasswordPolicy = []PasswordRule{
+		NoPersonalInfo{},
+		MinEntropy(36),
+		NotBreached{Passwords: breachedSet{"Tr0ub4dor&3": true}},
+	}
+
+	user := &User{Name: "Alice Smith", Email: "alice@example.com"}
+
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"Accepted", "correct horse battery staple", ""},
+		{"Personal info", "alice-in-wonderland", "must not contain your name or email address"},
+		{"Low entropy", "aaaaaaaaaaaa", "is too easy to guess, make it longer or use more kinds of characters"},
+		{"Breached", "Tr0ub4dor&3", "has appeared in a data breach, please choose another one"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidatePasswordPolicy(v, tt.password, user)
+
+			if tt.want == "" {
+				assert.True(t, v.Valid())
+				return
+			}
+			assert.Equal(t, validator.ValidationErrors{"password": tt.want}, v.Err())
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+)
+
+// Permission codes which can be granted to users.
+const (
+	PermissionTasksRead  = "tasks:read"  // Read tasks
+	PermissionTasksWrite = "tasks:write" // Create, update and delete tasks
+	PermissionAdmin      = "admin"       // Manage users and their permissions
+)
+
+// PermissionCodes contains all permission codes which can be granted to users.
+var PermissionCodes = []string{PermissionTasksRead, PermissionTasksWrite, PermissionAdmin}
+
+// DefaultPermissions contains the permission codes every new user gets on registration.
+var DefaultPermissions = []string{PermissionTasksRead, PermissionTasksWrite}
+
+// Permissions holds the permission codes for a single user.
+type Permissions []string
+
+// Include checks whether the Permissions slice contains a specific permission code.
+func (p Permissions) Include(code string) bool {
+	for i := range p {
+		if code == p[i] {
+			return true
+		}
+	}
+	return false
+}
+
+type PermissionUsecase interface {
+	GetAllForUser(ctx context.Context, userID int64) (Permissions, error)
+	Grant(ctx context.Context, userID int64, codes []string) (Permissions, error)
+	Revoke(ctx context.Context, userID int64, code string) (Permissions, error)
+}
+
+type PermissionRepository interface {
+	GetAllForUser(ctx context.Context, userID int64) (Permissions, error)
+	AddForUser(ctx context.Context, userID int64, codes []string) error
+	DeleteForUser(ctx context.Context, userID int64, code string) error
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"strings"
+	"time"
+)
+
+// personalAccessTokenPrefix tells personal access tokens apart from the other tokens,
+// it also makes leaked ones easy to spot.
+const personalAccessTokenPrefix = "pat_"
+
+// PersonalAccessToken is a named, long-lived token for scripts and CI jobs. Unlike
+// authentication tokens, it's only good for the routes requiring one of its scopes,
+// which are permission codes. The plaintext is only shown once it's created.
+type PersonalAccessToken struct {
+	ID         int64       `json:"id"`
+	Name       string      `json:"name"`
+	Scopes     Permissions `json:"scopes"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Expiry     *time.Time  `json:"expiry"`
+	LastUsedAt *time.Time  `json:"last_used_at"`
+	Plaintext  string      `json:"token,omitempty"`
+	Hash       []byte      `json:"-"`
+	UserID     int64       `json:"-"`
+}
+
+type PersonalAccessTokenRepository interface {
+	Insert(ctx context.Context, token *PersonalAccessToken) error
+	GetAllForUser(ctx context.Context, userID int64) ([]*PersonalAccessToken, error)
+	GetForPlaintext(ctx context.Context, tokenPlaintext string) (*PersonalAccessToken, error)
+	Touch(ctx context.Context, tokenID int64) error
+	DeleteForUser(ctx context.Context, userID int64, tokenID int64) error
+	DeleteAllForUser(ctx context.Context, userID int64) error
+}
+
+// IsPersonalAccessToken reports whether the token is a personal access token.
+func IsPersonalAccessToken(tokenPlaintext string) bool {
+	return strings.HasPrefix(tokenPlaintext, personalAccessTokenPrefix)
+}
+
+// GeneratePersonalAccessToken generates the plaintext and hash of the personal access token.
+func GeneratePersonalAccessToken(token *PersonalAccessToken) error {
+	randomBytes := make([]byte, 20)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return err
+	}
+
+	token.Plaintext = personalAccessTokenPrefix + base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+
+	hash := sha256.Sum256([]byte(token.Plaintext))
+	token.Hash = hash[:]
+
+	return nil
+}
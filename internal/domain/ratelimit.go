@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// RateLimit is a token bucket which holds up to Burst tokens and is refilled with Rate tokens
+// per second. Every request takes a token, and is refused if there's none left.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket.
+type RateLimitResult struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int
+	// Reset is how long it takes for the bucket to be full again.
+	Reset time.Duration
+	// RetryAfter is how long it takes for the next token to be available,
+	// it's zero if the request is allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimiter keeps the token buckets identified by keys. Implementations backed by a shared
+// store enforce the limits across all the instances of the server.
+type RateLimiter interface {
+	// Allow takes a token from the bucket with the key, which is created full if it doesn't exist.
+	Allow(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error)
+	// Peek reports whether a token could be taken from the bucket with the key without taking
+	// it, Remaining is the number of whole tokens in the bucket.
+	Peek(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error)
+}
+
+// Take refills the bucket holding tokens for the elapsed time, and takes a token from it if there's
+// one. It returns the tokens left, and reports whether the token is taken.
+func (l RateLimit) Take(tokens float64, elapsed time.Duration) (left float64, allowed bool) {
+	tokens = l.Fill(tokens, elapsed)
+	if tokens >= 1 {
+		return tokens - 1, true
+	}
+	return tokens, false
+}
+
+// Fill returns the tokens in the bucket holding tokens after refilling it for the elapsed time.
+func (l RateLimit) Fill(tokens float64, elapsed time.Duration) float64 {
+	return math.Min(float64(l.Burst), tokens+elapsed.Seconds()*l.Rate)
+}
+
+// Refill returns how long it takes for the bucket holding tokens to be full again, after which
+// it's no different from a new bucket and can be forgotten.
+func (l RateLimit) Refill(tokens float64) time.Duration {
+	return l.duration(float64(l.Burst) - tokens)
+}
+
+// Result returns the result of taking a token from the bucket, which is left with tokens.
+func (l RateLimit) Result(tokens float64, allowed bool) *RateLimitResult {
+	res := &RateLimitResult{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     l.Refill(tokens),
+	}
+
+	if !allowed {
+		res.RetryAfter = l.duration(1 - tokens)
+	}
+
+	return res
+}
+
+// duration returns how long it takes to refill n tokens.
+func (l RateLimit) duration(n float64) time.Duration {
+	if n <= 0 || l.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(n / l.Rate * float64(time.Second))
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Session is an authentication token seen from the user who owns it, so that
+// the user can tell which device uses it and revoke it. If the authentication tokens
+// are signed, it's the current refresh token of the token family instead.
+type Session struct {
+	ID         int64      `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Expiry     time.Time  `json:"expiry"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	Current    bool       `json:"current"`
+	Hash       []byte     `json:"-"`
+	Family     []byte     `json:"-"`
+}
+
+// Client describes the device a request comes from.
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+type clientContextKey struct{}
+
+// ContextWithClient returns a copy of ctx which carries the client the request comes from.
+// The token repository records it for authentication tokens.
+func ContextWithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientContextKey{}, client)
+}
+
+// ClientFromContext returns the client stored in ctx, if there's no client,
+// the zero value is returned.
+func ClientFromContext(ctx context.Context) Client {
+	client, _ := ctx.Value(clientContextKey{}).(Client)
+	return client
+}
@@ -5,30 +5,81 @@ import (
 	"time"
 )
 
+// Roles a user can have on a task. The owner is the user who created the task,
+// editors and viewers are the users the owner has shared the task with.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+	// RoleAssignee is the role of the user the task is assigned to,
+	// the assignee can read the task and mark it as done.
+	RoleAssignee = "assignee"
+)
+
 // Task represent the data structure of our task object.
 type Task struct {
-	ID        int64     `json:"id"`      // Unique integer ID for the task
-	UserID    int64     `json:"user_id"` // integer ID for the task owner
-	CreatedAt time.Time `json:"-"`       // Timestamp for when the task is added to our database
-	Title     string    `json:"title"`   // task title
-	Content   string    `json:"content"` // task content
-	Done      bool      `json:"done"`    // true if task is done
-	Version   int32     `json:"version"` // The version number starts at 1 and will be incremented each
+	ID          int64     `json:"id"`                     // Unique integer ID for the task
+	UserID      int64     `json:"user_id"`                // integer ID for the task owner
+	AssigneeID  int64     `json:"assignee_id,omitempty"`  // integer ID for the task assignee, 0 if the task isn't assigned
+	WorkspaceID int64     `json:"workspace_id,omitempty"` // integer ID for the workspace of the task, 0 for personal tasks
+	CreatedAt   time.Time `json:"-"`                      // Timestamp for when the task is added to our database
+	Title       string    `json:"title"`                  // task title
+	Content     string    `json:"content"`                // task content
+	Done        bool      `json:"done"`                   // true if task is done
+	Version     int32     `json:"version"`                // The version number starts at 1 and will be incremented each
 	// time the task information is updated
+	Role string `json:"role,omitempty"` // Role of the requesting user on this task
+}
+
+// CanEdit reports whether the requesting user is allowed to modify the task.
+func (t *Task) CanEdit() bool {
+	return t.Role == RoleOwner || t.Role == RoleEditor
+}
+
+// CanComplete reports whether the requesting user is allowed to mark the task as done.
+func (t *Task) CanComplete() bool {
+	return t.CanEdit() || t.Role == RoleAssignee
+}
+
+// IsOwner reports whether the requesting user is the owner of the task.
+func (t *Task) IsOwner() bool {
+	return t.Role == RoleOwner
+}
+
+// TaskShare represents a task shared by its owner with another user.
+type TaskShare struct {
+	TaskID    int64     `json:"task_id"`
+	UserID    int64     `json:"user_id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type TaskUsecase interface {
 	GetAll(ctx context.Context, userID int64, title string, filters Filters) ([]*Task, Metadata, error)
+	GetAllShared(ctx context.Context, userID int64, title string, filters Filters) ([]*Task, Metadata, error)
+	GetAllAssigned(ctx context.Context, userID int64, assigneeID int64, title string, filters Filters) ([]*Task, Metadata, error)
 	GetByID(ctx context.Context, userID int64, taskID int64) (*Task, error)
 	Insert(ctx context.Context, userID int64, task *Task) error
-	Update(ctx context.Context, task *Task) error
+	Update(ctx context.Context, userID int64, task *Task) error
+	Assign(ctx context.Context, userID int64, taskID int64, email string) (*Task, error)
+	Unassign(ctx context.Context, userID int64, taskID int64) (*Task, error)
 	Delete(ctx context.Context, userID int64, taskID int64) error
+	Share(ctx context.Context, userID int64, taskID int64, email, role string) (*TaskShare, error)
+	GetShares(ctx context.Context, userID int64, taskID int64) ([]*TaskShare, error)
+	Unshare(ctx context.Context, userID int64, taskID int64, shareUserID int64) error
 }
 
 type TaskRepository interface {
 	GetAll(ctx context.Context, userID int64, title string, filters Filters) ([]*Task, Metadata, error)
+	GetAllShared(ctx context.Context, userID int64, title string, filters Filters) ([]*Task, Metadata, error)
+	GetAllAssigned(ctx context.Context, userID int64, assigneeID int64, title string, filters Filters) ([]*Task, Metadata, error)
 	GetByID(ctx context.Context, userID int64, taskID int64) (*Task, error)
 	Insert(ctx context.Context, userID int64, task *Task) error
 	Update(ctx context.Context, task *Task) error
+	UpdateAssignee(ctx context.Context, taskID int64, assigneeID int64) error
 	Delete(ctx context.Context, userID int64, taskID int64) error
+	InsertShare(ctx context.Context, share *TaskShare) error
+	GetShares(ctx context.Context, taskID int64) ([]*TaskShare, error)
+	DeleteShare(ctx context.Context, taskID int64, userID int64) error
 }
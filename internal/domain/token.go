@@ -5,30 +5,81 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base32"
+	"strings"
 	"time"
+
+	"github.com/unknowntpo/todos/pkg/jwt"
 )
 
 const (
 	ScopeActivation     = "activation"
 	ScopeAuthentication = "authentication"
+	ScopePasswordReset  = "password-reset"
+	ScopeEmailChange    = "email-change"
+	ScopeRefresh        = "refresh"
+	ScopeMFAChallenge   = "mfa-challenge"
 )
 
+// Family is shared by the authentication and refresh tokens descended from the same login,
+// so that they can be revoked together. Rotated reports whether a refresh token has been
+// exchanged for new tokens already.
 type Token struct {
 	Plaintext string    `json:"token"`
 	Hash      []byte    `json:"-"`
 	UserID    int64     `json:"-"`
 	Expiry    time.Time `json:"expiry"`
 	Scope     string    `json:"-"`
+	Family    []byte    `json:"-"`
+	Rotated   bool      `json:"-"`
 }
 
 type TokenUsecase interface {
 	Insert(ctx context.Context, token *Token) error
+	IssueAuthTokens(ctx context.Context, userID int64) (access *Token, refresh *Token, err error)
+	Refresh(ctx context.Context, refreshTokenPlaintext string) (access *Token, refresh *Token, err error)
+	Verify(tokenPlaintext string) (*Token, error)
+	JWKS() *jwt.JWKS
+	Delete(ctx context.Context, scope, tokenPlaintext string) error
 	DeleteAllForUser(ctx context.Context, scope string, userID int64) error
+	Touch(ctx context.Context, tokenPlaintext string) error
+	GetSessions(ctx context.Context, userID int64, currentTokenPlaintext string) ([]*Session, error)
+	DeleteSession(ctx context.Context, userID int64, sessionID int64) error
+	CreatePersonalToken(ctx context.Context, token *PersonalAccessToken) error
+	GetPersonalTokens(ctx context.Context, userID int64) ([]*PersonalAccessToken, error)
+	DeletePersonalToken(ctx context.Context, userID int64, tokenID int64) error
+	DeleteAllPersonalTokens(ctx context.Context, userID int64) error
+	AuthenticatePersonalToken(ctx context.Context, tokenPlaintext string) (*PersonalAccessToken, error)
+	DeleteExpired(ctx context.Context, batchSize int) (int64, error)
 }
 
 type TokenRepository interface {
 	Insert(ctx context.Context, token *Token) error
+	Get(ctx context.Context, scope, tokenPlaintext string) (*Token, error)
+	Rotate(ctx context.Context, token *Token) error
+	DeleteFamily(ctx context.Context, scope string, family []byte) error
+	Delete(ctx context.Context, scope, tokenPlaintext string) error
 	DeleteAllForUser(ctx context.Context, scope string, userID int64) error
+	Touch(ctx context.Context, scope, tokenPlaintext string) error
+	GetAllForUser(ctx context.Context, scope string, userID int64) ([]*Session, error)
+	DeleteForUser(ctx context.Context, scope string, userID int64, tokenID int64) error
+	DeleteExpired(ctx context.Context, limit int) (int64, error)
+}
+
+// IsSignedToken reports whether the token is a signed token rather than an opaque one.
+func IsSignedToken(tokenPlaintext string) bool {
+	return strings.Count(tokenPlaintext, ".") == 2
+}
+
+// GenerateTokenFamily generates a random family for the tokens issued on login.
+func GenerateTokenFamily() ([]byte, error) {
+	family := make([]byte, 16)
+
+	_, err := rand.Read(family)
+	if err != nil {
+		return nil, err
+	}
+
+	return family, nil
 }
 
 // GenerateToken generates token based on userID, ttl (time-to-live), and scope.
@@ -6,14 +6,29 @@ import (
 )
 
 // User represents an individual user.
+// PendingEmail is the email address the user is changing to, it replaces
+// Email once the user confirms it.
+// Deactivated is set once an admin deactivates the user, unlike the users who
+// haven't been activated yet, they can't activate the account by themselves.
 type User struct {
-	ID        int64     `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Password  Password  `json:"-"`
-	Activated bool      `json:"activated"`
-	Version   int       `json:"-"`
+	ID           int64     `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	PendingEmail string    `json:"pending_email,omitempty"`
+	Password     Password  `json:"-"`
+	Activated    bool      `json:"activated"`
+	Deactivated  bool      `json:"deactivated"`
+	Version      int       `json:"-"`
+}
+
+// UserUpdate holds the changes the user makes to their own account,
+// the fields which are nil are left unchanged.
+type UserUpdate struct {
+	Name            *string
+	Email           *string
+	Password        *string
+	CurrentPassword string
 }
 
 // AnonymousUser represents an anonymous user.
@@ -29,13 +44,24 @@ type UserUsecase interface {
 	Update(ctx context.Context, user *User) error
 	Register(ctx context.Context, user *User) error
 	Activate(ctx context.Context, tokenPlaintext string) (*User, error)
-	Login(ctx context.Context, email, password string) (*Token, error)
+	Login(ctx context.Context, email, password string) (access *Token, refresh *Token, err error)
+	CompleteLogin(ctx context.Context, challengePlaintext, code string) (access *Token, refresh *Token, err error)
 	Authenticate(ctx context.Context, tokenScope, tokenPlaintext string) (*User, error)
+	GetByID(ctx context.Context, userID int64) (*User, error)
+	ResendActivation(ctx context.Context, email string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, tokenPlaintext, password string) (*User, error)
+	UpdateAccount(ctx context.Context, user *User, changes *UserUpdate) error
+	ConfirmEmailChange(ctx context.Context, tokenPlaintext string) (*User, error)
+	DeleteAccount(ctx context.Context, user *User, password string) error
 }
 
 type UserRepository interface {
 	Insert(ctx context.Context, user *User) error
+	GetAll(ctx context.Context, search string, filters Filters) ([]*User, Metadata, error)
+	GetByID(ctx context.Context, userID int64) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	Update(ctx context.Context, user *User) error
 	GetForToken(ctx context.Context, tokenScope, tokenPlaintext string) (*User, error)
+	Delete(ctx context.Context, userID int64) error
 }
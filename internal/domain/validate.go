@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/unknowntpo/todos/pkg/validator"
 )
 
@@ -21,7 +24,8 @@ func ValidateEmail(v *validator.Validator, email string) {
 func ValidatePasswordPlaintext(v *validator.Validator, password string) {
 	v.Check(password != "", "password", "must be provided")
 	v.Check(len(password) >= 8, "password", "must be at least 8 bytes long")
-	v.Check(len(password) <= 72, "password", "must not be more than 72 bytes long")
+	max := PasswordHashParams.MaxLength()
+	v.Check(len(password) <= max, "password", fmt.Sprintf("must not be more than %d bytes long", max))
 }
 
 func ValidateUser(v *validator.Validator, user *User) {
@@ -38,6 +42,7 @@ func ValidateUser(v *validator.Validator, user *User) {
 	// raise a panic instead.
 	if user.Password.Plaintext != nil {
 		ValidatePasswordPlaintext(v, *user.Password.Plaintext)
+		ValidatePasswordPolicy(v, *user.Password.Plaintext, user)
 	} else {
 		panic("missing password plaintext for user")
 	}
@@ -52,6 +57,27 @@ func ValidateUser(v *validator.Validator, user *User) {
 	}
 }
 
+// ValidateUserUpdate checks the changes the user makes to their own account,
+// changing email or password requires the current password.
+func ValidateUserUpdate(v *validator.Validator, changes *UserUpdate) {
+	if changes.Name != nil {
+		v.Check(*changes.Name != "", "name", "must be provided")
+		v.Check(len(*changes.Name) <= 500, "name", "must not be more than 500 bytes long")
+	}
+
+	if changes.Email != nil {
+		ValidateEmail(v, *changes.Email)
+	}
+
+	if changes.Password != nil {
+		ValidatePasswordPlaintext(v, *changes.Password)
+	}
+
+	if changes.Email != nil || changes.Password != nil {
+		v.Check(changes.CurrentPassword != "", "current_password", "must be provided to change email or password")
+	}
+}
+
 // ValidateTokenPlaintext checks that the plaintext token has been provided and is exactly 26 bytes long.
 func ValidateTokenPlaintext(v *validator.Validator, tokenPlaintext string) {
 	v.Check(tokenPlaintext != "", "token", "must be provided")
@@ -69,3 +95,63 @@ func ValidateFilters(v *validator.Validator, f Filters) {
 	// Check that the sort parameter matches a value in the safelist.
 	v.Check(validator.In(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
 }
+
+// ValidateTaskShare checks that the invitee email is valid and the role is one that
+// can be granted by the task owner.
+func ValidateTaskShare(v *validator.Validator, email, role string) {
+	ValidateEmail(v, email)
+	v.Check(validator.In(role, RoleEditor, RoleViewer), "role", "must be either editor or viewer")
+}
+
+// ValidateWorkspace checks if workspace match the constrains.
+func ValidateWorkspace(v *validator.Validator, ws *Workspace) {
+	v.Check(ws.Name != "", "name", "must be provided")
+	v.Check(len(ws.Name) <= 500, "name", "must not be more than 500 bytes long")
+
+	ValidateWorkspaceSlug(v, ws.Slug)
+}
+
+// ValidateWorkspaceSlug checks that the slug only contains lowercase letters, digits and hyphens.
+func ValidateWorkspaceSlug(v *validator.Validator, slug string) {
+	v.Check(slug != "", "slug", "must be provided")
+	v.Check(validator.Matches(slug, validator.SlugRX), "slug", "must be 3 to 50 lowercase letters, digits or hyphens")
+}
+
+// ValidateWorkspaceMember checks that the member email is valid and the role is one that
+// can be granted by the workspace admins.
+func ValidateWorkspaceMember(v *validator.Validator, email, role string) {
+	ValidateEmail(v, email)
+	v.Check(validator.In(role, WorkspaceRoleAdmin, WorkspaceRoleMember), "role", "must be either admin or member")
+}
+
+// ValidatePersonalAccessToken checks the name, scopes and optional expiry of the personal access token.
+func ValidatePersonalAccessToken(v *validator.Validator, token *PersonalAccessToken) {
+	v.Check(token.Name != "", "name", "must be provided")
+	v.Check(len(token.Name) <= 100, "name", "must not be more than 100 bytes long")
+
+	v.Check(len(token.Scopes) > 0, "scopes", "must contain at least 1 scope")
+	for _, scope := range token.Scopes {
+		v.Check(validator.In(scope, PermissionCodes...), "scopes", "must only contain known permission codes")
+	}
+	v.Check(validator.Unique(token.Scopes), "scopes", "must not contain duplicate values")
+
+	if token.Expiry != nil {
+		v.Check(token.Expiry.After(time.Now()), "expiry", "must be in the future")
+	}
+}
+
+// ValidatePermissions checks that at least one permission is provided and every permission
+// code is known.
+func ValidatePermissions(v *validator.Validator, codes []string) {
+	v.Check(len(codes) > 0, "permissions", "must contain at least 1 permission")
+	for _, code := range codes {
+		v.Check(validator.In(code, PermissionCodes...), "permissions", "must only contain known permission codes")
+	}
+	v.Check(validator.Unique(codes), "permissions", "must not contain duplicate values")
+}
+
+// ValidateMFACode checks that the code looks like either a 6-digit TOTP code or a recovery code.
+func ValidateMFACode(v *validator.Validator, code string) {
+	v.Check(code != "", "code", "must be provided")
+	v.Check(len(code) == 6 || (IsRecoveryCode(code) && len(code) == 11), "code", "must be a 6-digit code or a recovery code")
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Roles a user can have in a workspace. The owner is the user who created the workspace,
+// admins can manage the members, both can edit all tasks of the workspace. Members can add
+// tasks and view the others, they can only edit those shared with them as editors.
+const (
+	WorkspaceRoleOwner  = "owner"
+	WorkspaceRoleAdmin  = "admin"
+	WorkspaceRoleMember = "member"
+)
+
+// Workspace represents an organization whose members share their tasks.
+type Workspace struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`           // Unique identifier used in the X-Workspace header
+	Role      string    `json:"role,omitempty"` // Role of the requesting user in this workspace
+}
+
+// IsOwner reports whether the requesting user is the owner of the workspace.
+func (ws *Workspace) IsOwner() bool {
+	return ws.Role == WorkspaceRoleOwner
+}
+
+// CanManageMembers reports whether the requesting user is allowed to add and remove members.
+func (ws *Workspace) CanManageMembers() bool {
+	return ws.Role == WorkspaceRoleOwner || ws.Role == WorkspaceRoleAdmin
+}
+
+// TaskRole returns the role the requesting user has on the tasks of the workspace, which
+// the tasks shared with or assigned to the user may raise.
+func (ws *Workspace) TaskRole() string {
+	if ws.CanManageMembers() {
+		return RoleEditor
+	}
+	return RoleViewer
+}
+
+// WorkspaceMember represents the membership of a user in a workspace.
+type WorkspaceMember struct {
+	WorkspaceID int64     `json:"workspace_id"`
+	UserID      int64     `json:"user_id"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type workspaceContextKey struct{}
+
+// ContextWithWorkspace returns a copy of ctx which carries the workspace the request operates on.
+// Repositories use it to isolate the data of every workspace.
+func ContextWithWorkspace(ctx context.Context, ws *Workspace) context.Context {
+	return context.WithValue(ctx, workspaceContextKey{}, ws)
+}
+
+// WorkspaceFromContext returns the workspace stored in ctx, if there's no workspace,
+// the request operates on the personal space of the user and ok is false.
+func WorkspaceFromContext(ctx context.Context) (ws *Workspace, ok bool) {
+	ws, ok = ctx.Value(workspaceContextKey{}).(*Workspace)
+	return ws, ok && ws != nil
+}
+
+type WorkspaceUsecase interface {
+	Create(ctx context.Context, userID int64, ws *Workspace) error
+	GetAll(ctx context.Context, userID int64) ([]*Workspace, error)
+	GetByID(ctx context.Context, userID int64, workspaceID int64) (*Workspace, error)
+	GetBySlug(ctx context.Context, userID int64, slug string) (*Workspace, error)
+	Delete(ctx context.Context, userID int64, workspaceID int64) error
+	GetMembers(ctx context.Context, userID int64, workspaceID int64) ([]*WorkspaceMember, error)
+	AddMember(ctx context.Context, userID int64, workspaceID int64, email, role string) (*WorkspaceMember, error)
+	RemoveMember(ctx context.Context, userID int64, workspaceID int64, memberID int64) error
+}
+
+type WorkspaceRepository interface {
+	Insert(ctx context.Context, ownerID int64, ws *Workspace) error
+	GetAll(ctx context.Context, userID int64) ([]*Workspace, error)
+	GetByID(ctx context.Context, userID int64, workspaceID int64) (*Workspace, error)
+	GetBySlug(ctx context.Context, userID int64, slug string) (*Workspace, error)
+	Delete(ctx context.Context, workspaceID int64) error
+	GetMembers(ctx context.Context, workspaceID int64) ([]*WorkspaceMember, error)
+	GetMember(ctx context.Context, workspaceID int64, userID int64) (*WorkspaceMember, error)
+	InsertMember(ctx context.Context, member *WorkspaceMember) error
+	DeleteMember(ctx context.Context, workspaceID int64, userID int64) error
+}
@@ -3,7 +3,10 @@ package api
 import (
 	"net/http"
 
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/logger"
 	"github.com/unknowntpo/todos/internal/reactor"
+	"github.com/unknowntpo/todos/pkg/health"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -12,6 +15,7 @@ import (
 type healthcheckAPI struct {
 	version string
 	env     string
+	health  *health.Registry
 	rc      *reactor.Reactor
 }
 
@@ -21,15 +25,37 @@ type HealthcheckResponse struct {
 	Version     string `json:"version"`
 }
 
-// NewHealthcheckAPI registers all handlers in /v1/healcheck to the router.
-func NewHealthcheckAPI(router *httprouter.Router, version, env string, rc *reactor.Reactor) {
-	api := &healthcheckAPI{version: version, env: env, rc: rc}
+// LivenessResponse is the response of /livez.
+type LivenessResponse struct {
+	Status string `json:"status"`
+}
+
+// ReadinessResponse is the response of /readyz. Status is "ready", "not ready" or "shutting
+// down", the checks are keyed by their names.
+type ReadinessResponse struct {
+	Status string                    `json:"status"`
+	Checks map[string]*CheckResponse `json:"checks,omitempty"`
+}
+
+// CheckResponse is the result of a check of a dependency. Status is "pass" or "fail", the
+// service isn't ready if a critical check fails.
+type CheckResponse struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// NewHealthcheckAPI registers all handlers in /v1/healcheck, /livez and /readyz to the router.
+func NewHealthcheckAPI(router *httprouter.Router, version, env string, hr *health.Registry, rc *reactor.Reactor) {
+	api := &healthcheckAPI{version: version, env: env, health: hr, rc: rc}
 	router.HandlerFunc(http.MethodGet, "/v1/healthcheck", api.Healthcheck)
+	router.HandlerFunc(http.MethodGet, "/livez", api.Livez)
+	router.HandlerFunc(http.MethodGet, "/readyz", api.Readyz)
 }
 
 // Healthcheck shows status of service.
 // @Summary Show status of service.
-// @Description None.
+// @Description It only tells the service is up, see /readyz for its dependencies.
 // @Produce json
 // @Success 200 {object} HealthcheckResponse
 // @Router /v1/healthcheck [get]
@@ -40,3 +66,57 @@ func (h *healthcheckAPI) Healthcheck(w http.ResponseWriter, r *http.Request) {
 		Environment: h.env,
 	})
 }
+
+// Livez tells whether the service is alive.
+// @Summary Show whether the service is alive.
+// @Description The service is alive as long as it serves requests, the dependencies aren't checked, so that it isn't restarted when they fail.
+// @Produce json
+// @Success 200 {object} LivenessResponse
+// @Router /livez [get]
+func (h *healthcheckAPI) Livez(w http.ResponseWriter, r *http.Request) {
+	h.rc.WriteJSON(w, http.StatusOK, &LivenessResponse{Status: "alive"})
+}
+
+// Readyz checks the dependencies of the service, and tells whether it's ready to serve requests.
+// @Summary Show whether the service is ready to serve requests.
+// @Description The service is ready if all the critical checks of its dependencies pass and it isn't shutting down. The errors of the failed checks are logged.
+// @Produce json
+// @Success 200 {object} ReadinessResponse
+// @Failure 503 {object} ReadinessResponse
+// @Router /readyz [get]
+func (h *healthcheckAPI) Readyz(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "healthcheckAPI.Readyz"
+
+	report := h.health.Check(r.Context())
+
+	if report.ShuttingDown {
+		h.rc.WriteJSON(w, http.StatusServiceUnavailable, &ReadinessResponse{Status: "shutting down"})
+		return
+	}
+
+	res := &ReadinessResponse{Status: "ready", Checks: make(map[string]*CheckResponse, len(report.Results))}
+
+	for _, c := range report.Results {
+		res.Checks[c.Name] = &CheckResponse{
+			Status:    c.Status,
+			Critical:  c.Critical,
+			LatencyMS: float64(c.Latency.Microseconds()) / 1000,
+		}
+
+		// The errors may tell about the internals of the deployment, so they're only logged.
+		if c.Err != nil {
+			logger.FromContext(r.Context(), h.rc.Logger).PrintError(errors.E(op, errors.Msg("health check failed"), c.Err), map[string]interface{}{
+				"check":    c.Name,
+				"critical": c.Critical,
+			})
+		}
+	}
+
+	status := http.StatusOK
+	if !report.Ready {
+		res.Status = "not ready"
+		status = http.StatusServiceUnavailable
+	}
+
+	h.rc.WriteJSON(w, status, res)
+}
@@ -2,12 +2,17 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/unknowntpo/todos/internal/logger/zerolog"
 	"github.com/unknowntpo/todos/internal/reactor"
+	"github.com/unknowntpo/todos/pkg/health"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
@@ -20,7 +25,7 @@ func TestHealthcheckHandler(t *testing.T) {
 
 	rc := reactor.NewReactor(logger)
 
-	NewHealthcheckAPI(router, "v3.14", "development", rc)
+	NewHealthcheckAPI(router, "v3.14", "development", health.NewRegistry(time.Second), rc)
 
 	// build request: GET /v1/healthcheck
 	r, err := http.NewRequest(http.MethodGet, "/v1/healthcheck", nil)
@@ -43,3 +48,90 @@ func TestHealthcheckHandler(t *testing.T) {
 	assert.Equal(t, wantBody, w.Body.String(), "response body must be equal")
 	assert.Equal(t, "", buf.String(), "buf should contain nothing")
 }
+
+func TestLivez(t *testing.T) {
+	router := httprouter.New()
+	NewHealthcheckAPI(router, "v3.14", "development", health.NewRegistry(time.Second), reactor.NewReactor(zerolog.New(new(bytes.Buffer))))
+
+	r, err := http.NewRequest(http.MethodGet, "/livez", nil)
+	if err != nil {
+		t.Fatalf("failed to make new request: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\n\t\"status\": \"alive\"\n}\n", w.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	pass := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("dial tcp 10.0.0.5:5432: connection refused") }
+
+	tests := []struct {
+		name       string
+		database   health.CheckFunc
+		smtp       health.CheckFunc
+		shutdown   bool
+		wantCode   int
+		wantStatus string
+	}{
+		{"ready", pass, pass, false, http.StatusOK, "ready"},
+		{"non-critical check fails", pass, fail, false, http.StatusOK, "ready"},
+		{"critical check fails", fail, pass, false, http.StatusServiceUnavailable, "not ready"},
+		{"shutting down", pass, pass, true, http.StatusServiceUnavailable, "shutting down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			router := httprouter.New()
+
+			hr := health.NewRegistry(time.Second)
+			hr.Register("database", true, tt.database)
+			hr.Register("smtp", false, tt.smtp)
+			if tt.shutdown {
+				hr.Shutdown()
+			}
+
+			NewHealthcheckAPI(router, "v3.14", "development", hr, reactor.NewReactor(zerolog.New(buf)))
+
+			r, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			if err != nil {
+				t.Fatalf("failed to make new request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			body := w.Body.String()
+
+			var res ReadinessResponse
+			if err := json.Unmarshal([]byte(body), &res); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			assert.Equal(t, tt.wantStatus, res.Status)
+
+			if tt.shutdown {
+				assert.Empty(t, res.Checks, "the checks shouldn't run while shutting down")
+				return
+			}
+
+			if assert.Contains(t, res.Checks, "database") {
+				assert.True(t, res.Checks["database"].Critical)
+			}
+			assert.Contains(t, res.Checks, "smtp")
+
+			for name, c := range res.Checks {
+				if c.Status == health.StatusFail {
+					assert.Contains(t, buf.String(), `"check":"`+name+`"`, "the failed check should be logged")
+				}
+			}
+			assert.NotContains(t, body, "10.0.0.5", "the errors shouldn't be sent to the client")
+		})
+	}
+}
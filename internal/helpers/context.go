@@ -15,6 +15,12 @@ type contextKey string
 // in the request context.
 const userContextKey = contextKey("user")
 
+// tokenContextKey is the key for the plaintext of the authentication token the request carries.
+const tokenContextKey = contextKey("token")
+
+// tokenScopesContextKey is the key for the scopes of the personal access token the request carries.
+const tokenScopesContextKey = contextKey("tokenScopes")
+
 // ContextSetUser returns a new copy of the request with the provided
 // User struct added to the context. Note that we use our userContextKey constant as the
 // key.
@@ -35,3 +41,50 @@ func ContextGetUser(r *http.Request) *domain.User {
 
 	return user
 }
+
+// ContextSetToken returns a new copy of the request with the plaintext
+// of the authentication token added to the context.
+func ContextSetToken(r *http.Request, tokenPlaintext string) *http.Request {
+	ctx := context.WithValue(r.Context(), tokenContextKey, tokenPlaintext)
+	return r.WithContext(ctx)
+}
+
+// ContextGetToken retrieves the plaintext of the authentication token from the request context,
+// it returns an empty string if the request is anonymous.
+func ContextGetToken(r *http.Request) string {
+	token, _ := r.Context().Value(tokenContextKey).(string)
+	return token
+}
+
+// ContextSetTokenScopes returns a new copy of the request with the scopes of
+// the personal access token added to the context.
+func ContextSetTokenScopes(r *http.Request, scopes domain.Permissions) *http.Request {
+	ctx := context.WithValue(r.Context(), tokenScopesContextKey, scopes)
+	return r.WithContext(ctx)
+}
+
+// ContextGetTokenScopes retrieves the scopes of the personal access token from the request context,
+// ok is false if the request isn't authenticated with a personal access token.
+func ContextGetTokenScopes(r *http.Request) (scopes domain.Permissions, ok bool) {
+	scopes, ok = r.Context().Value(tokenScopesContextKey).(domain.Permissions)
+	return scopes, ok
+}
+
+// ContextSetWorkspace returns a new copy of the request with the provided
+// Workspace struct added to the context.
+func ContextSetWorkspace(r *http.Request, ws *domain.Workspace) *http.Request {
+	ctx := domain.ContextWithWorkspace(r.Context(), ws)
+	return r.WithContext(ctx)
+}
+
+// ContextGetWorkspace retrieves the Workspace struct from the request context.
+// Unlike ContextGetUser, it returns nil if there's no workspace, which means the
+// request operates on the personal space of the user.
+func ContextGetWorkspace(r *http.Request) *domain.Workspace {
+	ws, ok := domain.WorkspaceFromContext(r.Context())
+	if !ok {
+		return nil
+	}
+
+	return ws
+}
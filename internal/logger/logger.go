@@ -1,8 +1,28 @@
 package logger
 
+import "context"
+
 // Logger represent common interface for logging function
 type Logger interface {
 	PrintInfo(message string, properties map[string]interface{})
 	PrintError(err error, properties map[string]interface{})
 	PrintFatal(err error, properties map[string]interface{})
+	// With returns a logger which adds the properties to every entry it prints.
+	With(properties map[string]interface{}) Logger
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx which carries the logger, e.g. the logger of the request
+// which adds its request ID to every entry.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, if there's no logger, fallback is returned.
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if l, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return l
+	}
+	return fallback
 }
@@ -39,3 +39,8 @@ func (zw *zerologWrapper) PrintFatal(err error, properties map[string]interface{
 		Err(err).Fields(properties).Msg("")
 	os.Exit(1)
 }
+
+func (zw *zerologWrapper) With(properties map[string]interface{}) logger.Logger {
+	log := zw.logger.With().Fields(properties).Logger()
+	return &zerologWrapper{logger: &log}
+}
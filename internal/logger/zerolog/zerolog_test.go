@@ -42,3 +42,14 @@ func TestPrintError(t *testing.T) {
 	// Output:
 	// {"level":"info","key1":"value1","key2":"value2","message":"test PrintInfo"}
 }
+
+func TestWith(t *testing.T) {
+	out := bytes.NewBufferString("")
+	log := New(out).With(map[string]interface{}{"request_id": "abc"})
+
+	log.PrintInfo("test With", map[string]interface{}{"key1": "value1"})
+
+	got := out.String()
+	assert.Contains(t, got, `"request_id":"abc"`, "log output must contain the properties of With")
+	assert.Contains(t, got, `"key1":"value1"`)
+}
@@ -2,19 +2,31 @@ package mailer
 
 import (
 	"bytes"
+	"context"
 	"embed"
 	"html/template"
 	"time"
 
 	"github.com/unknowntpo/todos/config"
 	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/tracing"
 
 	"github.com/go-mail/mail/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 //go:embed "templates"
 var templateFS embed.FS
 
+// mailsSent counts the emails by the result of sending them, "success" or "failure".
+var mailsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "mail_send_total",
+	Help: "The number of emails sent, by result.",
+}, []string{"result"})
+
 // Mailer send the message
 type Mailer struct {
 	config *config.Smtp // config is used to set up the dialer in DefaultSendFunc.
@@ -61,11 +73,17 @@ func (m *Mailer) PrepareLetterPaper(recipient, templateName string, data interfa
 	return letterPaper, nil
 }
 
-func (m *Mailer) Send(recipient, templateName string, data interface{}) error {
+// Send sends the email of the template to the recipient, in the span of ctx, which records every
+// failed attempt.
+func (m *Mailer) Send(ctx context.Context, recipient, templateName string, data interface{}) (err error) {
 	const op errors.Op = "mailer.Send"
 
+	_, span := tracing.Start(ctx, op, attribute.String("mail.template", templateName))
+	defer func() { tracing.End(span, err) }()
+
 	lp, err := m.PrepareLetterPaper(recipient, templateName, data)
 	if err != nil {
+		mailsSent.WithLabelValues("failure").Inc()
 		return errors.E(op, err)
 	}
 
@@ -78,12 +96,18 @@ func (m *Mailer) Send(recipient, templateName string, data interface{}) error {
 		err = dialer.DialAndSend(lp)
 		// If everything worked, return nil.
 		if nil == err {
+			mailsSent.WithLabelValues("success").Inc()
 			return nil
 		}
 
+		span.AddEvent("attempt failed", trace.WithAttributes(attribute.Int("mail.attempt", i), attribute.String("exception.message", err.Error())))
+
 		// If it didn't work, sleep for a short time and retry.
-		time.Sleep(500 * time.Millisecond)
+		if i < 3 {
+			time.Sleep(500 * time.Millisecond)
+		}
 	}
 
-	return nil
+	mailsSent.WithLabelValues("failure").Inc()
+	return errors.E(op, err)
 }
@@ -2,6 +2,7 @@ package mailer
 
 import (
 	"bytes"
+	"context"
 	"testing"
 	"time"
 
@@ -35,12 +36,28 @@ func TestSend(t *testing.T) {
 				"userID":          user.ID,
 			}
 
-			err = m.Send("alice@example.com", "user_welcome.tmpl", data)
+			err = m.Send(context.Background(), "alice@example.com", "user_welcome.tmpl", data)
 			if err != nil {
 				t.Fatalf("failed to send welcome email: %v", err)
 			}
 		})
 	*/
+
+	t.Run("Fail on unreachable server", func(t *testing.T) {
+		m := New(&config.Smtp{
+			Host:   "127.0.0.1",
+			Port:   1,
+			Sender: "TODOs <no-reply@todos.unknowntpo.net>",
+		})
+
+		data := map[string]interface{}{
+			"activationToken": "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU",
+			"userID":          int64(1),
+		}
+
+		err := m.Send(context.Background(), "alice@example.com", "user_welcome.tmpl", data)
+		assert.Error(t, err, "the error of the last attempt should be returned")
+	})
 }
 
 func TestPrepareLetterPaper(t *testing.T) {
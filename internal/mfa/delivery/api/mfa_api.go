@@ -0,0 +1,199 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/helpers"
+	"github.com/unknowntpo/todos/internal/middleware"
+	"github.com/unknowntpo/todos/internal/reactor"
+	"github.com/unknowntpo/todos/pkg/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type mfaAPI struct {
+	mu  domain.MFAUsecase
+	mid *middleware.Middleware
+	rc  *reactor.Reactor
+}
+
+type TOTPEnrollmentResponse struct {
+	TOTP *domain.TOTPEnrollment `json:"totp"`
+}
+
+type TOTPConfirmationRequest struct {
+	Code string `json:"code"`
+}
+
+type TOTPConfirmationResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TOTPDisableRequest struct {
+	Password string `json:"password"`
+}
+
+type TOTPMessageResponse struct {
+	Message string `json:"message"`
+}
+
+func NewMFAAPI(router *httprouter.Router, mu domain.MFAUsecase, mid *middleware.Middleware, rc *reactor.Reactor) {
+	api := &mfaAPI{mu: mu, mid: mid, rc: rc}
+	router.Handler(http.MethodPost, "/v1/users/me/mfa/totp", mid.RequireActivatedUser(http.HandlerFunc(api.EnrollTOTP)))
+	router.Handler(http.MethodPut, "/v1/users/me/mfa/totp", mid.RequireActivatedUser(http.HandlerFunc(api.ConfirmTOTP)))
+	router.Handler(http.MethodDelete, "/v1/users/me/mfa/totp", mid.RequireActivatedUser(http.HandlerFunc(api.DisableTOTP)))
+}
+
+// EnrollTOTP generates a new TOTP secret for the current user.
+// @Summary Generate a new TOTP secret for the current user.
+// @Description: The secret takes effect once it's confirmed by the first code generated from it.
+// @Description: Enrolling again before confirmation replaces the secret.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success 201 {object} TOTPEnrollmentResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse "TOTP is already enabled"
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/users/me/mfa/totp [post]
+func (m *mfaAPI) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "mfaAPI.EnrollTOTP"
+
+	user := helpers.ContextGetUser(r)
+
+	ctx := r.Context()
+	enrollment, err := m.mu.EnrollTOTP(ctx, user.ID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindEditConflict):
+			v := validator.New()
+			v.AddError("totp", "is already enabled")
+			m.rc.FailedValidationResponse(w, r, v.Err())
+		default:
+			m.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = m.rc.WriteJSON(w, http.StatusCreated, &TOTPEnrollmentResponse{TOTP: enrollment})
+	if err != nil {
+		m.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// ConfirmTOTP enables TOTP for the current user.
+// @Summary Enable TOTP for the current user with the first code generated by the authenticator app.
+// @Description: The response contains the one-time recovery codes, which are never shown again.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param reqBody body TOTPConfirmationRequest true "request body"
+// @Success 200 {object} TOTPConfirmationResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse "TOTP secret hasn't been generated"
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/users/me/mfa/totp [put]
+func (m *mfaAPI) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "mfaAPI.ConfirmTOTP"
+
+	var input TOTPConfirmationRequest
+
+	err := m.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		m.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if v.Check(len(input.Code) == 6, "code", "must be a 6-digit code"); !v.Valid() {
+		m.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	user := helpers.ContextGetUser(r)
+
+	ctx := r.Context()
+	codes, err := m.mu.ConfirmTOTP(ctx, user.ID, input.Code)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			m.rc.NotFoundResponse(w, r)
+		case errors.KindIs(err, errors.KindEditConflict):
+			v.AddError("totp", "is already enabled")
+			m.rc.FailedValidationResponse(w, r, v.Err())
+		case errors.KindIs(err, errors.KindInvalidCredentials):
+			v.AddError("code", "is invalid")
+			m.rc.FailedValidationResponse(w, r, v.Err())
+		default:
+			m.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = m.rc.WriteJSON(w, http.StatusOK, &TOTPConfirmationResponse{RecoveryCodes: codes})
+	if err != nil {
+		m.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// DisableTOTP disables TOTP for the current user.
+// @Summary Disable TOTP for the current user, the recovery codes are deleted along with it.
+// @Description: Requires the password of the user.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param reqBody body TOTPDisableRequest true "request body"
+// @Success 200 {object} TOTPMessageResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/users/me/mfa/totp [delete]
+func (m *mfaAPI) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "mfaAPI.DisableTOTP"
+
+	var input TOTPDisableRequest
+
+	err := m.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		m.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if v.Check(input.Password != "", "password", "must be provided"); !v.Valid() {
+		m.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	user := helpers.ContextGetUser(r)
+
+	ctx := r.Context()
+	err = m.mu.DisableTOTP(ctx, user.ID, input.Password)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			m.rc.NotFoundResponse(w, r)
+		case errors.KindIs(err, errors.KindInvalidCredentials):
+			v.AddError("password", "is incorrect")
+			m.rc.FailedValidationResponse(w, r, v.Err())
+		default:
+			m.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = m.rc.WriteJSON(w, http.StatusOK, &TOTPMessageResponse{Message: "two-factor authentication was successfully disabled"})
+	if err != nil {
+		m.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
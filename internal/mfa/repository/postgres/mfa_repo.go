@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+
+	"github.com/lib/pq"
+)
+
+type mfaRepo struct {
+	DB *sql.DB
+}
+
+func NewMFARepo(DB *sql.DB) domain.MFARepository {
+	return &mfaRepo{DB}
+}
+
+// GetTOTP returns the TOTP secret of the user with given userID. If there's no such secret,
+// the error with kind errors.KindRecordNotFound is returned.
+func (mr *mfaRepo) GetTOTP(ctx context.Context, userID int64) (*domain.TOTP, error) {
+	const op errors.Op = "mfaRepo.GetTOTP"
+
+	query := `
+        SELECT user_id, secret, confirmed_at IS NOT NULL, last_used_step
+        FROM totp_secrets
+        WHERE user_id = $1`
+
+	var totp domain.TOTP
+
+	err := mr.DB.QueryRowContext(ctx, query, userID).Scan(
+		&totp.UserID,
+		&totp.Secret,
+		&totp.Confirmed,
+		&totp.LastUsedStep,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+		default:
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+	}
+
+	return &totp, nil
+}
+
+// InsertTOTP adds the unconfirmed TOTP secret, replacing the one which hasn't been confirmed yet.
+// If the user has confirmed a secret already, the error with kind errors.KindEditConflict
+// is returned.
+func (mr *mfaRepo) InsertTOTP(ctx context.Context, totp *domain.TOTP) error {
+	const op errors.Op = "mfaRepo.InsertTOTP"
+
+	query := `
+        INSERT INTO totp_secrets (user_id, secret)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) DO UPDATE
+        SET secret = EXCLUDED.secret, last_used_step = 0
+        WHERE totp_secrets.confirmed_at IS NULL`
+
+	result, err := mr.DB.ExecContext(ctx, query, totp.UserID, totp.Secret)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindEditConflict, domain.ErrEditConflict)
+	}
+
+	return nil
+}
+
+// ConfirmTOTP confirms the TOTP secret of the user with the code of given time step. If the secret
+// has been confirmed already, e.g. by a concurrent request, the error with kind
+// errors.KindEditConflict is returned.
+func (mr *mfaRepo) ConfirmTOTP(ctx context.Context, userID int64, step int64) error {
+	const op errors.Op = "mfaRepo.ConfirmTOTP"
+
+	query := `
+        UPDATE totp_secrets
+        SET confirmed_at = NOW(), last_used_step = $2
+        WHERE user_id = $1 AND confirmed_at IS NULL`
+
+	return mr.update(ctx, op, query, userID, step)
+}
+
+// UseTOTPStep records that the code of given time step has been used. If a code of the same or
+// a later time step has been used already, the error with kind errors.KindEditConflict is
+// returned, so that a code can't be replayed.
+func (mr *mfaRepo) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	const op errors.Op = "mfaRepo.UseTOTPStep"
+
+	query := `
+        UPDATE totp_secrets
+        SET last_used_step = $2
+        WHERE user_id = $1 AND last_used_step < $2`
+
+	return mr.update(ctx, op, query, userID, step)
+}
+
+func (mr *mfaRepo) update(ctx context.Context, op errors.Op, query string, args ...interface{}) error {
+	result, err := mr.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindEditConflict, domain.ErrEditConflict)
+	}
+
+	return nil
+}
+
+// DeleteTOTP deletes the TOTP secret of the user along with the recovery codes. If there's
+// no such secret, the error with kind errors.KindRecordNotFound is returned.
+func (mr *mfaRepo) DeleteTOTP(ctx context.Context, userID int64) error {
+	const op errors.Op = "mfaRepo.DeleteTOTP"
+
+	query := `
+        WITH codes AS (
+            DELETE FROM recovery_codes WHERE user_id = $1
+        )
+        DELETE FROM totp_secrets
+        WHERE user_id = $1`
+
+	result, err := mr.DB.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// InsertRecoveryCodes replaces the recovery codes of the user with the given hashes.
+func (mr *mfaRepo) InsertRecoveryCodes(ctx context.Context, userID int64, hashes [][]byte) error {
+	const op errors.Op = "mfaRepo.InsertRecoveryCodes"
+
+	query := `
+        WITH old AS (
+            DELETE FROM recovery_codes WHERE user_id = $1
+        )
+        INSERT INTO recovery_codes (user_id, hash)
+        SELECT $1, UNNEST($2::bytea[])`
+
+	_, err := mr.DB.ExecContext(ctx, query, userID, pq.Array(hashes))
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	return nil
+}
+
+// DeleteRecoveryCode uses up the recovery code with given hash of the user. If there's no
+// such code, the error with kind errors.KindRecordNotFound is returned.
+func (mr *mfaRepo) DeleteRecoveryCode(ctx context.Context, userID int64, hash []byte) error {
+	const op errors.Op = "mfaRepo.DeleteRecoveryCode"
+
+	query := `
+        DELETE FROM recovery_codes
+        WHERE user_id = $1 AND hash = $2`
+
+	result, err := mr.DB.ExecContext(ctx, query, userID, hash)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	return nil
+}
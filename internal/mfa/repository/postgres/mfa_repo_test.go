@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/testutil"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/stretchr/testify/suite"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+type MFARepoTestSuite struct {
+	suite.Suite
+	container testcontainers.Container
+	db        *sql.DB
+	mig       *migrate.Migrate
+	alice     *domain.User
+}
+
+func (suite *MFARepoTestSuite) SetupSuite() {
+	ctx := context.Background()
+
+	container, db, err := testutil.CreatePostgresTestContainer(ctx, "testdb")
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.container = container
+	suite.db = db
+
+	mig, err := testutil.NewPgMigrator(db)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.mig = mig
+}
+
+// TearDownSuite tears down the test suite by closing db connection,
+// terminates container.
+func (suite *MFARepoTestSuite) TearDownSuite() {
+	defer suite.db.Close()
+	ctx := context.Background()
+	defer suite.container.Terminate(ctx)
+}
+
+// SetupTest do migration up and creates a fake user for each test.
+func (suite *MFARepoTestSuite) SetupTest() {
+	err := suite.mig.Up()
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	user := testutil.NewFakeUser(suite.T(), "Alice Smith", "alice@example.com", "pa55word", true)
+
+	query := `
+	INSERT INTO users (name, email, password_hash, activated)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id`
+
+	err = suite.db.QueryRowContext(context.TODO(), query, user.Name, user.Email, user.Password.Hash, user.Activated).Scan(&user.ID)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.alice = user
+}
+
+// TearDownTest do migration down for each test to ensure the results of
+// this test won't affect to the result of next test.
+func (suite *MFARepoTestSuite) TearDownTest() {
+	err := suite.mig.Down()
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.alice = nil
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestMFARepoTestSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration tests...")
+	}
+
+	suite.Run(t, new(MFARepoTestSuite))
+}
+
+func (suite *MFARepoTestSuite) TestTOTP() {
+	suite.Run("Success", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewMFARepo(suite.db)
+
+		_, err := repo.GetTOTP(ctx, suite.alice.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		suite.NoError(repo.InsertTOTP(ctx, &domain.TOTP{UserID: suite.alice.ID, Secret: "first"}))
+		// The unconfirmed secret can be replaced.
+		suite.NoError(repo.InsertTOTP(ctx, &domain.TOTP{UserID: suite.alice.ID, Secret: "second"}))
+
+		secret, err := repo.GetTOTP(ctx, suite.alice.ID)
+		suite.NoError(err)
+		suite.Equal(&domain.TOTP{UserID: suite.alice.ID, Secret: "second"}, secret)
+
+		suite.NoError(repo.ConfirmTOTP(ctx, suite.alice.ID, 100))
+
+		err = repo.ConfirmTOTP(ctx, suite.alice.ID, 101)
+		suite.True(errors.KindIs(err, errors.KindEditConflict))
+
+		// The confirmed secret can't be replaced.
+		err = repo.InsertTOTP(ctx, &domain.TOTP{UserID: suite.alice.ID, Secret: "third"})
+		suite.True(errors.KindIs(err, errors.KindEditConflict))
+
+		secret, err = repo.GetTOTP(ctx, suite.alice.ID)
+		suite.NoError(err)
+		suite.Equal(&domain.TOTP{UserID: suite.alice.ID, Secret: "second", Confirmed: true, LastUsedStep: 100}, secret)
+
+		// The code of a time step can only be used once.
+		err = repo.UseTOTPStep(ctx, suite.alice.ID, 100)
+		suite.True(errors.KindIs(err, errors.KindEditConflict))
+		suite.NoError(repo.UseTOTPStep(ctx, suite.alice.ID, 101))
+
+		suite.NoError(repo.DeleteTOTP(ctx, suite.alice.ID))
+
+		err = repo.DeleteTOTP(ctx, suite.alice.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
+
+func (suite *MFARepoTestSuite) TestRecoveryCodes() {
+	suite.Run("Success", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewMFARepo(suite.db)
+
+		first := domain.HashRecoveryCode("aaaaa-aaaaa")
+		second := domain.HashRecoveryCode("bbbbb-bbbbb")
+
+		suite.NoError(repo.InsertTOTP(ctx, &domain.TOTP{UserID: suite.alice.ID, Secret: "secret"}))
+		suite.NoError(repo.InsertRecoveryCodes(ctx, suite.alice.ID, [][]byte{first}))
+		// The new codes replace the old ones.
+		suite.NoError(repo.InsertRecoveryCodes(ctx, suite.alice.ID, [][]byte{second}))
+
+		err := repo.DeleteRecoveryCode(ctx, suite.alice.ID, first)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		suite.NoError(repo.DeleteRecoveryCode(ctx, suite.alice.ID, second))
+
+		// A recovery code can only be used once.
+		err = repo.DeleteRecoveryCode(ctx, suite.alice.ID, second)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		// Disabling TOTP deletes the recovery codes.
+		suite.NoError(repo.InsertRecoveryCodes(ctx, suite.alice.ID, [][]byte{first}))
+		suite.NoError(repo.DeleteTOTP(ctx, suite.alice.ID))
+
+		err = repo.DeleteRecoveryCode(ctx, suite.alice.ID, first)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
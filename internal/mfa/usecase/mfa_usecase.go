@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/pkg/totp"
+)
+
+// issuer is the name shown along with the account in authenticator apps.
+const issuer = "TODOs"
+
+type mfaUsecase struct {
+	mfaRepo        domain.MFARepository
+	userRepo       domain.UserRepository
+	contextTimeout time.Duration
+}
+
+func NewMFAUsecase(mr domain.MFARepository, ur domain.UserRepository, timeout time.Duration) domain.MFAUsecase {
+	return &mfaUsecase{
+		mfaRepo:        mr,
+		userRepo:       ur,
+		contextTimeout: timeout,
+	}
+}
+
+// EnrollTOTP generates a new TOTP secret for the user, replacing the unconfirmed one if exists.
+// The secret doesn't protect the login until it's confirmed by ConfirmTOTP. If the user has
+// TOTP enabled already, the error with kind errors.KindEditConflict is returned.
+func (mu *mfaUsecase) EnrollTOTP(ctx context.Context, userID int64) (*domain.TOTPEnrollment, error) {
+	const op errors.Op = "mfaUsecase.EnrollTOTP"
+
+	ctx, cancel := context.WithTimeout(ctx, mu.contextTimeout)
+	defer cancel()
+
+	user, err := mu.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	err = mu.mfaRepo.InsertTOTP(ctx, &domain.TOTP{UserID: userID, Secret: secret})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	enrollment := &domain.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(issuer, user.Email, secret),
+	}
+
+	return enrollment, nil
+}
+
+// ConfirmTOTP enables TOTP for the user with the first code generated by the authenticator app,
+// and returns the recovery codes which can be used once each in place of a code. The recovery
+// codes are only stored hashed, so they can't be shown again.
+// If the user hasn't enrolled, the error with kind errors.KindRecordNotFound is returned, if
+// the user has TOTP enabled already, the error with kind errors.KindEditConflict is returned,
+// if the code is invalid, the error with kind errors.KindInvalidCredentials is returned.
+func (mu *mfaUsecase) ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error) {
+	const op errors.Op = "mfaUsecase.ConfirmTOTP"
+
+	ctx, cancel := context.WithTimeout(ctx, mu.contextTimeout)
+	defer cancel()
+
+	secret, err := mu.mfaRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	if secret.Confirmed {
+		return nil, errors.E(op, errors.KindEditConflict, domain.ErrEditConflict)
+	}
+
+	step, ok := totp.Validate(secret.Secret, code, time.Now())
+	if !ok {
+		return nil, errors.E(op, errors.KindInvalidCredentials, domain.ErrInvalidCredentials)
+	}
+
+	err = mu.mfaRepo.ConfirmTOTP(ctx, userID, step)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	codes := make([]string, domain.RecoveryCodeCount)
+	hashes := make([][]byte, domain.RecoveryCodeCount)
+
+	for i := range codes {
+		codes[i], err = domain.GenerateRecoveryCode()
+		if err != nil {
+			return nil, errors.E(op, err)
+		}
+		hashes[i] = domain.HashRecoveryCode(codes[i])
+	}
+
+	err = mu.mfaRepo.InsertRecoveryCodes(ctx, userID, hashes)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP disables TOTP for the user after checking the password, the recovery codes are
+// deleted along with it. If the password doesn't match, the error with kind
+// errors.KindInvalidCredentials is returned, if the user hasn't enrolled, the error with kind
+// errors.KindRecordNotFound is returned.
+func (mu *mfaUsecase) DisableTOTP(ctx context.Context, userID int64, password string) error {
+	const op errors.Op = "mfaUsecase.DisableTOTP"
+
+	ctx, cancel := context.WithTimeout(ctx, mu.contextTimeout)
+	defer cancel()
+
+	user, err := mu.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	match, err := user.Password.Matches(password)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !match {
+		return errors.E(op, errors.KindInvalidCredentials, domain.ErrInvalidCredentials)
+	}
+
+	err = mu.mfaRepo.DeleteTOTP(ctx, userID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// Enabled reports whether the user has confirmed TOTP, and so needs a code to log in.
+func (mu *mfaUsecase) Enabled(ctx context.Context, userID int64) (bool, error) {
+	const op errors.Op = "mfaUsecase.Enabled"
+
+	ctx, cancel := context.WithTimeout(ctx, mu.contextTimeout)
+	defer cancel()
+
+	secret, err := mu.mfaRepo.GetTOTP(ctx, userID)
+	switch {
+	case err == nil:
+		return secret.Confirmed, nil
+	case errors.KindIs(err, errors.KindRecordNotFound):
+		return false, nil
+	default:
+		return false, errors.E(op, err)
+	}
+}
+
+// Verify verifies the TOTP code or the recovery code of the user, a recovery code is used up
+// once verified, and a TOTP code can't be used again. If the code is invalid or used, the error
+// with kind errors.KindInvalidCredentials is returned.
+func (mu *mfaUsecase) Verify(ctx context.Context, userID int64, code string) error {
+	const op errors.Op = "mfaUsecase.Verify"
+
+	ctx, cancel := context.WithTimeout(ctx, mu.contextTimeout)
+	defer cancel()
+
+	if domain.IsRecoveryCode(code) {
+		err := mu.mfaRepo.DeleteRecoveryCode(ctx, userID, domain.HashRecoveryCode(code))
+		switch {
+		case err == nil:
+			return nil
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			return errors.E(op, errors.KindInvalidCredentials, domain.ErrInvalidCredentials)
+		default:
+			return errors.E(op, err)
+		}
+	}
+
+	secret, err := mu.mfaRepo.GetTOTP(ctx, userID)
+	switch {
+	case err == nil:
+	case errors.KindIs(err, errors.KindRecordNotFound):
+		return errors.E(op, errors.KindInvalidCredentials, domain.ErrInvalidCredentials)
+	default:
+		return errors.E(op, err)
+	}
+
+	if !secret.Confirmed {
+		return errors.E(op, errors.KindInvalidCredentials, domain.ErrInvalidCredentials)
+	}
+
+	step, ok := totp.Validate(secret.Secret, code, time.Now())
+	if !ok {
+		return errors.E(op, errors.KindInvalidCredentials, domain.ErrInvalidCredentials)
+	}
+
+	err = mu.mfaRepo.UseTOTPStep(ctx, userID, step)
+	switch {
+	case err == nil:
+		return nil
+	case errors.KindIs(err, errors.KindEditConflict):
+		return errors.E(op, errors.KindInvalidCredentials, errors.Msg("totp code reused"), domain.ErrInvalidCredentials)
+	default:
+		return errors.E(op, err)
+	}
+}
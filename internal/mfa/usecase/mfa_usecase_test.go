@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	_repoMock "github.com/unknowntpo/todos/internal/domain/mocks"
+	"github.com/unknowntpo/todos/internal/testutil"
+	"github.com/unknowntpo/todos/pkg/totp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newConfirmedTOTP(t *testing.T) *domain.TOTP {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	return &domain.TOTP{UserID: 1, Secret: secret, Confirmed: true}
+}
+
+func currentCode(t *testing.T, secret string) (string, int64) {
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	require.NoError(t, err)
+	return code, step
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mfaRepo := new(_repoMock.MFARepository)
+		userRepo := new(_repoMock.UserRepository)
+
+		user := testutil.NewFakeUser(t, "Alice Smith", "alice@example.com", "pa55word", true)
+		user.ID = 1
+
+		userRepo.On("GetByID", mock.Anything, int64(1)).Return(user, nil)
+		mfaRepo.On("InsertTOTP", mock.Anything, mock.MatchedBy(func(secret *domain.TOTP) bool {
+			return secret.UserID == 1 && secret.Secret != "" && !secret.Confirmed
+		})).Return(nil)
+
+		mu := NewMFAUsecase(mfaRepo, userRepo, 3*time.Second)
+
+		enrollment, err := mu.EnrollTOTP(context.TODO(), 1)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, enrollment.Secret)
+		assert.Contains(t, enrollment.URI, "otpauth://totp/")
+		assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+		mfaRepo.AssertExpectations(t)
+	})
+
+	t.Run("Already enabled", func(t *testing.T) {
+		mfaRepo := new(_repoMock.MFARepository)
+		userRepo := new(_repoMock.UserRepository)
+
+		userRepo.On("GetByID", mock.Anything, int64(1)).Return(testutil.NewFakeUser(t, "Alice Smith", "alice@example.com", "pa55word", true), nil)
+		mfaRepo.On("InsertTOTP", mock.Anything, mock.Anything).Return(errors.E(errors.KindEditConflict, domain.ErrEditConflict))
+
+		mu := NewMFAUsecase(mfaRepo, userRepo, 3*time.Second)
+
+		_, err := mu.EnrollTOTP(context.TODO(), 1)
+		assert.True(t, errors.KindIs(err, errors.KindEditConflict))
+	})
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mfaRepo := new(_repoMock.MFARepository)
+
+		secret := newConfirmedTOTP(t)
+		secret.Confirmed = false
+		code, step := currentCode(t, secret.Secret)
+
+		mfaRepo.On("GetTOTP", mock.Anything, int64(1)).Return(secret, nil)
+		mfaRepo.On("ConfirmTOTP", mock.Anything, int64(1), step).Return(nil)
+
+		var hashes [][]byte
+		mfaRepo.On("InsertRecoveryCodes", mock.Anything, int64(1), mock.Anything).Run(func(args mock.Arguments) {
+			hashes = args.Get(2).([][]byte)
+		}).Return(nil)
+
+		mu := NewMFAUsecase(mfaRepo, new(_repoMock.UserRepository), 3*time.Second)
+
+		codes, err := mu.ConfirmTOTP(context.TODO(), 1, code)
+		assert.NoError(t, err)
+		if assert.Len(t, codes, domain.RecoveryCodeCount) {
+			// Only the hashes are stored.
+			assert.Equal(t, domain.HashRecoveryCode(codes[0]), hashes[0])
+			assert.True(t, domain.IsRecoveryCode(codes[0]))
+		}
+
+		mfaRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid code", func(t *testing.T) {
+		mfaRepo := new(_repoMock.MFARepository)
+
+		secret := newConfirmedTOTP(t)
+		secret.Confirmed = false
+
+		mfaRepo.On("GetTOTP", mock.Anything, int64(1)).Return(secret, nil)
+
+		mu := NewMFAUsecase(mfaRepo, new(_repoMock.UserRepository), 3*time.Second)
+
+		_, err := mu.ConfirmTOTP(context.TODO(), 1, "abcdef")
+		assert.True(t, errors.KindIs(err, errors.KindInvalidCredentials))
+
+		mfaRepo.AssertNotCalled(t, "ConfirmTOTP", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Already enabled", func(t *testing.T) {
+		mfaRepo := new(_repoMock.MFARepository)
+
+		secret := newConfirmedTOTP(t)
+		code, _ := currentCode(t, secret.Secret)
+
+		mfaRepo.On("GetTOTP", mock.Anything, int64(1)).Return(secret, nil)
+
+		mu := NewMFAUsecase(mfaRepo, new(_repoMock.UserRepository), 3*time.Second)
+
+		_, err := mu.ConfirmTOTP(context.TODO(), 1, code)
+		assert.True(t, errors.KindIs(err, errors.KindEditConflict))
+	})
+}
+
+func TestDisableTOTP(t *testing.T) {
+	user := testutil.NewFakeUser(t, "Alice Smith", "alice@example.com", "pa55word", true)
+
+	t.Run("Success", func(t *testing.T) {
+		mfaRepo := new(_repoMock.MFARepository)
+		userRepo := new(_repoMock.UserRepository)
+
+		userRepo.On("GetByID", mock.Anything, int64(1)).Return(user, nil)
+		mfaRepo.On("DeleteTOTP", mock.Anything, int64(1)).Return(nil)
+
+		mu := NewMFAUsecase(mfaRepo, userRepo, 3*time.Second)
+
+		err := mu.DisableTOTP(context.TODO(), 1, "pa55word")
+		assert.NoError(t, err)
+
+		mfaRepo.AssertExpectations(t)
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		mfaRepo := new(_repoMock.MFARepository)
+		userRepo := new(_repoMock.UserRepository)
+
+		userRepo.On("GetByID", mock.Anything, int64(1)).Return(user, nil)
+
+		mu := NewMFAUsecase(mfaRepo, userRepo, 3*time.Second)
+
+		err := mu.DisableTOTP(context.TODO(), 1, "wrong password")
+		assert.True(t, errors.KindIs(err, errors.KindInvalidCredentials))
+
+		mfaRepo.AssertNotCalled(t, "DeleteTOTP", mock.Anything, mock.Anything)
+	})
+}
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret *domain.TOTP
+		err    error
+		want   bool
+	}{
+		{"Confirmed", &domain.TOTP{UserID: 1, Confirmed: true}, nil, true},
+		{"Unconfirmed", &domain.TOTP{UserID: 1}, nil, false},
+		{"Not enrolled", nil, errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mfaRepo := new(_repoMock.MFARepository)
+			mfaRepo.On("GetTOTP", mock.Anything, int64(1)).Return(tt.secret, tt.err)
+
+			mu := NewMFAUsecase(mfaRepo, new(_repoMock.UserRepository), 3*time.Second)
+
+			got, err := mu.Enabled(context.TODO(), 1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Run("TOTP code", func(t *testing.T) {
+		mfaRepo := new(_repoMock.MFARepository)
+
+		secret := newConfirmedTOTP(t)
+		code, step := currentCode(t, secret.Secret)
+
+		mfaRepo.On("GetTOTP", mock.Anything, int64(1)).Return(secret, nil)
+		mfaRepo.On("UseTOTPStep", mock.Anything, int64(1), step).Return(nil)
+
+		mu := NewMFAUsecase(mfaRepo, new(_repoMock.UserRepository), 3*time.Second)
+
+		err := mu.Verify(context.TODO(), 1, code)
+		assert.NoError(t, err)
+
+		mfaRepo.AssertExpectations(t)
+	})
+
+	t.Run("Reused TOTP code", func(t *testing.T) {
+		mfaRepo := new(_repoMock.MFARepository)
+
+		secret := newConfirmedTOTP(t)
+		code, step := currentCode(t, secret.Secret)
+
+		mfaRepo.On("GetTOTP", mock.Anything, int64(1)).Return(secret, nil)
+		mfaRepo.On("UseTOTPStep", mock.Anything, int64(1), step).Return(errors.E(errors.KindEditConflict, domain.ErrEditConflict))
+
+		mu := NewMFAUsecase(mfaRepo, new(_repoMock.UserRepository), 3*time.Second)
+
+		err := mu.Verify(context.TODO(), 1, code)
+		assert.True(t, errors.KindIs(err, errors.KindInvalidCredentials))
+	})
+
+	t.Run("Unconfirmed TOTP", func(t *testing.T) {
+		mfaRepo := new(_repoMock.MFARepository)
+
+		secret := newConfirmedTOTP(t)
+		secret.Confirmed = false
+		code, _ := currentCode(t, secret.Secret)
+
+		mfaRepo.On("GetTOTP", mock.Anything, int64(1)).Return(secret, nil)
+
+		mu := NewMFAUsecase(mfaRepo, new(_repoMock.UserRepository), 3*time.Second)
+
+		err := mu.Verify(context.TODO(), 1, code)
+		assert.True(t, errors.KindIs(err, errors.KindInvalidCredentials))
+	})
+
+	t.Run("Recovery code", func(t *testing.T) {
+		mfaRepo := new(_repoMock.MFARepository)
+		mfaRepo.On("DeleteRecoveryCode", mock.Anything, int64(1), domain.HashRecoveryCode("abcde-fghij")).Return(nil)
+
+		mu := NewMFAUsecase(mfaRepo, new(_repoMock.UserRepository), 3*time.Second)
+
+		// Recovery codes are case insensitive.
+		err := mu.Verify(context.TODO(), 1, "ABCDE-FGHIJ")
+		assert.NoError(t, err)
+
+		mfaRepo.AssertExpectations(t)
+	})
+
+	t.Run("Used recovery code", func(t *testing.T) {
+		mfaRepo := new(_repoMock.MFARepository)
+		mfaRepo.On("DeleteRecoveryCode", mock.Anything, int64(1), mock.Anything).Return(errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound))
+
+		mu := NewMFAUsecase(mfaRepo, new(_repoMock.UserRepository), 3*time.Second)
+
+		err := mu.Verify(context.TODO(), 1, "abcde-fghij")
+		assert.True(t, errors.KindIs(err, errors.KindInvalidCredentials))
+	})
+}
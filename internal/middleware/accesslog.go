@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/helpers"
+	"github.com/unknowntpo/todos/internal/logger"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/julienschmidt/httprouter"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the ID of the request. The ID a client or a proxy sends along is kept,
+// so that the request can be followed across services, otherwise a new one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the maximum length of the request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+// requestInfo collects what's only known by the handlers deeper in the chain, which run with
+// copies of the request, for the access log line and the metrics.
+type requestInfo struct {
+	route  string
+	userID int64
+}
+
+type requestInfoContextKey struct{}
+
+// withRequestInfo returns the request info stored in the request context, if there's none, a new
+// one is stored in the returned copy of the request.
+func withRequestInfo(r *http.Request) (*http.Request, *requestInfo) {
+	if info, ok := r.Context().Value(requestInfoContextKey{}).(*requestInfo); ok {
+		return r, info
+	}
+
+	info := &requestInfo{}
+	return r.WithContext(context.WithValue(r.Context(), requestInfoContextKey{}, info)), info
+}
+
+// AccessLog accepts or generates the ID of the request, echoes it in the X-Request-ID header, and
+// stores it in the request context along with the logger of the request, which adds the request
+// ID to every entry, so that handlers and usecases can log with logger.FromContext. Once the
+// response is sent, it prints one line with the method, route pattern, status, latency, bytes
+// written, user ID and client IP of the request. It relies on ClientIP running before, and Trace
+// too, for the trace ID to be added to the entries.
+func (mid *Middleware) AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+
+		fields := map[string]interface{}{"request_id": id}
+
+		// The trace ID links the log entries of the request to its trace.
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			fields["trace_id"] = sc.TraceID().String()
+		}
+
+		log := mid.rc.Logger.With(fields)
+
+		r, info := withRequestInfo(r)
+
+		ctx := domain.ContextWithRequestID(r.Context(), id)
+		ctx = logger.NewContext(ctx, log)
+
+		// The method is read before, the request may be changed by the handlers.
+		method := r.Method
+
+		metrics := httpsnoop.CaptureMetrics(next, w, r.WithContext(ctx))
+
+		properties := map[string]interface{}{
+			"request_method": method,
+			"status":         metrics.Code,
+			"latency_ms":     float64(metrics.Duration.Microseconds()) / 1000,
+			"bytes":          metrics.Written,
+			"client_ip":      domain.ClientFromContext(r.Context()).IP,
+		}
+
+		// Requests refused before they're routed have no route.
+		if info.route != "" {
+			properties["route"] = info.route
+		}
+
+		if info.userID != 0 {
+			properties["user_id"] = info.userID
+		}
+
+		log.PrintInfo("request completed", properties)
+	})
+}
+
+// Route records the pattern of the route the router matches the request with, e.g.
+// "/v1/tasks/:id", for the access log and the metrics, which can't use the path because of the
+// IDs and tokens in it. It wraps the router, so that it sees the path the workspace prefix has
+// been stripped from.
+func (mid *Middleware) Route(router *httprouter.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info, ok := r.Context().Value(requestInfoContextKey{}).(*requestInfo); ok {
+			if handle, ps, _ := router.Lookup(r.Method, r.URL.Path); handle != nil {
+				info.route = routePattern(r.URL.Path, ps)
+			}
+		}
+
+		router.ServeHTTP(w, r)
+	})
+}
+
+// routePattern replaces the values of the parameters in the path with their names. A catch-all
+// parameter takes the rest of the path, the values of named ones are matched from the end of
+// the path, so that the literal segments before them aren't taken for their values.
+func routePattern(path string, ps httprouter.Params) string {
+	if len(ps) == 0 {
+		return path
+	}
+
+	var catchAll string
+	if last := ps[len(ps)-1]; strings.HasPrefix(last.Value, "/") && strings.HasSuffix(path, last.Value) {
+		path = strings.TrimSuffix(path, last.Value)
+		catchAll = "/*" + last.Key
+		ps = ps[:len(ps)-1]
+	}
+
+	segments := strings.Split(path, "/")
+
+	i := len(segments) - 1
+	for j := len(ps) - 1; j >= 0; j-- {
+		for i > 0 && segments[i] != ps[j].Value {
+			i--
+		}
+
+		if i == 0 {
+			break
+		}
+
+		segments[i] = ":" + ps[j].Key
+		i--
+	}
+
+	return strings.Join(segments, "/") + catchAll
+}
+
+// setUser stores the authenticated user in the request context, and adds the ID of the user
+// to the access log line and the logger of the request.
+func setUser(r *http.Request, user *domain.User) *http.Request {
+	ctx := r.Context()
+
+	if info, ok := ctx.Value(requestInfoContextKey{}).(*requestInfo); ok {
+		info.userID = user.ID
+	}
+
+	if log := logger.FromContext(ctx, nil); log != nil {
+		ctx = logger.NewContext(ctx, log.With(map[string]interface{}{"user_id": user.ID}))
+		r = r.WithContext(ctx)
+	}
+
+	return helpers.ContextSetUser(r, user)
+}
+
+// validRequestID reports whether the request ID sent by the client can be kept, it mustn't be
+// too long or contain characters which could forge log lines or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.ContainsRune("-_.:+/=@", c):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID returns a random request ID of 32 hexadecimal digits.
+func newRequestID() string {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		// The time still tells requests apart, should the source of randomness fail.
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+
+	return hex.EncodeToString(random)
+}
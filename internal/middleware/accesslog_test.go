@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutePattern(t *testing.T) {
+	tests := []struct {
+		path string
+		ps   httprouter.Params
+		want string
+	}{
+		{"/v1/tasks", nil, "/v1/tasks"},
+		{"/v1/tasks/42", httprouter.Params{{Key: "id", Value: "42"}}, "/v1/tasks/:id"},
+		{"/v1/tasks/7/shares/7", httprouter.Params{{Key: "id", Value: "7"}, {Key: "user_id", Value: "7"}}, "/v1/tasks/:id/shares/:user_id"},
+		{"/v1/users/users", httprouter.Params{{Key: "id", Value: "users"}}, "/v1/users/:id"},
+		{"/swagger/index.html", httprouter.Params{{Key: "any", Value: "/index.html"}}, "/swagger/*any"},
+		{"/swagger/", httprouter.Params{{Key: "any", Value: "/"}}, "/swagger/*any"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, routePattern(tt.path, tt.ps), tt.path)
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	assert.True(t, validRequestID("f2b1c0de-1234-4cba-9d2e-0a1b2c3d4e5f"))
+	assert.True(t, validRequestID("Root=1-67891233-abcdef012345678912345678"))
+	assert.False(t, validRequestID(""))
+	assert.False(t, validRequestID("abc\ndef"))
+	assert.False(t, validRequestID(`abc" def`))
+	assert.False(t, validRequestID(strings.Repeat("a", maxRequestIDLength+1)))
+
+	id := newRequestID()
+	assert.Len(t, id, 32)
+	assert.True(t, validRequestID(id))
+}
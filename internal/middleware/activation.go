@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// activationTTL is how long the activation state of the users of signed tokens is cached, so
+// a deactivated user keeps access for at most that long instead of until the token expires.
+const activationTTL = 30 * time.Second
+
+type activationEntry struct {
+	activated bool
+	expires   time.Time
+}
+
+// activationCache caches the activation state of users by their IDs, so that the signed tokens
+// don't need a database lookup on every request.
+type activationCache struct {
+	mu        sync.Mutex
+	entries   map[int64]activationEntry
+	lastSweep time.Time
+}
+
+func newActivationCache() *activationCache {
+	return &activationCache{entries: make(map[int64]activationEntry)}
+}
+
+// get returns the cached activation state of the user, and reports whether it's cached.
+func (c *activationCache) get(userID int64, now time.Time) (activated, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, found := c.entries[userID]
+	if !found || !now.Before(e.expires) {
+		return false, false
+	}
+
+	return e.activated, true
+}
+
+// set caches the activation state of the user for activationTTL.
+func (c *activationCache) set(userID int64, activated bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Forget the expired entries once in a while, so that the users who stopped sending
+	// requests don't pile up.
+	if now.Sub(c.lastSweep) >= activationTTL {
+		for id, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, id)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	c.entries[userID] = activationEntry{activated: activated, expires: now.Add(activationTTL)}
+}
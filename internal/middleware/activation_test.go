@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivationCache(t *testing.T) {
+	now := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	c := newActivationCache()
+
+	_, ok := c.get(1, now)
+	assert.False(t, ok)
+
+	c.set(1, true, now)
+
+	activated, ok := c.get(1, now.Add(activationTTL-time.Second))
+	assert.True(t, ok)
+	assert.True(t, activated)
+
+	_, ok = c.get(1, now.Add(activationTTL))
+	assert.False(t, ok, "expired state should be looked up again")
+
+	c.set(2, false, now.Add(activationTTL))
+	assert.NotContains(t, c.entries, int64(1), "expired entry should be forgotten")
+}
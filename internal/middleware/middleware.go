@@ -1,12 +1,13 @@
 package middleware
 
 import (
+	"context"
+	"crypto/subtle"
 	"expvar"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/unknowntpo/todos/config"
@@ -14,23 +15,32 @@ import (
 	"github.com/unknowntpo/todos/internal/domain/errors"
 	"github.com/unknowntpo/todos/internal/helpers"
 	"github.com/unknowntpo/todos/internal/reactor"
+	"github.com/unknowntpo/todos/pkg/clientip"
 	"github.com/unknowntpo/todos/pkg/validator"
 
 	"github.com/felixge/httpsnoop"
-	"github.com/tomasen/realip"
-	"golang.org/x/time/rate"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 type Middleware struct {
-	config  *config.Config
-	usecase domain.UserUsecase
-	rc      *reactor.Reactor
+	config            *config.Config
+	usecase           domain.UserUsecase
+	tokenUsecase      domain.TokenUsecase
+	workspaceUsecase  domain.WorkspaceUsecase
+	permissionUsecase domain.PermissionUsecase
+	limiter           domain.RateLimiter
+	activation        *activationCache
+	rc                *reactor.Reactor
 }
 
-func New(cfg *config.Config, uu domain.UserUsecase, rc *reactor.Reactor) *Middleware {
-	return &Middleware{config: cfg, usecase: uu, rc: rc}
+func New(cfg *config.Config, uu domain.UserUsecase, tu domain.TokenUsecase, wu domain.WorkspaceUsecase, pu domain.PermissionUsecase, rl domain.RateLimiter, rc *reactor.Reactor) *Middleware {
+	return &Middleware{config: cfg, usecase: uu, tokenUsecase: tu, workspaceUsecase: wu, permissionUsecase: pu, limiter: rl, activation: newActivationCache(), rc: rc}
 }
 
+// maxUserAgentLength is the maximum length of the User-Agent header recorded for the sessions.
+const maxUserAgentLength = 512
+
 func (mid *Middleware) RecoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -44,123 +54,394 @@ func (mid *Middleware) RecoverPanic(next http.Handler) http.Handler {
 	})
 }
 
+// RateLimit takes a token from the bucket of the first policy matching the request, and refuses
+// the request if there's none left. It runs after Authenticate, so that the policies can count
+// the requests by user, the failed authentications are counted by Authenticate.
 func (mid *Middleware) RateLimit(next http.Handler) http.Handler {
-	type client struct {
-		limiter  *rate.Limiter
-		lastSeen time.Time
+	policies := newRateLimitPolicies(&mid.config.Limiter)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := mid.rateLimitPolicy(policies, r)
+		if policy == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		res, err := mid.limiter.Allow(r.Context(), policy.key(r), policy.limit)
+		if err != nil {
+			mid.rc.ServerErrorResponse(w, r, err)
+			return
+		}
+
+		setRateLimitHeaders(w, policy.limit, res)
+
+		if !res.Allowed {
+			mid.rc.RateLimitExceededResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitPolicy returns the first of the policies matching the request, or nil if none does
+// or the limiter is disabled.
+func (mid *Middleware) rateLimitPolicy(policies []*rateLimitPolicy, r *http.Request) *rateLimitPolicy {
+	if !mid.config.Limiter.Enabled {
+		return nil
 	}
-	// Declare a mutex and a map to hold the clients' IP addresses and rate limiters.
-	var (
-		mu      sync.Mutex
-		clients = make(map[string]*client)
-	)
-
-	// Launch a background goroutine which removes old entries from the clients map once
-	// every minute.
-	go func() {
-		for {
-			time.Sleep(time.Minute)
-
-			mu.Lock()
-
-			// Loop through all clients. If they haven't been seen within the last three
-			// minutes, delete the corresponding entry from the map.
-			for ip, client := range clients {
-				if time.Since(client.lastSeen) > 3*time.Minute {
-					delete(clients, ip)
-				}
-			}
 
-			mu.Unlock()
+	for _, p := range policies {
+		if p.matches(r) {
+			return p
 		}
-	}()
+	}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return nil
+}
 
-		if mid.config.Limiter.Enabled {
-			// Use the realip.FromRequest() function to get the client's real IP address.
-			ip := realip.FromRequest(r)
+// limitAuthentication refuses the request if the bucket of its client IP is empty, before its
+// credentials are looked up, so that the tokens can't be guessed faster than the anonymous
+// requests are allowed. It reports whether the request is refused.
+func (mid *Middleware) limitAuthentication(w http.ResponseWriter, r *http.Request, policy *rateLimitPolicy) bool {
+	if policy == nil {
+		return false
+	}
 
-			mu.Lock()
+	res, err := mid.limiter.Peek(r.Context(), policy.ipKey(r), policy.limit)
+	if err != nil {
+		mid.rc.ServerErrorResponse(w, r, err)
+		return true
+	}
 
-			// Check to see if the IP address already exists in the map. If it doesn't, then
-			// initialize a new rate limiter and add the IP address and limiter to the map.
-			if _, found := clients[ip]; !found {
-				clients[ip] = &client{
-					limiter: rate.NewLimiter(rate.Limit(mid.config.Limiter.Rps), mid.config.Limiter.Burst),
-				}
-			}
+	if !res.Allowed {
+		setRateLimitHeaders(w, policy.limit, res)
+		mid.rc.RateLimitExceededResponse(w, r)
+		return true
+	}
 
-			clients[ip].lastSeen = time.Now()
+	return false
+}
 
-			// Call the Allow() method on the rate limiter for the current IP address. If
-			// the request isn't allowed, unlock the mutex and send a 429 Too Many Requests
-			// response, just like before.
-			if !clients[ip].limiter.Allow() {
-				mu.Unlock()
-				mid.rc.RateLimitExceededResponse(w, r)
-				return
-			}
+// invalidAuthenticationToken takes a token from the bucket of the client IP for the failed
+// authentication, which never reaches RateLimit, and sends the 401 Unauthorized response.
+func (mid *Middleware) invalidAuthenticationToken(w http.ResponseWriter, r *http.Request, policy *rateLimitPolicy) {
+	if policy != nil {
+		res, err := mid.limiter.Allow(r.Context(), policy.ipKey(r), policy.limit)
+		if err != nil {
+			mid.rc.ServerErrorResponse(w, r, err)
+			return
+		}
 
-			mu.Unlock()
+		setRateLimitHeaders(w, policy.limit, res)
+	}
+
+	mid.rc.InvalidAuthenticationTokenResponse(w, r)
+}
+
+// ValidateTrustedProxies reports whether the trusted proxies and their header of the configuration
+// are valid, it's called at startup so that ClientIP can rely on them.
+func ValidateTrustedProxies(cfg *config.Proxy) error {
+	_, err := clientip.NewResolver(cfg.Header, cfg.Trusted)
+	return err
+}
+
+// ClientIP resolves the client the request comes from once, and stores it in the request context,
+// where it's used by rate limiting, logging, and the authentication tokens issued to or used by the
+// client, which are listed as the sessions of the user. Only the forwarding header the trusted
+// proxies set is believed, and only when the request comes from one of them.
+func (mid *Middleware) ClientIP(next http.Handler) http.Handler {
+	resolver, err := clientip.NewResolver(mid.config.Proxy.Header, mid.config.Proxy.Trusted)
+	if err != nil {
+		// The configuration is validated at startup, trust no proxy just in case.
+		resolver = new(clientip.Resolver)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent := r.UserAgent()
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
 		}
 
+		r = r.WithContext(domain.ContextWithClient(r.Context(), domain.Client{
+			IP:        resolver.ClientIP(r),
+			UserAgent: userAgent,
+		}))
+
 		next.ServeHTTP(w, r)
 	})
 }
 
+// Authenticate authenticates the request with the token in the Authorization header or the session
+// cookie, and stores its user in the request context. The requests without credentials are
+// anonymous. The failed authentications are rate limited by the client IP, since they never
+// reach RateLimit.
 func (mid *Middleware) Authenticate(next http.Handler) http.Handler {
+	policies := newRateLimitPolicies(&mid.config.Limiter)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// This indicates to any caches that the response may vary
 		// based on the value of the Authorization
 		// header in the request.
 		w.Header().Add("Vary", "Authorization")
+		if mid.config.Session.Enabled {
+			w.Header().Add("Vary", "Cookie")
+		}
 
 		authorizationHeader := r.Header.Get("Authorization")
 
 		if authorizationHeader == "" {
+			if cookie, err := r.Cookie(SessionCookie); err == nil && mid.config.Session.Enabled {
+				if mid.limitAuthentication(w, r, mid.rateLimitPolicy(policies, r)) {
+					return
+				}
+
+				mid.authenticateSession(w, r, next, cookie.Value)
+				return
+			}
+
 			r = helpers.ContextSetUser(r, domain.AnonymousUser)
 			next.ServeHTTP(w, r)
 			return
 		}
 
+		policy := mid.rateLimitPolicy(policies, r)
+		if mid.limitAuthentication(w, r, policy) {
+			return
+		}
+
 		// Expect the value of the Authorization header to be in the format
 		// "Bearer <token>".
 		headerParts := strings.Split(authorizationHeader, " ")
 		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-			mid.rc.InvalidAuthenticationTokenResponse(w, r)
+			mid.invalidAuthenticationToken(w, r, policy)
 			return
 		}
 
 		token := headerParts[1]
 
+		if domain.IsPersonalAccessToken(token) {
+			mid.authenticatePersonalToken(w, r, next, token, policy)
+			return
+		}
+
+		user, err := mid.userForToken(r.Context(), token)
+		if err != nil {
+			switch {
+			case errors.KindIs(err, errors.KindRecordNotFound):
+				mid.invalidAuthenticationToken(w, r, policy)
+			default:
+				mid.rc.ServerErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		r = setUser(r, user)
+		r = helpers.ContextSetToken(r, token)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticateSession authenticates the request with the authentication token in the session cookie.
+// The state-changing requests have to pass the CSRF check. If the token is no longer valid, the
+// cookies are dropped and the request goes on anonymously, so that the client can log in again,
+// and RateLimit counts it by the client IP.
+func (mid *Middleware) authenticateSession(w http.ResponseWriter, r *http.Request, next http.Handler, token string) {
+	if !isSafeMethod(r.Method) && !ValidCSRF(r) {
+		mid.rc.InvalidCSRFTokenResponse(w, r)
+		return
+	}
+
+	user, err := mid.userForToken(r.Context(), token)
+	switch {
+	case err == nil:
+		r = setUser(r, user)
+		r = helpers.ContextSetToken(r, token)
+	case errors.KindIs(err, errors.KindRecordNotFound):
+		ClearSessionCookies(w)
+		r = helpers.ContextSetUser(r, domain.AnonymousUser)
+	default:
+		mid.rc.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	next.ServeHTTP(w, r)
+}
+
+// userForToken returns the user the signed or opaque authentication token belongs to, and marks
+// the opaque one as used. If the token is invalid or expired, the error with kind
+// errors.KindRecordNotFound is returned.
+func (mid *Middleware) userForToken(ctx context.Context, token string) (*domain.User, error) {
+	const op errors.Op = "middleware.userForToken"
+
+	if domain.IsSignedToken(token) {
+		// Signed tokens are verified without hitting database, and they don't carry anything
+		// else about the user. The user may have been deactivated since the token was issued,
+		// so the activation state is looked up, and cached for a while.
+		t, err := mid.tokenUsecase.Verify(token)
+		if err != nil {
+			return nil, errors.E(op, errors.KindRecordNotFound, err)
+		}
+
+		activated, err := mid.activated(ctx, t.UserID)
+		if err != nil {
+			return nil, errors.E(op, err)
+		}
+
+		return &domain.User{ID: t.UserID, Activated: activated}, nil
+	}
+
+	v := validator.New()
+
+	if domain.ValidateTokenPlaintext(v, token); !v.Valid() {
+		return nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	user, err := mid.usecase.Authenticate(ctx, domain.ScopeAuthentication, token)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	err = mid.tokenUsecase.Touch(ctx, token)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return user, nil
+}
+
+// activated reports whether the user is activated, the state is cached for activationTTL. If the
+// user has been deleted, the error with kind errors.KindRecordNotFound is returned.
+func (mid *Middleware) activated(ctx context.Context, userID int64) (bool, error) {
+	const op errors.Op = "middleware.activated"
+
+	if activated, ok := mid.activation.get(userID, time.Now()); ok {
+		return activated, nil
+	}
+
+	user, err := mid.usecase.GetByID(ctx, userID)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	mid.activation.set(userID, user.Activated, time.Now())
+
+	return user.Activated, nil
+}
+
+// authenticatePersonalToken authenticates the request with the personal access token, and stores
+// its scopes in the request context, so that it's only good for the routes requiring one of them.
+// The failed authentication is counted against the bucket of the client IP in the policy.
+func (mid *Middleware) authenticatePersonalToken(w http.ResponseWriter, r *http.Request, next http.Handler, token string, policy *rateLimitPolicy) {
+	ctx := r.Context()
+	pat, err := mid.tokenUsecase.AuthenticatePersonalToken(ctx, token)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			mid.invalidAuthenticationToken(w, r, policy)
+		default:
+			mid.rc.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	user, err := mid.usecase.GetByID(ctx, pat.UserID)
+	if err != nil {
+		mid.rc.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	r = setUser(r, user)
+	r = helpers.ContextSetToken(r, token)
+	r = helpers.ContextSetTokenScopes(r, pat.Scopes)
+
+	next.ServeHTTP(w, r)
+}
+
+// Workspace resolves the workspace the request operates on and stores it in the request
+// context, so that every task query is restricted to that workspace. The workspace is
+// identified by its slug, either in the X-Workspace header or in the /w/<slug> path prefix,
+// which is stripped before the request is routed. Requests without a workspace operate on
+// the personal space of the user. If the user isn't a member of the workspace, a 404 Not
+// Found response is sent, so that the existence of the workspace isn't revealed.
+func (mid *Middleware) Workspace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "X-Workspace")
+
+		slug := r.Header.Get("X-Workspace")
+
+		if prefixSlug, rest, ok := splitWorkspacePath(r.URL.Path); ok {
+			if rest == "" {
+				mid.rc.NotFoundResponse(w, r)
+				return
+			}
+
+			slug = prefixSlug
+			r.URL.Path = rest
+			r.URL.RawPath = ""
+		}
+
+		if slug == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user := helpers.ContextGetUser(r)
+		if user.IsAnonymous() {
+			mid.rc.AuthenticationRequiredResponse(w, r)
+			return
+		}
+
 		v := validator.New()
 
-		if domain.ValidateTokenPlaintext(v, token); !v.Valid() {
-			mid.rc.InvalidAuthenticationTokenResponse(w, r)
+		if domain.ValidateWorkspaceSlug(v, slug); !v.Valid() {
+			mid.rc.NotFoundResponse(w, r)
 			return
 		}
 
-		ctx := r.Context()
-		user, err := mid.usecase.Authenticate(ctx, domain.ScopeAuthentication, token)
+		ws, err := mid.workspaceUsecase.GetBySlug(r.Context(), user.ID, slug)
 		if err != nil {
 			switch {
 			case errors.KindIs(err, errors.KindRecordNotFound):
-				mid.rc.InvalidAuthenticationTokenResponse(w, r)
+				mid.rc.NotFoundResponse(w, r)
 			default:
 				mid.rc.ServerErrorResponse(w, r, err)
 			}
 			return
 		}
 
-		r = helpers.ContextSetUser(r, user)
+		r = helpers.ContextSetWorkspace(r, ws)
 
 		next.ServeHTTP(w, r)
 	})
 }
 
-// RequireAuthenticatedUser checks that a user is not anonymous.
+// splitWorkspacePath splits the path into the slug of its /w/<slug> prefix and the rest of it,
+// which is empty if nothing follows the slug. It reports whether the path has the prefix.
+func splitWorkspacePath(path string) (slug, rest string, ok bool) {
+	rest = strings.TrimPrefix(path, "/w/")
+	if rest == path {
+		return "", "", false
+	}
+
+	i := strings.Index(rest, "/")
+	if i < 0 {
+		return rest, "", true
+	}
+
+	return rest[:i], rest[i:], true
+}
+
+// RequireAuthenticatedUser checks that a user is not anonymous. Personal access tokens
+// are rejected, since they're only good for the routes requiring one of their scopes.
 func (mid *Middleware) RequireAuthenticatedUser(next http.Handler) http.Handler {
+	return mid.requireAuthenticatedUser("", next)
+}
+
+// requireAuthenticatedUser checks that a user is not anonymous, and if the request carries
+// a personal access token, that the token has the scope.
+func (mid *Middleware) requireAuthenticatedUser(scope string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := helpers.ContextGetUser(r)
 
@@ -169,12 +450,21 @@ func (mid *Middleware) RequireAuthenticatedUser(next http.Handler) http.Handler
 			return
 		}
 
+		if scopes, ok := helpers.ContextGetTokenScopes(r); ok && !scopes.Include(scope) {
+			mid.rc.InsufficientScopeResponse(w, r)
+			return
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
 
 // RequireActivatedUser checks if a user is both authenticated and activated.
 func (mid *Middleware) RequireActivatedUser(next http.Handler) http.Handler {
+	return mid.requireActivatedUser("", next)
+}
+
+func (mid *Middleware) requireActivatedUser(scope string, next http.Handler) http.Handler {
 	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Retrieve the user information from the request context.
 		user := helpers.ContextGetUser(r)
@@ -187,10 +477,51 @@ func (mid *Middleware) RequireActivatedUser(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 
-	// Use mid.RequireAuthenticatedUser to check if a user is authenticated.
-	return mid.RequireAuthenticatedUser(fn)
+	// Use mid.requireAuthenticatedUser to check if a user is authenticated.
+	return mid.requireAuthenticatedUser(scope, fn)
+}
+
+// RequirePermission checks if a user is activated and has been granted the permission
+// with given code. Personal access tokens must have the permission code as a scope.
+func (mid *Middleware) RequirePermission(code string, next http.Handler) http.Handler {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := helpers.ContextGetUser(r)
+
+		permissions, err := mid.permissionUsecase.GetAllForUser(r.Context(), user.ID)
+		if err != nil {
+			mid.rc.ServerErrorResponse(w, r, err)
+			return
+		}
+
+		if !permissions.Include(code) {
+			mid.rc.NotPermittedResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	// Use mid.requireActivatedUser to check if a user is activated.
+	return mid.requireActivatedUser(code, fn)
 }
 
+// The metrics of the HTTP requests served in the Prometheus text format at /metrics.
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "The latency of the HTTP requests, by method, route pattern and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	httpRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "The number of HTTP requests being served.",
+	})
+)
+
+// Metrics counts the requests and measures their latency. The global counters are published
+// as expvar variables at /debug/vars, and the latency histograms labeled by method, route
+// pattern and status are served at /metrics. Requests refused before they're routed have an
+// empty route.
 func (mid *Middleware) Metrics(next http.Handler) http.Handler {
 	// Initialize the new expvar variables when the middleware chain is first built.
 	totalRequestsReceived := expvar.NewInt("total_requests_received")
@@ -206,22 +537,83 @@ func (mid *Middleware) Metrics(next http.Handler) http.Handler {
 		// Increment the requests received count, like before.
 		totalRequestsReceived.Add(1)
 
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
+
+		r, info := withRequestInfo(r)
+
+		// The method is read before, the request may be changed by the handlers.
+		method := metricsMethod(r.Method)
+
 		// Call the httpsnoop.CaptureMetrics() function, passing in the next handler in
 		// the chain along with the existing http.ResponseWriter and http.Request. This
 		// returns the metrics struct that we saw above.
-		metrics := httpsnoop.CaptureMetrics(next, w, r)
+		m := httpsnoop.CaptureMetrics(next, w, r)
 
 		// Increment the response sent count, like before.
 		totalResponsesSent.Add(1)
 
 		// Get the request processing time in microseconds from httpsnoop and increment
 		// the cumulative processing time.
-		totalProcessingTimeMicroseconds.Add(metrics.Duration.Microseconds())
+		totalProcessingTimeMicroseconds.Add(m.Duration.Microseconds())
 
 		// Use the Add() method to increment the count for the given status code by 1.
 		// Note that the expvar map is string-keyed, so we need to use the strconv.Itoa()
 		// function to convert the status code (which is an integer) to a string.
-		totalResponsesSentByStatus.Add(strconv.Itoa(metrics.Code), 1)
+		status := strconv.Itoa(m.Code)
+		totalResponsesSentByStatus.Add(status, 1)
+
+		httpRequestDuration.WithLabelValues(method, info.route, status).Observe(m.Duration.Seconds())
+	})
+}
+
+// metricsMethod returns the method for the metric labels, the methods the API doesn't use are
+// reported as "OTHER", so that clients can't make up any number of label values.
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// ValidateMetricsAuth reports whether the basic auth credentials of the metrics endpoint are
+// valid, it's called at startup so that RequireMetricsAuth can rely on them.
+func ValidateMetricsAuth(cfg *config.Metrics) error {
+	if cfg.Username != "" && cfg.Password == "" {
+		return fmt.Errorf("the password of the metrics endpoint must be set along with the username")
+	}
+
+	return nil
+}
+
+// RequireMetricsAuth checks the basic auth credentials of the scrapers, if the username of the
+// metrics endpoint is configured.
+func (mid *Middleware) RequireMetricsAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := mid.config.Metrics
+
+		if cfg.Username == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		username, password, ok := r.BasicAuth()
+
+		// Both are compared in constant time, so that the time taken doesn't tell which
+		// one is wrong.
+		usernameMatch := subtle.ConstantTimeCompare([]byte(username), []byte(cfg.Username))
+		passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(cfg.Password))
+
+		if !ok || usernameMatch&passwordMatch != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="metrics", charset="UTF-8"`)
+			mid.rc.InvalidCredentialsResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
 	})
 }
 
@@ -242,12 +634,17 @@ func (mid *Middleware) EnableCORS(next http.Handler) http.Handler {
 				if origin == mid.config.Cors.TrustedOrigins[i] {
 					w.Header().Set("Access-Control-Allow-Origin", origin)
 
+					// The pages of trusted origins can make requests with the session cookies.
+					if mid.config.Session.Enabled {
+						w.Header().Set("Access-Control-Allow-Credentials", "true")
+					}
+
 					// Check if the request has the HTTP method OPTIONS and contains the
 					// "Access-Control-Request-Method" header. If it does, then we treat
 					// it as a preflight request.
 					if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
 						w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PUT, PATCH, DELETE")
-						w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+						w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Workspace, "+CSRFHeader)
 
 						// Write the headers along with a 200 OK status and return from
 						// the middleware with no further action.
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unknowntpo/todos/config"
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/helpers"
+)
+
+// rateLimitPolicy is the limit of the requests matching its method and route pattern.
+type rateLimitPolicy struct {
+	// name prefixes the keys of the buckets, so that every policy counts the requests separately.
+	name   string
+	method string
+	// segments are the segments of the route pattern, nil matches any path.
+	segments []string
+	limit    domain.RateLimit
+	byUser   bool
+}
+
+// ValidateRateLimits reports whether the default limit and the policies of the configuration
+// are valid, it's called at startup so that RateLimit can rely on them.
+func ValidateRateLimits(cfg *config.Limiter) error {
+	if _, err := newRateLimitPolicy("", cfg.Rps, cfg.Burst, cfg.By); err != nil {
+		return fmt.Errorf("invalid default rate limit: %v", err)
+	}
+
+	for _, p := range cfg.Policies {
+		if _, err := newRateLimitPolicy(p.Route, p.Rps, p.Burst, p.By); err != nil {
+			return fmt.Errorf("invalid rate limit policy %q: %v", p.Route, err)
+		}
+	}
+
+	return nil
+}
+
+// newRateLimitPolicies returns the policies of the configuration followed by the default one,
+// which matches any request.
+func newRateLimitPolicies(cfg *config.Limiter) []*rateLimitPolicy {
+	var policies []*rateLimitPolicy
+
+	for _, p := range cfg.Policies {
+		if policy, err := newRateLimitPolicy(p.Route, p.Rps, p.Burst, p.By); err == nil {
+			policies = append(policies, policy)
+		}
+	}
+
+	if policy, err := newRateLimitPolicy("", cfg.Rps, cfg.Burst, cfg.By); err == nil {
+		policies = append(policies, policy)
+	}
+
+	return policies
+}
+
+// newRateLimitPolicy parses the route, "[METHOD] /path", the empty route matches any request.
+func newRateLimitPolicy(route string, rps float64, burst int, by string) (*rateLimitPolicy, error) {
+	if rps <= 0 || burst < 1 {
+		return nil, fmt.Errorf("rps must be positive and burst at least 1")
+	}
+
+	p := &rateLimitPolicy{
+		name:  "default",
+		limit: domain.RateLimit{Rate: rps, Burst: burst},
+	}
+
+	switch by {
+	case "", "ip":
+	case "user":
+		p.byUser = true
+	default:
+		return nil, fmt.Errorf("unknown key %q, must be ip or user", by)
+	}
+
+	if route == "" {
+		return p, nil
+	}
+
+	fields := strings.Fields(route)
+
+	path := fields[len(fields)-1]
+	if len(fields) > 2 || !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("route must be [METHOD] /path")
+	}
+
+	if len(fields) == 2 {
+		p.method = strings.ToUpper(fields[0])
+	}
+
+	p.name = strings.Join(fields, " ")
+	p.segments = strings.Split(path, "/")
+
+	return p, nil
+}
+
+// matches reports whether the request matches the method and route pattern of the policy. The
+// /w/<slug> prefix is ignored, since Workspace strips it only after the requests are limited.
+func (p *rateLimitPolicy) matches(r *http.Request) bool {
+	if p.method != "" && p.method != r.Method {
+		return false
+	}
+
+	if p.segments == nil {
+		return true
+	}
+
+	path := r.URL.Path
+	if _, rest, ok := splitWorkspacePath(path); ok {
+		path = rest
+	}
+
+	segments := strings.Split(path, "/")
+
+	for i, s := range p.segments {
+		switch {
+		case strings.HasPrefix(s, "*"):
+			return true
+		case i >= len(segments):
+			return false
+		case strings.HasPrefix(s, ":"):
+			if segments[i] == "" {
+				return false
+			}
+		case s != segments[i]:
+			return false
+		}
+	}
+
+	return len(segments) == len(p.segments)
+}
+
+// key returns the key of the bucket counting the request, anonymous requests are always
+// counted by IP. It relies on ClientIP and Authenticate running before.
+func (p *rateLimitPolicy) key(r *http.Request) string {
+	if p.byUser {
+		if user := helpers.ContextGetUser(r); !user.IsAnonymous() {
+			return p.name + ":user:" + strconv.FormatInt(user.ID, 10)
+		}
+	}
+
+	return p.ipKey(r)
+}
+
+// ipKey returns the key of the bucket counting the requests of the client IP, which also
+// counts the failed authentications.
+func (p *rateLimitPolicy) ipKey(r *http.Request) string {
+	return p.name + ":ip:" + domain.ClientFromContext(r.Context()).IP
+}
+
+// setRateLimitHeaders sets the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers
+// of the IETF draft, and the Retry-After header if the request is refused.
+func setRateLimitHeaders(w http.ResponseWriter, limit domain.RateLimit, res *domain.RateLimitResult) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", seconds(res.Reset))
+
+	if !res.Allowed {
+		w.Header().Set("Retry-After", seconds(res.RetryAfter))
+	}
+}
+
+// seconds formats d in whole seconds, rounded up so that clients don't come back too early.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/unknowntpo/todos/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitPolicyMatches(t *testing.T) {
+	tests := []struct {
+		route  string
+		method string
+		path   string
+		want   bool
+	}{
+		{"", http.MethodGet, "/v1/tasks", true},
+		{"/v1/tasks", http.MethodPost, "/v1/tasks", true},
+		{"/v1/tasks", http.MethodGet, "/v1/tasks/1", false},
+		{"POST /v1/tokens/authentication", http.MethodPost, "/v1/tokens/authentication", true},
+		{"POST /v1/tokens/authentication", http.MethodGet, "/v1/tokens/authentication", false},
+		{"post /v1/tasks/:id", http.MethodPost, "/v1/tasks/42", true},
+		{"POST /v1/tasks/:id", http.MethodPost, "/v1/tasks/", false},
+		{"GET /swagger/*any", http.MethodGet, "/swagger/index.html", true},
+		{"GET /swagger/*any", http.MethodGet, "/v1/swagger", false},
+		{"/v1/tasks", http.MethodGet, "/w/acme/v1/tasks", true},
+		{"POST /v1/tasks/:id", http.MethodPost, "/w/acme/v1/tasks/42", true},
+		{"/v1/tasks", http.MethodGet, "/w/acme", false},
+	}
+
+	for _, tt := range tests {
+		p, err := newRateLimitPolicy(tt.route, 1, 1, "")
+		if err != nil {
+			t.Fatalf("route %q: %v", tt.route, err)
+		}
+
+		r, err := http.NewRequest(tt.method, tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, tt.want, p.matches(r), "%s %s against %q", tt.method, tt.path, tt.route)
+	}
+}
+
+func TestValidateRateLimits(t *testing.T) {
+	valid := config.Limiter{Rps: 2, Burst: 4}
+	assert.NoError(t, ValidateRateLimits(&valid))
+
+	for _, cfg := range []config.Limiter{
+		{Rps: 0, Burst: 4},
+		{Rps: 2, Burst: 4, By: "session"},
+		{Rps: 2, Burst: 4, Policies: []config.RateLimitPolicy{{Route: "v1/tasks", Rps: 1, Burst: 1}}},
+		{Rps: 2, Burst: 4, Policies: []config.RateLimitPolicy{{Route: "POST /v1/tasks extra", Rps: 1, Burst: 1}}},
+		{Rps: 2, Burst: 4, Policies: []config.RateLimitPolicy{{Route: "/v1/tasks", Rps: 1, Burst: 0}}},
+	} {
+		assert.Error(t, ValidateRateLimits(&cfg), "%+v", cfg)
+	}
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/unknowntpo/todos/internal/domain"
+)
+
+// The cookies of the session mode. The authentication and refresh tokens are kept in HttpOnly
+// cookies, so that they can't be stolen by injected scripts. Cookies are sent along with the
+// requests other sites make as well, so the state-changing requests authenticated by them have
+// to echo the CSRF token, which other sites can't read, in the CSRF header.
+const (
+	SessionCookie = "session"
+	RefreshCookie = "refresh_token"
+	CSRFCookie    = "csrf_token"
+	CSRFHeader    = "X-CSRF-Token"
+
+	// refreshCookiePath restricts the refresh token to the endpoint exchanging it.
+	refreshCookiePath = "/v1/tokens/refresh"
+)
+
+// SessionEnabled reports whether clients can ask to be authenticated by cookies.
+func (mid *Middleware) SessionEnabled() bool {
+	return mid.config.Session.Enabled
+}
+
+// SetSessionCookies sets the cookies carrying the authentication and refresh tokens, along with
+// a new CSRF token the client has to echo in the X-CSRF-Token header. The CSRF token is returned
+// as well, because the pages of trusted origins on other hosts can't read the cookie.
+func (mid *Middleware) SetSessionCookies(w http.ResponseWriter, access, refresh *domain.Token) (csrfToken string, err error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	csrfToken = base64.RawURLEncoding.EncodeToString(random)
+
+	cfg := mid.config.Session
+	sameSite := sameSiteMode(cfg.SameSite)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    access.Plaintext,
+		Path:     "/",
+		Expires:  access.Expiry,
+		Secure:   cfg.Secure,
+		HttpOnly: true,
+		SameSite: sameSite,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     RefreshCookie,
+		Value:    refresh.Plaintext,
+		Path:     refreshCookiePath,
+		Expires:  refresh.Expiry,
+		Secure:   cfg.Secure,
+		HttpOnly: true,
+		SameSite: sameSite,
+	})
+
+	// The CSRF cookie lives as long as the refresh token, so that the refresh request can
+	// carry it after the session cookie has expired.
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookie,
+		Value:    csrfToken,
+		Path:     "/",
+		Expires:  refresh.Expiry,
+		Secure:   cfg.Secure,
+		SameSite: sameSite,
+	})
+
+	return csrfToken, nil
+}
+
+// ClearSessionCookies tells the client to drop the cookies of the session mode.
+func ClearSessionCookies(w http.ResponseWriter) {
+	for _, c := range []struct{ name, path string }{
+		{SessionCookie, "/"},
+		{RefreshCookie, refreshCookiePath},
+		{CSRFCookie, "/"},
+	} {
+		http.SetCookie(w, &http.Cookie{Name: c.name, Path: c.path, MaxAge: -1})
+	}
+}
+
+// ValidCSRF reports whether the request carries the CSRF cookie and echoes it in the X-CSRF-Token
+// header. Other sites can make the browser send the cookie, but they can't learn the token to set
+// the header.
+func ValidCSRF(r *http.Request) bool {
+	cookie, err := r.Cookie(CSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.Header.Get(CSRFHeader))) == 1
+}
+
+// isSafeMethod reports whether the method doesn't change state, such requests don't need
+// CSRF protection.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func sameSiteMode(s string) http.SameSite {
+	switch strings.ToLower(s) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/tracing"
+
+	"github.com/felixge/httpsnoop"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Trace records the server span of the request, which continues the trace of the traceparent
+// header sent by the client, if any. The span is named by the method and the route pattern, e.g.
+// "GET /v1/tasks/:id", and the responses with status 5xx mark it as failed. It relies on ClientIP
+// running before.
+func (mid *Middleware) Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, info := withRequestInfo(r)
+
+		// The method is read before, the request may be changed by the handlers. The span is
+		// named by the methods the API uses, like the metric labels.
+		method := r.Method
+		name := metricsMethod(method)
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("client.address", domain.ClientFromContext(ctx).IP),
+		))
+		defer span.End()
+
+		m := httpsnoop.CaptureMetrics(next, w, r.WithContext(ctx))
+
+		// Requests refused before they're routed have no route.
+		if info.route != "" {
+			span.SetName(name + " " + info.route)
+			span.SetAttributes(attribute.String("http.route", info.route))
+		}
+
+		span.SetAttributes(attribute.Int("http.response.status_code", m.Code))
+		if m.Code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(m.Code))
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/reactor"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// stateCookie binds the login to the browser which started it, so that nobody can make
+// the user sign in with an authorization code of theirs.
+const stateCookie = "oidc_state"
+
+type oidcAPI struct {
+	ou domain.OIDCUsecase
+	rc *reactor.Reactor
+}
+
+type OIDCAuthenticationResponse struct {
+	Token        *domain.Token `json:"token"`
+	RefreshToken *domain.Token `json:"refresh_token"`
+}
+
+type OIDCMFAChallengeResponse struct {
+	MFAToken *domain.Token `json:"mfa_token"`
+}
+
+func NewOIDCAPI(router *httprouter.Router, ou domain.OIDCUsecase, rc *reactor.Reactor) {
+	api := &oidcAPI{ou: ou, rc: rc}
+	router.HandlerFunc(http.MethodGet, "/v1/auth/oidc/:provider/start", api.StartLogin)
+	router.HandlerFunc(http.MethodGet, "/v1/auth/oidc/:provider/callback", api.FinishLogin)
+}
+
+// StartLogin redirects the user to the identity provider.
+// @Summary Sign in with an external identity provider.
+// @Description: Redirects to the identity provider, which redirects back to the callback.
+// @Param provider path string true "Name of the identity provider"
+// @Success 302
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/auth/oidc/{provider}/start [get]
+func (o *oidcAPI) StartLogin(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "oidcAPI.StartLogin"
+
+	provider := httprouter.ParamsFromContext(r.Context()).ByName("provider")
+
+	ctx := r.Context()
+	authURL, state, err := o.ou.StartLogin(ctx, provider)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			o.rc.NotFoundResponse(w, r)
+		default:
+			o.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     "/v1/auth/oidc/" + provider,
+		MaxAge:   int(domain.OIDCLoginStateTTL.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		// The callback is a top-level navigation from the identity provider.
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// FinishLogin handles the redirect back from the identity provider.
+// @Summary Finish signing in with an external identity provider.
+// @Description: The first sign-in links the identity to the activated user of the same verified email address,
+// @Description: or creates a new user.
+// @Description If the user has enabled two-factor authentication, an MFA token is returned instead,
+// @Description which has to be exchanged with the code at /v1/tokens/mfa within 5 minutes.
+// @Produce  json
+// @Param provider path string true "Name of the identity provider"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} OIDCAuthenticationResponse
+// @Success 202 {object} OIDCMFAChallengeResponse "the user has enabled two-factor authentication"
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse "the user account of the email address hasn't been activated"
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/auth/oidc/{provider}/callback [get]
+func (o *oidcAPI) FinishLogin(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "oidcAPI.FinishLogin"
+
+	provider := httprouter.ParamsFromContext(r.Context()).ByName("provider")
+
+	qs := r.URL.Query()
+	state := o.rc.ReadString(qs, "state", "")
+	code := o.rc.ReadString(qs, "code", "")
+
+	// The state cookie is only good for a single attempt.
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Path:     "/v1/auth/oidc/" + provider,
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		o.rc.InvalidCredentialsResponse(w, r)
+		return
+	}
+
+	// The user may have declined to sign in at the provider, in which case it redirects
+	// back with an error instead of a code.
+	if code == "" {
+		o.rc.InvalidCredentialsResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	token, refreshToken, err := o.ou.FinishLogin(ctx, provider, state, code)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			o.rc.NotFoundResponse(w, r)
+		case errors.KindIs(err, errors.KindInvalidCredentials):
+			o.rc.InvalidCredentialsResponse(w, r)
+		case errors.KindIs(err, errors.KindInactiveAccount):
+			o.rc.InactiveAccountResponse(w, r)
+		default:
+			o.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	// The identity is verified, but the second factor is still required.
+	if token.Scope == domain.ScopeMFAChallenge {
+		err = o.rc.WriteJSON(w, http.StatusAccepted, &OIDCMFAChallengeResponse{MFAToken: token})
+		if err != nil {
+			o.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = o.rc.WriteJSON(w, http.StatusOK, &OIDCAuthenticationResponse{Token: token, RefreshToken: refreshToken})
+	if err != nil {
+		o.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/domain/mocks"
+	"github.com/unknowntpo/todos/internal/logger/zerolog"
+	"github.com/unknowntpo/todos/internal/reactor"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartLogin(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		logBuf := new(bytes.Buffer)
+		rc := reactor.NewReactor(zerolog.New(logBuf))
+
+		oidcUsecase := new(mocks.OIDCUsecase)
+		oidcUsecase.On("StartLogin", mock.Anything, "company").Return("https://idp.example.com/authorize?state=abc", "abc", nil)
+
+		router := httprouter.New()
+		NewOIDCAPI(router, oidcUsecase, rc)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/company/start", nil))
+
+		assert.Equal(t, "", logBuf.String())
+		assert.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, "https://idp.example.com/authorize?state=abc", rr.Header().Get("Location"))
+
+		cookies := rr.Result().Cookies()
+		if assert.Len(t, cookies, 1) {
+			assert.Equal(t, stateCookie, cookies[0].Name)
+			assert.Equal(t, "abc", cookies[0].Value)
+			assert.True(t, cookies[0].HttpOnly)
+		}
+	})
+	t.Run("Unknown provider", func(t *testing.T) {
+		rc := reactor.NewReactor(zerolog.New(new(bytes.Buffer)))
+
+		oidcUsecase := new(mocks.OIDCUsecase)
+		oidcUsecase.On("StartLogin", mock.Anything, "unknown").Return("", "", errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound))
+
+		router := httprouter.New()
+		NewOIDCAPI(router, oidcUsecase, rc)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/unknown/start", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestFinishLogin(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		logBuf := new(bytes.Buffer)
+		rc := reactor.NewReactor(zerolog.New(logBuf))
+
+		access, err := domain.GenerateToken(1, 15*time.Minute, domain.ScopeAuthentication)
+		require.NoError(t, err)
+		refresh, err := domain.GenerateToken(1, 7*24*time.Hour, domain.ScopeRefresh)
+		require.NoError(t, err)
+
+		oidcUsecase := new(mocks.OIDCUsecase)
+		oidcUsecase.On("FinishLogin", mock.Anything, "company", "abc", "code").Return(access, refresh, nil)
+
+		router := httprouter.New()
+		NewOIDCAPI(router, oidcUsecase, rc)
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/company/callback?code=code&state=abc", nil)
+		r.AddCookie(&http.Cookie{Name: stateCookie, Value: "abc"})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, r)
+
+		assert.Equal(t, "", logBuf.String())
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), access.Plaintext)
+		assert.Contains(t, rr.Body.String(), refresh.Plaintext)
+	})
+	t.Run("Fail without state cookie", func(t *testing.T) {
+		rc := reactor.NewReactor(zerolog.New(new(bytes.Buffer)))
+
+		oidcUsecase := new(mocks.OIDCUsecase)
+
+		router := httprouter.New()
+		NewOIDCAPI(router, oidcUsecase, rc)
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/company/callback?code=code&state=abc", nil)
+		r.AddCookie(&http.Cookie{Name: stateCookie, Value: "another login"})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, r)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		oidcUsecase.AssertNotCalled(t, "FinishLogin", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run("Fail on unactivated account", func(t *testing.T) {
+		rc := reactor.NewReactor(zerolog.New(new(bytes.Buffer)))
+
+		oidcUsecase := new(mocks.OIDCUsecase)
+		oidcUsecase.On("FinishLogin", mock.Anything, "company", "abc", "code").
+			Return(nil, nil, errors.E(errors.KindInactiveAccount, domain.ErrInactiveAccount))
+
+		router := httprouter.New()
+		NewOIDCAPI(router, oidcUsecase, rc)
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/company/callback?code=code&state=abc", nil)
+		r.AddCookie(&http.Cookie{Name: stateCookie, Value: "abc"})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, r)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
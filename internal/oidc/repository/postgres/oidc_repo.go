@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+)
+
+type oidcRepo struct {
+	DB *sql.DB
+}
+
+func NewOIDCRepo(DB *sql.DB) domain.OIDCRepository {
+	return &oidcRepo{DB}
+}
+
+// InsertState adds the login state, the expired ones are deleted along the way.
+func (or *oidcRepo) InsertState(ctx context.Context, state *domain.OIDCLoginState) error {
+	const op errors.Op = "oidcRepo.InsertState"
+
+	query := `
+        WITH expired AS (
+            DELETE FROM oidc_login_states WHERE expiry < NOW()
+        )
+        INSERT INTO oidc_login_states (hash, provider, nonce, verifier, expiry)
+        VALUES ($1, $2, $3, $4, $5)`
+
+	args := []interface{}{state.Hash, state.Provider, state.Nonce, state.Verifier, state.Expiry}
+
+	_, err := or.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	return nil
+}
+
+// TakeState deletes and returns the unexpired login state with given plaintext of the provider,
+// so that a state can only be used once. If there's no such state, the error with kind
+// errors.KindRecordNotFound is returned.
+func (or *oidcRepo) TakeState(ctx context.Context, provider, statePlaintext string) (*domain.OIDCLoginState, error) {
+	const op errors.Op = "oidcRepo.TakeState"
+
+	stateHash := sha256.Sum256([]byte(statePlaintext))
+
+	query := `
+        DELETE FROM oidc_login_states
+        WHERE hash = $1 AND provider = $2 AND expiry > NOW()
+        RETURNING hash, provider, nonce, verifier, expiry`
+
+	var state domain.OIDCLoginState
+
+	err := or.DB.QueryRowContext(ctx, query, stateHash[:], provider).Scan(
+		&state.Hash,
+		&state.Provider,
+		&state.Nonce,
+		&state.Verifier,
+		&state.Expiry,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+		default:
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+	}
+
+	return &state, nil
+}
+
+// GetIdentity returns the identity with given subject at the provider. If there's no such
+// identity, the error with kind errors.KindRecordNotFound is returned.
+func (or *oidcRepo) GetIdentity(ctx context.Context, provider, subject string) (*domain.OIDCIdentity, error) {
+	const op errors.Op = "oidcRepo.GetIdentity"
+
+	query := `
+        SELECT provider, subject, user_id
+        FROM oidc_identities
+        WHERE provider = $1 AND subject = $2`
+
+	var identity domain.OIDCIdentity
+
+	err := or.DB.QueryRowContext(ctx, query, provider, subject).Scan(
+		&identity.Provider,
+		&identity.Subject,
+		&identity.UserID,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+		default:
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+	}
+
+	return &identity, nil
+}
+
+// InsertIdentity links the identity to its user.
+func (or *oidcRepo) InsertIdentity(ctx context.Context, identity *domain.OIDCIdentity) error {
+	const op errors.Op = "oidcRepo.InsertIdentity"
+
+	query := `
+        INSERT INTO oidc_identities (provider, subject, user_id)
+        VALUES ($1, $2, $3)`
+
+	_, err := or.DB.ExecContext(ctx, query, identity.Provider, identity.Subject, identity.UserID)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	return nil
+}
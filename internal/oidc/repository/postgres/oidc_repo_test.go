@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/testutil"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/stretchr/testify/suite"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+type OIDCRepoTestSuite struct {
+	suite.Suite
+	container testcontainers.Container
+	db        *sql.DB
+	mig       *migrate.Migrate
+	alice     *domain.User
+}
+
+func (suite *OIDCRepoTestSuite) SetupSuite() {
+	ctx := context.Background()
+
+	container, db, err := testutil.CreatePostgresTestContainer(ctx, "testdb")
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.container = container
+	suite.db = db
+
+	mig, err := testutil.NewPgMigrator(db)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.mig = mig
+}
+
+// TearDownSuite tears down the test suite by closing db connection,
+// terminates container.
+func (suite *OIDCRepoTestSuite) TearDownSuite() {
+	defer suite.db.Close()
+	ctx := context.Background()
+	defer suite.container.Terminate(ctx)
+}
+
+// SetupTest do migration up and creates a fake user for each test.
+func (suite *OIDCRepoTestSuite) SetupTest() {
+	err := suite.mig.Up()
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	user := testutil.NewFakeUser(suite.T(), "Alice Smith", "alice@example.com", "pa55word", true)
+
+	query := `
+	INSERT INTO users (name, email, password_hash, activated)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id`
+
+	err = suite.db.QueryRowContext(context.TODO(), query, user.Name, user.Email, user.Password.Hash, user.Activated).Scan(&user.ID)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.alice = user
+}
+
+// TearDownTest do migration down for each test to ensure the results of
+// this test won't affect to the result of next test.
+func (suite *OIDCRepoTestSuite) TearDownTest() {
+	err := suite.mig.Down()
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.alice = nil
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestOIDCRepoTestSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration tests...")
+	}
+
+	suite.Run(t, new(OIDCRepoTestSuite))
+}
+
+func (suite *OIDCRepoTestSuite) TestStates() {
+	suite.Run("Success", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewOIDCRepo(suite.db)
+
+		state, err := domain.GenerateOIDCLoginState("company")
+		suite.Require().NoError(err)
+		suite.NoError(repo.InsertState(ctx, state))
+
+		// The state is bound to the provider.
+		_, err = repo.TakeState(ctx, "another", state.Plaintext)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		got, err := repo.TakeState(ctx, "company", state.Plaintext)
+		suite.NoError(err)
+		suite.Equal(state.Nonce, got.Nonce)
+		suite.Equal(state.Verifier, got.Verifier)
+
+		// A state can only be used once.
+		_, err = repo.TakeState(ctx, "company", state.Plaintext)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+
+	suite.Run("Expired", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewOIDCRepo(suite.db)
+
+		state, err := domain.GenerateOIDCLoginState("company")
+		suite.Require().NoError(err)
+		state.Expiry = time.Now().Add(-time.Minute)
+		suite.NoError(repo.InsertState(ctx, state))
+
+		_, err = repo.TakeState(ctx, "company", state.Plaintext)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
+
+func (suite *OIDCRepoTestSuite) TestIdentities() {
+	suite.Run("Success", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewOIDCRepo(suite.db)
+
+		_, err := repo.GetIdentity(ctx, "company", "alice-id")
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		identity := &domain.OIDCIdentity{Provider: "company", Subject: "alice-id", UserID: suite.alice.ID}
+		suite.NoError(repo.InsertIdentity(ctx, identity))
+
+		got, err := repo.GetIdentity(ctx, "company", "alice-id")
+		suite.NoError(err)
+		suite.Equal(identity, got)
+
+		// Subjects are only unique within a provider.
+		_, err = repo.GetIdentity(ctx, "another", "alice-id")
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
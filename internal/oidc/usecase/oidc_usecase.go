@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/pkg/oidc"
+)
+
+type oidcUsecase struct {
+	oidcRepo       domain.OIDCRepository
+	userRepo       domain.UserRepository
+	permissionRepo domain.PermissionRepository
+	tokenUsecase   domain.TokenUsecase
+	mfaUsecase     domain.MFAUsecase
+	providers      map[string]*oidc.Provider
+	contextTimeout time.Duration
+}
+
+// NewOIDCUsecase returns the OIDC usecase for the identity providers keyed by their names,
+// which appear in the login URLs.
+func NewOIDCUsecase(
+	or domain.OIDCRepository,
+	ur domain.UserRepository,
+	pr domain.PermissionRepository,
+	tu domain.TokenUsecase,
+	mu domain.MFAUsecase,
+	providers map[string]*oidc.Provider,
+	timeout time.Duration,
+) domain.OIDCUsecase {
+	return &oidcUsecase{
+		oidcRepo:       or,
+		userRepo:       ur,
+		permissionRepo: pr,
+		tokenUsecase:   tu,
+		mfaUsecase:     mu,
+		providers:      providers,
+		contextTimeout: timeout,
+	}
+}
+
+// StartLogin starts the authorization code flow with the provider, it returns the URL to redirect
+// the user to and the state, which the callback has to present. If there's no such provider,
+// the error with kind errors.KindRecordNotFound is returned.
+func (ou *oidcUsecase) StartLogin(ctx context.Context, provider string) (string, string, error) {
+	const op errors.Op = "oidcUsecase.StartLogin"
+
+	ctx, cancel := context.WithTimeout(ctx, ou.contextTimeout)
+	defer cancel()
+
+	p, ok := ou.providers[provider]
+	if !ok {
+		return "", "", errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	state, err := domain.GenerateOIDCLoginState(provider)
+	if err != nil {
+		return "", "", errors.E(op, err)
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state.Plaintext, state.Nonce, state.Verifier)
+	if err != nil {
+		return "", "", errors.E(op, errors.KindInternal, err)
+	}
+
+	err = ou.oidcRepo.InsertState(ctx, state)
+	if err != nil {
+		return "", "", errors.E(op, err)
+	}
+
+	return authURL, state.Plaintext, nil
+}
+
+// FinishLogin exchanges the authorization code the provider redirected back with, and issues
+// a pair of authentication and refresh tokens to the user of the verified identity.
+// The first time an identity signs in, it's linked to the activated user of the same email
+// address, or a new user is created if there's none, both only if the provider has verified
+// the email address. If the user has enabled two-factor authentication, it returns an MFA
+// challenge token and nil refresh token instead, just like userUsecase.Login, so that signing in
+// at the provider doesn't skip the second factor.
+// If there's no such provider, the error with kind errors.KindRecordNotFound is returned, if the
+// state or code is invalid, or the email address isn't verified, the error with kind
+// errors.KindInvalidCredentials is returned, if the user of the identity or email address isn't
+// activated, e.g. has been deactivated, the error with kind errors.KindInactiveAccount is returned.
+func (ou *oidcUsecase) FinishLogin(ctx context.Context, provider, state, code string) (*domain.Token, *domain.Token, error) {
+	const op errors.Op = "oidcUsecase.FinishLogin"
+
+	ctx, cancel := context.WithTimeout(ctx, ou.contextTimeout)
+	defer cancel()
+
+	p, ok := ou.providers[provider]
+	if !ok {
+		return nil, nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	loginState, err := ou.oidcRepo.TakeState(ctx, provider, state)
+	if err != nil {
+		if errors.KindIs(err, errors.KindRecordNotFound) {
+			return nil, nil, errors.E(op, errors.KindInvalidCredentials, err)
+		}
+		return nil, nil, errors.E(op, err)
+	}
+
+	claims, err := p.Exchange(ctx, code, loginState.Verifier, loginState.Nonce)
+	if err != nil {
+		switch {
+		case errors.Is(err, oidc.ErrInvalidGrant), errors.Is(err, oidc.ErrInvalidIDToken):
+			return nil, nil, errors.E(op, errors.KindInvalidCredentials, err)
+		default:
+			return nil, nil, errors.E(op, errors.KindInternal, err)
+		}
+	}
+
+	userID, err := ou.userForIdentity(ctx, provider, claims)
+	if err != nil {
+		return nil, nil, errors.E(op, err)
+	}
+
+	enabled, err := ou.mfaUsecase.Enabled(ctx, userID)
+	if err != nil {
+		return nil, nil, errors.E(op, err)
+	}
+
+	if enabled {
+		challenge, err := domain.GenerateToken(userID, domain.MFAChallengeTTL, domain.ScopeMFAChallenge)
+		if err != nil {
+			return nil, nil, errors.E(op, err)
+		}
+
+		err = ou.tokenUsecase.Insert(ctx, challenge)
+		if err != nil {
+			return nil, nil, errors.E(op, err)
+		}
+
+		return challenge, nil, nil
+	}
+
+	access, refresh, err := ou.tokenUsecase.IssueAuthTokens(ctx, userID)
+	if err != nil {
+		return nil, nil, errors.E(op, err)
+	}
+
+	return access, refresh, nil
+}
+
+// userForIdentity returns the ID of the activated user the identity is linked to, linking it
+// first if needed.
+func (ou *oidcUsecase) userForIdentity(ctx context.Context, provider string, claims *oidc.Claims) (int64, error) {
+	const op errors.Op = "oidcUsecase.userForIdentity"
+
+	identity, err := ou.oidcRepo.GetIdentity(ctx, provider, claims.Subject)
+	switch {
+	case err == nil:
+		user, err := ou.userRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return 0, errors.E(op, err)
+		}
+
+		// The user may have been deactivated since the identity was linked.
+		if !user.Activated {
+			return 0, errors.E(op, errors.KindInactiveAccount, domain.ErrInactiveAccount)
+		}
+
+		return user.ID, nil
+	case errors.KindIs(err, errors.KindRecordNotFound):
+	default:
+		return 0, errors.E(op, err)
+	}
+
+	// Without a verified email address, anyone could claim the account of someone else.
+	if claims.Email == "" || !claims.EmailVerified {
+		return 0, errors.E(op, errors.KindInvalidCredentials, errors.Msg("email address not verified by provider"), domain.ErrInvalidCredentials)
+	}
+
+	user, err := ou.userRepo.GetByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		// Anyone can register an unactivated account with any email address, linking to it
+		// would let them log in to the account with their password once it's activated.
+		if !user.Activated {
+			return 0, errors.E(op, errors.KindInactiveAccount, domain.ErrInactiveAccount)
+		}
+	case errors.KindIs(err, errors.KindRecordNotFound):
+		user, err = ou.createUser(ctx, claims)
+		if err != nil {
+			return 0, errors.E(op, err)
+		}
+	default:
+		return 0, errors.E(op, err)
+	}
+
+	err = ou.oidcRepo.InsertIdentity(ctx, &domain.OIDCIdentity{Provider: provider, Subject: claims.Subject, UserID: user.ID})
+	if err != nil {
+		return 0, errors.E(op, err)
+	}
+
+	return user.ID, nil
+}
+
+// createUser creates the activated user of the identity with the default permissions. The user
+// gets a random password nobody knows, which can be replaced by resetting the password.
+func (ou *oidcUsecase) createUser(ctx context.Context, claims *oidc.Claims) (*domain.User, error) {
+	const op errors.Op = "oidcUsecase.createUser"
+
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+	if len(name) > 500 {
+		name = name[:500]
+	}
+
+	user := &domain.User{
+		Name:      name,
+		Email:     claims.Email,
+		Activated: true,
+	}
+
+	password, err := oidc.GenerateRandom()
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	err = user.Password.Set(password)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	err = ou.userRepo.Insert(ctx, user)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	err = ou.permissionRepo.AddForUser(ctx, user.ID, domain.DefaultPermissions)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return user, nil
+}
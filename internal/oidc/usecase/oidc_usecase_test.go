@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	_repoMock "github.com/unknowntpo/todos/internal/domain/mocks"
+	"github.com/unknowntpo/todos/internal/testutil"
+	"github.com/unknowntpo/todos/pkg/oidc"
+	"github.com/unknowntpo/todos/pkg/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var alice = oidctest.User{Subject: "alice-id", Email: "alice@example.com", EmailVerified: true, Name: "Alice Smith"}
+
+type fixture struct {
+	idp            *oidctest.Server
+	oidcRepo       *_repoMock.OIDCRepository
+	userRepo       *_repoMock.UserRepository
+	permissionRepo *_repoMock.PermissionRepository
+	tokenUsecase   *_repoMock.TokenUsecase
+	mfaUsecase     *_repoMock.MFAUsecase
+	usecase        domain.OIDCUsecase
+}
+
+func newFixture(t *testing.T, user oidctest.User) *fixture {
+	idp, err := oidctest.NewServer("todos", "s3cret", user)
+	require.NoError(t, err)
+	t.Cleanup(idp.Close)
+
+	f := &fixture{
+		idp:            idp,
+		oidcRepo:       new(_repoMock.OIDCRepository),
+		userRepo:       new(_repoMock.UserRepository),
+		permissionRepo: new(_repoMock.PermissionRepository),
+		tokenUsecase:   new(_repoMock.TokenUsecase),
+		mfaUsecase:     new(_repoMock.MFAUsecase),
+	}
+
+	providers := map[string]*oidc.Provider{
+		"company": oidc.NewProvider(oidc.Config{
+			Issuer:       idp.Issuer(),
+			ClientID:     "todos",
+			ClientSecret: "s3cret",
+			RedirectURL:  "http://localhost:4000/v1/auth/oidc/company/callback",
+		}, nil),
+	}
+
+	f.usecase = NewOIDCUsecase(f.oidcRepo, f.userRepo, f.permissionRepo, f.tokenUsecase, f.mfaUsecase, providers, 3*time.Second)
+
+	return f
+}
+
+// signIn starts the login, signs in at the mock provider and returns the state and code
+// it redirects back with. The login state is expected to be taken once.
+func (f *fixture) signIn(t *testing.T) (state, code string) {
+	var loginState *domain.OIDCLoginState
+	f.oidcRepo.On("InsertState", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		loginState = args.Get(1).(*domain.OIDCLoginState)
+	}).Return(nil).Once()
+
+	authURL, state, err := f.usecase.StartLogin(context.TODO(), "company")
+	require.NoError(t, err)
+	require.Equal(t, loginState.Plaintext, state)
+
+	code, gotState, err := f.idp.Authorize(authURL)
+	require.NoError(t, err)
+	require.Equal(t, state, gotState)
+
+	f.oidcRepo.On("TakeState", mock.Anything, "company", state).Return(loginState, nil).Once()
+
+	return state, code
+}
+
+func (f *fixture) expectTokens(t *testing.T, userID int64) {
+	access, err := domain.GenerateToken(userID, 15*time.Minute, domain.ScopeAuthentication)
+	require.NoError(t, err)
+	refresh, err := domain.GenerateToken(userID, 7*24*time.Hour, domain.ScopeRefresh)
+	require.NoError(t, err)
+
+	f.mfaUsecase.On("Enabled", mock.Anything, userID).Return(false, nil)
+	f.tokenUsecase.On("IssueAuthTokens", mock.Anything, userID).Return(access, refresh, nil)
+}
+
+func notFound() error {
+	return errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound)
+}
+
+func TestStartLogin(t *testing.T) {
+	t.Run("Unknown provider", func(t *testing.T) {
+		f := newFixture(t, alice)
+
+		_, _, err := f.usecase.StartLogin(context.TODO(), "unknown")
+		assert.True(t, errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
+
+func TestFinishLogin(t *testing.T) {
+	t.Run("Linked identity", func(t *testing.T) {
+		f := newFixture(t, alice)
+		state, code := f.signIn(t)
+
+		user := testutil.NewFakeUser(t, "Alice Smith", "alice@example.com", "pa55word", true)
+		user.ID = 1
+
+		f.oidcRepo.On("GetIdentity", mock.Anything, "company", "alice-id").Return(&domain.OIDCIdentity{Provider: "company", Subject: "alice-id", UserID: 1}, nil)
+		f.userRepo.On("GetByID", mock.Anything, int64(1)).Return(user, nil)
+		f.expectTokens(t, 1)
+
+		access, refresh, err := f.usecase.FinishLogin(context.TODO(), "company", state, code)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ScopeAuthentication, access.Scope)
+		assert.Equal(t, domain.ScopeRefresh, refresh.Scope)
+
+		f.oidcRepo.AssertNotCalled(t, "InsertIdentity", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Linked identity of user with two-factor authentication", func(t *testing.T) {
+		f := newFixture(t, alice)
+		state, code := f.signIn(t)
+
+		user := testutil.NewFakeUser(t, "Alice Smith", "alice@example.com", "pa55word", true)
+		user.ID = 1
+
+		f.oidcRepo.On("GetIdentity", mock.Anything, "company", "alice-id").Return(&domain.OIDCIdentity{Provider: "company", Subject: "alice-id", UserID: 1}, nil)
+		f.userRepo.On("GetByID", mock.Anything, int64(1)).Return(user, nil)
+		f.mfaUsecase.On("Enabled", mock.Anything, int64(1)).Return(true, nil)
+		f.tokenUsecase.On("Insert", mock.Anything, mock.MatchedBy(func(token *domain.Token) bool {
+			return token.UserID == 1 && token.Scope == domain.ScopeMFAChallenge
+		})).Return(nil)
+
+		challenge, refresh, err := f.usecase.FinishLogin(context.TODO(), "company", state, code)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ScopeMFAChallenge, challenge.Scope)
+		assert.Nil(t, refresh)
+
+		f.tokenUsecase.AssertExpectations(t)
+		f.tokenUsecase.AssertNotCalled(t, "IssueAuthTokens", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Linked identity of deactivated user", func(t *testing.T) {
+		f := newFixture(t, alice)
+		state, code := f.signIn(t)
+
+		user := testutil.NewFakeUser(t, "Alice Smith", "alice@example.com", "pa55word", false)
+		user.ID = 1
+
+		f.oidcRepo.On("GetIdentity", mock.Anything, "company", "alice-id").Return(&domain.OIDCIdentity{Provider: "company", Subject: "alice-id", UserID: 1}, nil)
+		f.userRepo.On("GetByID", mock.Anything, int64(1)).Return(user, nil)
+
+		_, _, err := f.usecase.FinishLogin(context.TODO(), "company", state, code)
+		assert.True(t, errors.KindIs(err, errors.KindInactiveAccount))
+
+		f.tokenUsecase.AssertNotCalled(t, "IssueAuthTokens", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Link to existing user by verified email", func(t *testing.T) {
+		f := newFixture(t, alice)
+		state, code := f.signIn(t)
+
+		user := testutil.NewFakeUser(t, "Alice Smith", "alice@example.com", "pa55word", true)
+		user.ID = 1
+
+		f.oidcRepo.On("GetIdentity", mock.Anything, "company", "alice-id").Return(nil, notFound())
+		f.userRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(user, nil)
+		f.oidcRepo.On("InsertIdentity", mock.Anything, &domain.OIDCIdentity{Provider: "company", Subject: "alice-id", UserID: 1}).Return(nil)
+		f.expectTokens(t, 1)
+
+		_, _, err := f.usecase.FinishLogin(context.TODO(), "company", state, code)
+		assert.NoError(t, err)
+
+		f.oidcRepo.AssertExpectations(t)
+		f.userRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Refuse to link to unactivated user", func(t *testing.T) {
+		f := newFixture(t, alice)
+		state, code := f.signIn(t)
+
+		f.oidcRepo.On("GetIdentity", mock.Anything, "company", "alice-id").Return(nil, notFound())
+		f.userRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(testutil.NewFakeUser(t, "Alice Smith", "alice@example.com", "pa55word", false), nil)
+
+		_, _, err := f.usecase.FinishLogin(context.TODO(), "company", state, code)
+		assert.True(t, errors.KindIs(err, errors.KindInactiveAccount))
+
+		f.oidcRepo.AssertNotCalled(t, "InsertIdentity", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Create user", func(t *testing.T) {
+		f := newFixture(t, alice)
+		state, code := f.signIn(t)
+
+		f.oidcRepo.On("GetIdentity", mock.Anything, "company", "alice-id").Return(nil, notFound())
+		f.userRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(nil, notFound())
+		f.userRepo.On("Insert", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+			return user.Name == "Alice Smith" && user.Email == "alice@example.com" && user.Activated
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.User).ID = 2
+		}).Return(nil)
+		f.permissionRepo.On("AddForUser", mock.Anything, int64(2), domain.DefaultPermissions).Return(nil)
+		f.oidcRepo.On("InsertIdentity", mock.Anything, &domain.OIDCIdentity{Provider: "company", Subject: "alice-id", UserID: 2}).Return(nil)
+		f.expectTokens(t, 2)
+
+		_, _, err := f.usecase.FinishLogin(context.TODO(), "company", state, code)
+		assert.NoError(t, err)
+
+		f.userRepo.AssertExpectations(t)
+		f.permissionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unverified email", func(t *testing.T) {
+		unverified := alice
+		unverified.EmailVerified = false
+
+		f := newFixture(t, unverified)
+		state, code := f.signIn(t)
+
+		f.oidcRepo.On("GetIdentity", mock.Anything, "company", "alice-id").Return(nil, notFound())
+
+		_, _, err := f.usecase.FinishLogin(context.TODO(), "company", state, code)
+		assert.True(t, errors.KindIs(err, errors.KindInvalidCredentials))
+
+		f.userRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown state", func(t *testing.T) {
+		f := newFixture(t, alice)
+
+		f.oidcRepo.On("TakeState", mock.Anything, "company", "state").Return(nil, notFound())
+
+		_, _, err := f.usecase.FinishLogin(context.TODO(), "company", "state", "code")
+		assert.True(t, errors.KindIs(err, errors.KindInvalidCredentials))
+	})
+
+	t.Run("Code of another login", func(t *testing.T) {
+		f := newFixture(t, alice)
+		state, _ := f.signIn(t)
+		_, code := f.signIn(t)
+
+		// The code was issued for the PKCE challenge of the other login.
+		_, _, err := f.usecase.FinishLogin(context.TODO(), "company", state, code)
+		assert.True(t, errors.KindIs(err, errors.KindInvalidCredentials))
+
+		f.tokenUsecase.AssertNotCalled(t, "IssueAuthTokens", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/middleware"
+	"github.com/unknowntpo/todos/internal/reactor"
+
+	"github.com/unknowntpo/todos/pkg/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type GrantPermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+type UserPermissionsResponse struct {
+	Permissions domain.Permissions `json:"permissions"`
+}
+
+type permissionAPI struct {
+	pu  domain.PermissionUsecase
+	mid *middleware.Middleware
+	rc  *reactor.Reactor
+}
+
+func NewPermissionAPI(router *httprouter.Router, pu domain.PermissionUsecase, mid *middleware.Middleware, rc *reactor.Reactor) {
+	api := &permissionAPI{pu: pu, mid: mid, rc: rc}
+	router.Handler(http.MethodGet, "/v1/admin/users/:id/permissions", mid.RequirePermission(domain.PermissionAdmin, http.HandlerFunc(api.GetAllForUser)))
+	router.Handler(http.MethodPost, "/v1/admin/users/:id/permissions", mid.RequirePermission(domain.PermissionAdmin, http.HandlerFunc(api.Grant)))
+	router.Handler(http.MethodDelete, "/v1/admin/users/:id/permissions/:code", mid.RequirePermission(domain.PermissionAdmin, http.HandlerFunc(api.Revoke)))
+}
+
+// GetAllForUser lists the permissions of a user.
+// @Summary List the permissions of a user.
+// @Description: Requires the admin permission.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param userID path int true "User ID"
+// @Success 200 {object} UserPermissionsResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/admin/users/{userID}/permissions [get]
+func (p *permissionAPI) GetAllForUser(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("permissionAPI.GetAllForUser")
+
+	userID, err := p.rc.ReadIDParam(r)
+	if err != nil {
+		p.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	permissions, err := p.pu.GetAllForUser(ctx, userID)
+	if err != nil {
+		p.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		return
+	}
+
+	err = p.rc.WriteJSON(w, http.StatusOK, &UserPermissionsResponse{permissions})
+	if err != nil {
+		p.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// Grant grants permissions to a user.
+// @Summary Grant permissions to a user.
+// @Description: Requires the admin permission. Known permissions are tasks:read, tasks:write and admin.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param userID path int true "User ID"
+// @Param reqBody body GrantPermissionsRequest true "request body"
+// @Success 200 {object} UserPermissionsResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/admin/users/{userID}/permissions [post]
+func (p *permissionAPI) Grant(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("permissionAPI.Grant")
+
+	userID, err := p.rc.ReadIDParam(r)
+	if err != nil {
+		p.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	var input GrantPermissionsRequest
+
+	err = p.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		p.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if domain.ValidatePermissions(v, input.Permissions); !v.Valid() {
+		p.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+	permissions, err := p.pu.Grant(ctx, userID, input.Permissions)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			p.rc.NotFoundResponse(w, r)
+		default:
+			p.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = p.rc.WriteJSON(w, http.StatusOK, &UserPermissionsResponse{permissions})
+	if err != nil {
+		p.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// Revoke revokes a permission from a user.
+// @Summary Revoke a permission from a user.
+// @Description: Requires the admin permission.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param userID path int true "User ID"
+// @Param code path string true "Permission code"
+// @Success 200 {object} UserPermissionsResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/admin/users/{userID}/permissions/{code} [delete]
+func (p *permissionAPI) Revoke(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("permissionAPI.Revoke")
+
+	userID, err := p.rc.ReadIDParam(r)
+	if err != nil {
+		p.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	code := httprouter.ParamsFromContext(r.Context()).ByName("code")
+
+	ctx := r.Context()
+	permissions, err := p.pu.Revoke(ctx, userID, code)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			p.rc.NotFoundResponse(w, r)
+		default:
+			p.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = p.rc.WriteJSON(w, http.StatusOK, &UserPermissionsResponse{permissions})
+	if err != nil {
+		p.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+
+	"github.com/lib/pq"
+)
+
+type permissionRepo struct {
+	DB *sql.DB
+}
+
+func NewPermissionRepo(DB *sql.DB) domain.PermissionRepository {
+	return &permissionRepo{DB}
+}
+
+// GetAllForUser returns all permission codes for the user with given userID.
+func (pr *permissionRepo) GetAllForUser(ctx context.Context, userID int64) (domain.Permissions, error) {
+	const op errors.Op = "permissionRepo.GetAllForUser"
+
+	query := `
+        SELECT permissions.code
+        FROM permissions
+        INNER JOIN users_permissions ON users_permissions.permission_id = permissions.id
+        WHERE users_permissions.user_id = $1
+        ORDER BY permissions.code ASC`
+
+	rows, err := pr.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+	defer rows.Close()
+
+	permissions := domain.Permissions{}
+
+	for rows.Next() {
+		var permission string
+
+		err := rows.Scan(&permission)
+		if err != nil {
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+
+	return permissions, nil
+}
+
+// AddForUser grants the permissions with given codes to the user with given userID,
+// permissions the user already has are left untouched.
+func (pr *permissionRepo) AddForUser(ctx context.Context, userID int64, codes []string) error {
+	const op errors.Op = "permissionRepo.AddForUser"
+
+	query := `
+        INSERT INTO users_permissions
+        SELECT $1, permissions.id FROM permissions WHERE permissions.code = ANY($2)
+        ON CONFLICT DO NOTHING`
+
+	_, err := pr.DB.ExecContext(ctx, query, userID, pq.Array(codes))
+	if err != nil {
+		switch {
+		case err.Error() == `pq: insert or update on table "users_permissions" violates foreign key constraint "users_permissions_user_id_fkey"`:
+			return errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+		default:
+			return errors.E(op, errors.KindDatabase, err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteForUser revokes the permission with given code from the user with given userID.
+// If the user doesn't have the permission, domain.ErrRecordNotFound is returned.
+func (pr *permissionRepo) DeleteForUser(ctx context.Context, userID int64, code string) error {
+	const op errors.Op = "permissionRepo.DeleteForUser"
+
+	query := `
+        DELETE FROM users_permissions
+        USING permissions
+        WHERE users_permissions.permission_id = permissions.id
+        AND users_permissions.user_id = $1 AND permissions.code = $2`
+
+	result, err := pr.DB.ExecContext(ctx, query, userID, code)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/testutil"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/stretchr/testify/suite"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+type PermissionRepoTestSuite struct {
+	suite.Suite
+	container testcontainers.Container
+	db        *sql.DB
+	mig       *migrate.Migrate
+	alice     *domain.User
+	bob       *domain.User
+}
+
+func (suite *PermissionRepoTestSuite) SetupSuite() {
+	ctx := context.Background()
+
+	container, db, err := testutil.CreatePostgresTestContainer(ctx, "testdb")
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.container = container
+	suite.db = db
+
+	mig, err := testutil.NewPgMigrator(db)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.mig = mig
+}
+
+// TearDownSuite tears down the test suite by closing db connection,
+// terminates container.
+func (suite *PermissionRepoTestSuite) TearDownSuite() {
+	defer suite.db.Close()
+	ctx := context.Background()
+	defer suite.container.Terminate(ctx)
+}
+
+// SetupTest do migration up and creates fake users for each test.
+func (suite *PermissionRepoTestSuite) SetupTest() {
+	err := suite.mig.Up()
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.alice = suite.insertUser("Alice Smith", "alice@example.com")
+	suite.bob = suite.insertUser("Bob Ross", "bob.ross@example.com")
+}
+
+// TearDownTest do migration down for each test to ensure the results of
+// this test won't affect to the result of next test.
+func (suite *PermissionRepoTestSuite) TearDownTest() {
+	err := suite.mig.Down()
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.alice = nil
+	suite.bob = nil
+}
+
+func (suite *PermissionRepoTestSuite) insertUser(name, email string) *domain.User {
+	user := testutil.NewFakeUser(suite.T(), name, email, "pa55word", true)
+
+	query := `
+	INSERT INTO users (name, email, password_hash, activated)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id`
+
+	err := suite.db.QueryRowContext(context.TODO(), query, user.Name, user.Email, user.Password.Hash, user.Activated).Scan(&user.ID)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	return user
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestPermissionRepoTestSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration tests...")
+	}
+
+	suite.Run(t, new(PermissionRepoTestSuite))
+}
+
+func (suite *PermissionRepoTestSuite) TestPermissions() {
+	suite.Run("Success", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewPermissionRepo(suite.db)
+
+		suite.NoError(repo.AddForUser(ctx, suite.alice.ID, domain.DefaultPermissions))
+		// Granting a permission twice is a no-op.
+		suite.NoError(repo.AddForUser(ctx, suite.alice.ID, []string{domain.PermissionTasksRead, domain.PermissionAdmin}))
+
+		permissions, err := repo.GetAllForUser(ctx, suite.alice.ID)
+		suite.NoError(err)
+		suite.Equal(domain.Permissions{domain.PermissionAdmin, domain.PermissionTasksRead, domain.PermissionTasksWrite}, permissions)
+
+		// Bob has no permissions.
+		permissions, err = repo.GetAllForUser(ctx, suite.bob.ID)
+		suite.NoError(err)
+		suite.Empty(permissions)
+
+		suite.NoError(repo.DeleteForUser(ctx, suite.alice.ID, domain.PermissionAdmin))
+
+		err = repo.DeleteForUser(ctx, suite.alice.ID, domain.PermissionAdmin)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+
+	suite.Run("Fail when user doesn't exist", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewPermissionRepo(suite.db)
+
+		err := repo.AddForUser(ctx, suite.bob.ID+1, domain.DefaultPermissions)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+)
+
+type permissionUsecase struct {
+	permissionRepo domain.PermissionRepository
+	contextTimeout time.Duration
+}
+
+func NewPermissionUsecase(pr domain.PermissionRepository, timeout time.Duration) domain.PermissionUsecase {
+	return &permissionUsecase{
+		permissionRepo: pr,
+		contextTimeout: timeout,
+	}
+}
+
+// GetAllForUser returns all permission codes for the user with given userID.
+func (pu *permissionUsecase) GetAllForUser(ctx context.Context, userID int64) (domain.Permissions, error) {
+	const op errors.Op = "permissionUsecase.GetAllForUser"
+
+	ctx, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+
+	permissions, err := pu.permissionRepo.GetAllForUser(ctx, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return permissions, nil
+}
+
+// Grant grants the permissions with given codes to the user with given userID and
+// returns all permissions of the user. If there's no such user, the error with kind
+// errors.KindRecordNotFound is returned.
+func (pu *permissionUsecase) Grant(ctx context.Context, userID int64, codes []string) (domain.Permissions, error) {
+	const op errors.Op = "permissionUsecase.Grant"
+
+	ctx, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+
+	err := pu.permissionRepo.AddForUser(ctx, userID, codes)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	permissions, err := pu.permissionRepo.GetAllForUser(ctx, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return permissions, nil
+}
+
+// Revoke revokes the permission with given code from the user with given userID and
+// returns the remaining permissions of the user. If the user doesn't have the permission,
+// the error with kind errors.KindRecordNotFound is returned.
+func (pu *permissionUsecase) Revoke(ctx context.Context, userID int64, code string) (domain.Permissions, error) {
+	const op errors.Op = "permissionUsecase.Revoke"
+
+	ctx, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+
+	err := pu.permissionRepo.DeleteForUser(ctx, userID, code)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	permissions, err := pu.permissionRepo.GetAllForUser(ctx, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	_repoMock "github.com/unknowntpo/todos/internal/domain/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGrant(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		repo := new(_repoMock.PermissionRepository)
+
+		codes := []string{domain.PermissionAdmin}
+		want := domain.Permissions{domain.PermissionAdmin, domain.PermissionTasksRead}
+
+		repo.On("AddForUser", mock.Anything, int64(1), codes).Return(nil)
+		repo.On("GetAllForUser", mock.Anything, int64(1)).Return(want, nil)
+
+		permissionUsecase := NewPermissionUsecase(repo, 3*time.Second)
+
+		got, err := permissionUsecase.Grant(context.TODO(), 1, codes)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Fail when user doesn't exist", func(t *testing.T) {
+		repo := new(_repoMock.PermissionRepository)
+
+		codes := []string{domain.PermissionAdmin}
+
+		repo.On("AddForUser", mock.Anything, int64(1), codes).Return(errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound))
+
+		permissionUsecase := NewPermissionUsecase(repo, 3*time.Second)
+
+		_, err := permissionUsecase.Grant(context.TODO(), 1, codes)
+		assert.True(t, errors.KindIs(err, errors.KindRecordNotFound), "kind of error should be KindRecordNotFound")
+
+		repo.AssertNotCalled(t, "GetAllForUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		repo := new(_repoMock.PermissionRepository)
+
+		want := domain.Permissions{domain.PermissionTasksRead}
+
+		repo.On("DeleteForUser", mock.Anything, int64(1), domain.PermissionTasksWrite).Return(nil)
+		repo.On("GetAllForUser", mock.Anything, int64(1)).Return(want, nil)
+
+		permissionUsecase := NewPermissionUsecase(repo, 3*time.Second)
+
+		got, err := permissionUsecase.Revoke(context.TODO(), 1, domain.PermissionTasksWrite)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+
+		repo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,91 @@
+// Package memory implements the rate limiter keeping the token buckets in process memory,
+// so the limits apply to each instance of the server separately.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+)
+
+// sweepInterval is how often the buckets which have been refilled are forgotten.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket is full again if no token is taken.
+	full time.Time
+}
+
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewRateLimiter() domain.RateLimiter {
+	return newRateLimiter(time.Now)
+}
+
+func newRateLimiter(now func() time.Time) *rateLimiter {
+	return &rateLimiter{
+		buckets:   make(map[string]*bucket),
+		lastSweep: now(),
+		now:       now,
+	}
+}
+
+// Allow takes a token from the bucket with the key.
+func (rl *rateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (*domain.RateLimitResult, error) {
+	now := rl.now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if now.Sub(rl.lastSweep) >= sweepInterval {
+		rl.sweep(now)
+	}
+
+	b, found := rl.buckets[key]
+	if !found {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		rl.buckets[key] = b
+	}
+
+	tokens, allowed := limit.Take(b.tokens, now.Sub(b.updated))
+
+	b.tokens = tokens
+	b.updated = now
+	b.full = now.Add(limit.Refill(tokens))
+
+	return limit.Result(tokens, allowed), nil
+}
+
+// Peek reports whether a token could be taken from the bucket with the key.
+func (rl *rateLimiter) Peek(ctx context.Context, key string, limit domain.RateLimit) (*domain.RateLimitResult, error) {
+	now := rl.now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	tokens := float64(limit.Burst)
+	if b, found := rl.buckets[key]; found {
+		tokens = limit.Fill(b.tokens, now.Sub(b.updated))
+	}
+
+	return limit.Result(tokens, tokens >= 1), nil
+}
+
+// sweep forgets the buckets which are full, they're no different from new ones.
+func (rl *rateLimiter) sweep(now time.Time) {
+	for key, b := range rl.buckets {
+		if !now.Before(b.full) {
+			delete(rl.buckets, key)
+		}
+	}
+	rl.lastSweep = now
+}
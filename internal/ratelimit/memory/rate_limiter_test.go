@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	rl := newRateLimiter(func() time.Time { return now })
+	limit := domain.RateLimit{Rate: 0.5, Burst: 2}
+	ctx := context.TODO()
+
+	res, err := rl.Allow(ctx, "ip:203.0.113.7", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.RateLimitResult{Allowed: true, Remaining: 1, Reset: 2 * time.Second}, res)
+
+	res, _ = rl.Allow(ctx, "ip:203.0.113.7", limit)
+	assert.Equal(t, &domain.RateLimitResult{Allowed: true, Remaining: 0, Reset: 4 * time.Second}, res)
+
+	res, _ = rl.Allow(ctx, "ip:203.0.113.7", limit)
+	assert.Equal(t, &domain.RateLimitResult{Allowed: false, Remaining: 0, Reset: 4 * time.Second, RetryAfter: 2 * time.Second}, res)
+
+	// Other keys have buckets of their own.
+	res, _ = rl.Allow(ctx, "ip:198.51.100.1", limit)
+	assert.True(t, res.Allowed)
+
+	// A token is refilled every 2 seconds.
+	now = now.Add(3 * time.Second)
+	res, _ = rl.Allow(ctx, "ip:203.0.113.7", limit)
+	assert.Equal(t, &domain.RateLimitResult{Allowed: true, Remaining: 0, Reset: 3 * time.Second}, res)
+}
+
+func TestPeek(t *testing.T) {
+	now := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	rl := newRateLimiter(func() time.Time { return now })
+	limit := domain.RateLimit{Rate: 0.5, Burst: 1}
+	ctx := context.TODO()
+
+	res, err := rl.Peek(ctx, "ip:203.0.113.7", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.RateLimitResult{Allowed: true, Remaining: 1}, res)
+
+	rl.Allow(ctx, "ip:203.0.113.7", limit)
+
+	res, _ = rl.Peek(ctx, "ip:203.0.113.7", limit)
+	assert.Equal(t, &domain.RateLimitResult{Allowed: false, Remaining: 0, Reset: 2 * time.Second, RetryAfter: 2 * time.Second}, res)
+
+	res, _ = rl.Allow(ctx, "ip:203.0.113.7", limit)
+	assert.False(t, res.Allowed, "peeking shouldn't take a token")
+
+	now = now.Add(2 * time.Second)
+	res, _ = rl.Peek(ctx, "ip:203.0.113.7", limit)
+	assert.True(t, res.Allowed)
+}
+
+func TestSweep(t *testing.T) {
+	now := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	rl := newRateLimiter(func() time.Time { return now })
+	ctx := context.TODO()
+
+	rl.Allow(ctx, "slow", domain.RateLimit{Rate: 0.001, Burst: 1})
+	rl.Allow(ctx, "fast", domain.RateLimit{Rate: 10, Burst: 1})
+
+	now = now.Add(sweepInterval)
+	rl.Allow(ctx, "other", domain.RateLimit{Rate: 10, Burst: 1})
+
+	assert.Contains(t, rl.buckets, "slow", "bucket still refilling should be kept")
+	assert.NotContains(t, rl.buckets, "fast", "full bucket should be forgotten")
+}
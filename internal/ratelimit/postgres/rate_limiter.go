@@ -0,0 +1,106 @@
+// Package postgres implements the rate limiter keeping the token buckets in Postgres, so the limits
+// apply to all the instances of the server sharing the database.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+)
+
+// RateLimiter keeps the token buckets in the rate_limits table. The table is unlogged, losing
+// the buckets on a crash only resets the limits.
+type RateLimiter struct {
+	DB *sql.DB
+}
+
+var _ domain.RateLimiter = (*RateLimiter)(nil)
+
+func NewRateLimiter(DB *sql.DB) *RateLimiter {
+	return &RateLimiter{DB}
+}
+
+// Allow takes a token from the bucket with the key in a single statement, which locks the row,
+// so that the concurrent requests of all instances take the tokens one after another. The bucket
+// is refilled with the clock of the database, which is the same for all instances. The allowed
+// column records whether the last request took a token, so that the statement can return it.
+func (rl *RateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (*domain.RateLimitResult, error) {
+	const op errors.Op = "rateLimiter.Allow"
+
+	query := `
+        INSERT INTO rate_limits AS rl (key, tokens, allowed, updated_at, expires_at)
+        VALUES ($1, $2::double precision - 1, TRUE, NOW(), NOW() + make_interval(secs => 1 / $3::double precision))
+        ON CONFLICT (key) DO UPDATE
+        SET (tokens, allowed, updated_at, expires_at) = (
+            SELECT left_tokens, taken, NOW(), NOW() + make_interval(secs => ($2::double precision - left_tokens) / $3::double precision)
+            FROM (
+                SELECT CASE WHEN t >= 1 THEN t - 1 ELSE t END AS left_tokens, t >= 1 AS taken
+                FROM (
+                    SELECT LEAST($2::double precision, rl.tokens + EXTRACT(EPOCH FROM NOW() - rl.updated_at) * $3::double precision) AS t
+                ) AS refilled
+            ) AS taking)
+        RETURNING tokens, allowed`
+
+	var tokens float64
+	var allowed bool
+
+	err := rl.DB.QueryRowContext(ctx, query, key, limit.Burst, limit.Rate).Scan(&tokens, &allowed)
+	if err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+
+	return limit.Result(tokens, allowed), nil
+}
+
+// Peek reports whether a token could be taken from the bucket with the key, which is refilled
+// with the clock of the database as Allow does. A missing bucket is full.
+func (rl *RateLimiter) Peek(ctx context.Context, key string, limit domain.RateLimit) (*domain.RateLimitResult, error) {
+	const op errors.Op = "rateLimiter.Peek"
+
+	query := `
+        SELECT LEAST($2::double precision, tokens + EXTRACT(EPOCH FROM NOW() - updated_at) * $3::double precision)
+        FROM rate_limits
+        WHERE key = $1`
+
+	var tokens float64
+
+	err := rl.DB.QueryRowContext(ctx, query, key, limit.Burst, limit.Rate).Scan(&tokens)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			tokens = float64(limit.Burst)
+		default:
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+	}
+
+	return limit.Result(tokens, tokens >= 1), nil
+}
+
+// DeleteExpired deletes at most limit buckets which are full again, they're no different from
+// new ones. It returns the number of buckets deleted.
+func (rl *RateLimiter) DeleteExpired(ctx context.Context, limit int) (int64, error) {
+	const op errors.Op = "rateLimiter.DeleteExpired"
+
+	query := `
+        DELETE FROM rate_limits
+        WHERE key IN (
+            SELECT key FROM rate_limits
+            WHERE expires_at < NOW()
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED)`
+
+	result, err := rl.DB.ExecContext(ctx, query, limit)
+	if err != nil {
+		return 0, errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.E(op, errors.KindDatabase, err)
+	}
+
+	return rowsAffected, nil
+}
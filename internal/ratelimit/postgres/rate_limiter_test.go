@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/testutil"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/stretchr/testify/suite"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+type RateLimiterTestSuite struct {
+	suite.Suite
+	container testcontainers.Container
+	db        *sql.DB
+	mig       *migrate.Migrate
+}
+
+func (suite *RateLimiterTestSuite) SetupSuite() {
+	ctx := context.Background()
+
+	container, db, err := testutil.CreatePostgresTestContainer(ctx, "testdb")
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.container = container
+	suite.db = db
+
+	mig, err := testutil.NewPgMigrator(db)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.mig = mig
+}
+
+func (suite *RateLimiterTestSuite) TearDownSuite() {
+	defer suite.db.Close()
+	ctx := context.Background()
+	defer suite.container.Terminate(ctx)
+}
+
+func (suite *RateLimiterTestSuite) SetupTest() {
+	if err := suite.mig.Up(); err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *RateLimiterTestSuite) TearDownTest() {
+	if err := suite.mig.Down(); err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func TestRateLimiterTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimiterTestSuite))
+}
+
+func (suite *RateLimiterTestSuite) TestAllow() {
+	rl := NewRateLimiter(suite.db)
+	ctx := context.TODO()
+	// Slow enough not to refill a token during the test.
+	limit := domain.RateLimit{Rate: 0.001, Burst: 2}
+
+	res, err := rl.Allow(ctx, "ip:203.0.113.7", limit)
+	suite.NoError(err)
+	suite.True(res.Allowed)
+	suite.Equal(1, res.Remaining)
+
+	res, err = rl.Allow(ctx, "ip:203.0.113.7", limit)
+	suite.NoError(err)
+	suite.True(res.Allowed)
+	suite.Equal(0, res.Remaining)
+
+	res, err = rl.Allow(ctx, "ip:203.0.113.7", limit)
+	suite.NoError(err)
+	suite.False(res.Allowed)
+	suite.Greater(int64(res.RetryAfter), int64(0))
+
+	res, err = rl.Allow(ctx, "ip:198.51.100.1", limit)
+	suite.NoError(err)
+	suite.True(res.Allowed, "other keys should have buckets of their own")
+}
+
+func (suite *RateLimiterTestSuite) TestPeek() {
+	rl := NewRateLimiter(suite.db)
+	ctx := context.TODO()
+	limit := domain.RateLimit{Rate: 0.001, Burst: 1}
+
+	res, err := rl.Peek(ctx, "ip:203.0.113.7", limit)
+	suite.NoError(err)
+	suite.True(res.Allowed, "missing bucket should be full")
+	suite.Equal(1, res.Remaining)
+
+	_, err = rl.Allow(ctx, "ip:203.0.113.7", limit)
+	suite.NoError(err)
+
+	res, err = rl.Peek(ctx, "ip:203.0.113.7", limit)
+	suite.NoError(err)
+	suite.False(res.Allowed)
+	suite.Greater(int64(res.RetryAfter), int64(0))
+
+	res, err = rl.Allow(ctx, "ip:203.0.113.7", limit)
+	suite.NoError(err)
+	suite.False(res.Allowed, "peeking shouldn't take a token")
+}
+
+func (suite *RateLimiterTestSuite) TestAllowConcurrently() {
+	rl := NewRateLimiter(suite.db)
+	limit := domain.RateLimit{Rate: 0.001, Burst: 5}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	allowed := 0
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := rl.Allow(context.TODO(), "user:1", limit)
+			suite.NoError(err)
+			if err == nil && res.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	suite.Equal(5, allowed, "only burst requests should be allowed")
+}
+
+func (suite *RateLimiterTestSuite) TestDeleteExpired() {
+	rl := NewRateLimiter(suite.db)
+	ctx := context.TODO()
+
+	_, err := rl.Allow(ctx, "refilling", domain.RateLimit{Rate: 0.001, Burst: 1})
+	suite.NoError(err)
+
+	_, err = suite.db.ExecContext(ctx, `
+        INSERT INTO rate_limits (key, tokens, allowed, updated_at, expires_at)
+        VALUES ('full', 1, TRUE, NOW() - INTERVAL '1 hour', NOW() - INTERVAL '1 minute')`)
+	suite.NoError(err)
+
+	n, err := rl.DeleteExpired(ctx, 100)
+	suite.NoError(err)
+	suite.Equal(int64(1), n)
+
+	var count int
+	err = suite.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM rate_limits WHERE key = 'refilling'`).Scan(&count)
+	suite.NoError(err)
+	suite.Equal(1, count, "bucket still refilling should be kept")
+}
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/unknowntpo/todos/internal/domain"
 	"github.com/unknowntpo/todos/internal/domain/errors"
 	"github.com/unknowntpo/todos/internal/logger/zerolog"
 
@@ -47,3 +48,20 @@ func TestHandlerWrapper(t *testing.T) {
 	assert.Contains(t, rr.Body.String(), wantBodyMsg, "response should contain these message")
 	assert.Contains(t, logBuf.String(), wantMsg, "output of logger should contain these message")
 }
+
+func TestErrorResponseRequestID(t *testing.T) {
+	rc := NewReactor(zerolog.New(new(bytes.Buffer)))
+
+	r, err := http.NewRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		t.Fatalf("failed to create new request: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	rc.NotFoundResponse(rr, r)
+	assert.NotContains(t, rr.Body.String(), "request_id", "request without ID shouldn't have it in the body")
+
+	rr = httptest.NewRecorder()
+	rc.NotFoundResponse(rr, r.WithContext(domain.ContextWithRequestID(r.Context(), "abc-123")))
+	assert.Contains(t, rr.Body.String(), `"request_id": "abc-123"`)
+}
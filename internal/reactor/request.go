@@ -116,11 +116,16 @@ func (rc *Reactor) ReadJSON(w http.ResponseWriter, r *http.Request, dst interfac
 }
 
 func (rc *Reactor) ReadIDParam(r *http.Request) (int64, error) {
+	return rc.ReadInt64Param(r, "id")
+}
+
+// ReadInt64Param reads the positive integer URL parameter with given name.
+func (rc *Reactor) ReadInt64Param(r *http.Request, name string) (int64, error) {
 	params := httprouter.ParamsFromContext(r.Context())
 
-	id, err := strconv.ParseInt(params.ByName("id"), 10, 64)
+	id, err := strconv.ParseInt(params.ByName(name), 10, 64)
 	if err != nil || id < 1 {
-		return 0, errors.New("invalid id parameter")
+		return 0, errors.New("invalid " + name + " parameter")
 	}
 
 	return id, nil
@@ -151,6 +156,25 @@ func (rc *Reactor) ReadCSV(qs url.Values, key string, defaultValue []string) []s
 	return strings.Split(csv, ",")
 }
 
+// ReadBool reads a boolean value from the query string. If no matching key could be
+// found it returns the provided default value. If the value couldn't be converted to
+// a boolean, then we record an error message in the provided Validator instance.
+func (rc *Reactor) ReadBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return defaultValue
+	}
+
+	return b
+}
+
 // The ReadInt reads a string value from the query string and converts it to an
 // integer before returning. If no matching key count be found it returns the provided
 // default value. If the value couldn't be converted to an integer, then we record an
@@ -3,20 +3,26 @@ package reactor
 import (
 	"fmt"
 	"net/http"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/logger"
 )
 
 type ErrorResponse struct {
 	ErrMsg interface{} `json:"error"`
+	// RequestID lets clients quote the request when they report the error.
+	RequestID string `json:"request_id,omitempty"`
 }
 
 func (rc *Reactor) errorResponse(w http.ResponseWriter, r *http.Request, status int, message interface{}) {
 	err := rc.WriteJSON(w, status, &ErrorResponse{
-		ErrMsg: message,
+		ErrMsg:    message,
+		RequestID: domain.RequestIDFromContext(r.Context()),
 	})
 	if err != nil {
 		// Something goes wrong during sending server error response.
 		// So we write the message directly.
-		rc.Logger.PrintError(err, nil)
+		logger.FromContext(r.Context(), rc.Logger).PrintError(err, nil)
 		w.WriteHeader(http.StatusInternalServerError)
 		msg := `{"error":"the server encountered a problem and could not process your request"}`
 		w.Write([]byte(msg))
@@ -26,7 +32,11 @@ func (rc *Reactor) errorResponse(w http.ResponseWriter, r *http.Request, status
 
 func (rc *Reactor) ServerErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
 	message := "the server encountered a problem and could not process your request"
-	rc.Logger.PrintError(err, nil)
+	logger.FromContext(r.Context(), rc.Logger).PrintError(err, map[string]interface{}{
+		"request_method": r.Method,
+		"request_url":    r.URL.String(),
+		"client_ip":      domain.ClientFromContext(r.Context()).IP,
+	})
 
 	rc.errorResponse(w, r, http.StatusInternalServerError, message)
 }
@@ -81,7 +91,17 @@ func (rc *Reactor) NotPermittedResponse(w http.ResponseWriter, r *http.Request)
 	rc.errorResponse(w, r, http.StatusForbidden, message)
 }
 
+func (rc *Reactor) InsufficientScopeResponse(w http.ResponseWriter, r *http.Request) {
+	message := "your access token doesn't have the necessary scope to access this resource"
+	rc.errorResponse(w, r, http.StatusForbidden, message)
+}
+
 func (rc *Reactor) RateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
 	message := "rate limit exceeded"
 	rc.errorResponse(w, r, http.StatusTooManyRequests, message)
 }
+
+func (rc *Reactor) InvalidCSRFTokenResponse(w http.ResponseWriter, r *http.Request) {
+	message := "missing or invalid CSRF token, send the csrf_token cookie in the X-CSRF-Token header"
+	rc.errorResponse(w, r, http.StatusForbidden, message)
+}
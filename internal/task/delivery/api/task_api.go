@@ -1,8 +1,10 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/unknowntpo/todos/internal/domain"
 
@@ -49,6 +51,31 @@ type UpdateTaskByIDResponse struct {
 	Task *domain.Task `json:"updated_task"`
 }
 
+type AssignTaskRequest struct {
+	Email string `json:"email"`
+}
+
+type AssignTaskResponse struct {
+	Task *domain.Task `json:"task"`
+}
+
+type CreateTaskShareRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type CreateTaskShareResponse struct {
+	Share *domain.TaskShare `json:"share"`
+}
+
+type GetTaskSharesResponse struct {
+	Shares []*domain.TaskShare `json:"shares"`
+}
+
+type DeleteTaskShareResponse struct {
+	Message string `json:"message"`
+}
+
 type taskAPI struct {
 	tu  domain.TaskUsecase
 	mid *middleware.Middleware
@@ -57,11 +84,16 @@ type taskAPI struct {
 
 func NewTaskAPI(router *httprouter.Router, tu domain.TaskUsecase, mid *middleware.Middleware, rc *reactor.Reactor) {
 	api := &taskAPI{tu: tu, mid: mid, rc: rc}
-	router.Handler(http.MethodGet, "/v1/tasks", mid.RequireActivatedUser(http.HandlerFunc(api.GetAll)))
-	router.Handler(http.MethodGet, "/v1/tasks/:id", mid.RequireActivatedUser(http.HandlerFunc(api.GetByID)))
-	router.Handler(http.MethodPost, "/v1/tasks", mid.RequireActivatedUser(http.HandlerFunc(api.Insert)))
-	router.Handler(http.MethodPatch, "/v1/tasks/:id", mid.RequireActivatedUser(http.HandlerFunc(api.Update)))
-	router.Handler(http.MethodDelete, "/v1/tasks/:id", mid.RequireActivatedUser(http.HandlerFunc(api.Delete)))
+	router.Handler(http.MethodGet, "/v1/tasks", mid.RequirePermission(domain.PermissionTasksRead, http.HandlerFunc(api.GetAll)))
+	router.Handler(http.MethodGet, "/v1/tasks/:id", mid.RequirePermission(domain.PermissionTasksRead, http.HandlerFunc(api.GetByID)))
+	router.Handler(http.MethodPost, "/v1/tasks", mid.RequirePermission(domain.PermissionTasksWrite, http.HandlerFunc(api.Insert)))
+	router.Handler(http.MethodPatch, "/v1/tasks/:id", mid.RequirePermission(domain.PermissionTasksWrite, http.HandlerFunc(api.Update)))
+	router.Handler(http.MethodDelete, "/v1/tasks/:id", mid.RequirePermission(domain.PermissionTasksWrite, http.HandlerFunc(api.Delete)))
+	router.Handler(http.MethodPut, "/v1/tasks/:id/assignee", mid.RequirePermission(domain.PermissionTasksWrite, http.HandlerFunc(api.Assign)))
+	router.Handler(http.MethodDelete, "/v1/tasks/:id/assignee", mid.RequirePermission(domain.PermissionTasksWrite, http.HandlerFunc(api.Unassign)))
+	router.Handler(http.MethodGet, "/v1/tasks/:id/shares", mid.RequirePermission(domain.PermissionTasksRead, http.HandlerFunc(api.GetShares)))
+	router.Handler(http.MethodPost, "/v1/tasks/:id/shares", mid.RequirePermission(domain.PermissionTasksWrite, http.HandlerFunc(api.CreateShare)))
+	router.Handler(http.MethodDelete, "/v1/tasks/:id/shares/:user_id", mid.RequirePermission(domain.PermissionTasksWrite, http.HandlerFunc(api.DeleteShare)))
 }
 
 // GetAll gets all tasks.
@@ -71,11 +103,14 @@ func NewTaskAPI(router *httprouter.Router, tu domain.TaskUsecase, mid *middlewar
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param X-Workspace header string false "Slug of the workspace, omit it for personal tasks"
 // @Param title query string false "title filter"
 // @Param sort query string false "sort filter"
 // @Param id query string false "id filter"
 // @Param page query string false "page filter"
 // @Param page_size query string false "page size filter"
+// @Param shared query bool false "list the tasks shared with me instead of my own tasks"
+// @Param assignee query string false "list the tasks assigned to 'me' or to the user with given id"
 // @Success 200 {object} GetAllTasksResponse
 // @Failure 400 {object} reactor.ErrorResponse
 // @Failure 404 {object} reactor.ErrorResponse
@@ -87,7 +122,9 @@ func (t *taskAPI) GetAll(w http.ResponseWriter, r *http.Request) {
 	user := helpers.ContextGetUser(r)
 
 	var input struct {
-		Title string
+		Title    string
+		Shared   bool
+		Assignee string
 		domain.Filters
 	}
 
@@ -95,6 +132,24 @@ func (t *taskAPI) GetAll(w http.ResponseWriter, r *http.Request) {
 
 	qs := r.URL.Query()
 	input.Title = t.rc.ReadString(qs, "title", "")
+	input.Shared = t.rc.ReadBool(qs, "shared", false, v)
+	input.Assignee = t.rc.ReadString(qs, "assignee", "")
+
+	// The assignee is either "me" or the id of a user.
+	var assigneeID int64
+	switch input.Assignee {
+	case "":
+	case "me":
+		assigneeID = user.ID
+	default:
+		id, err := strconv.ParseInt(input.Assignee, 10, 64)
+		if err != nil || id < 1 {
+			v.AddError("assignee", "must be 'me' or a user id")
+		}
+		assigneeID = id
+	}
+
+	v.Check(!input.Shared || input.Assignee == "", "assignee", "cannot be combined with shared")
 	input.CurrentPage = t.rc.ReadInt(qs, "page", 1, v)
 	input.PageSize = t.rc.ReadInt(qs, "page_size", 20, v)
 
@@ -107,7 +162,18 @@ func (t *taskAPI) GetAll(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := r.Context()
-	tasks, metadata, err := t.tu.GetAll(ctx, user.ID, input.Title, input.Filters)
+
+	getAll := t.tu.GetAll
+	switch {
+	case input.Shared:
+		getAll = t.tu.GetAllShared
+	case assigneeID != 0:
+		getAll = func(ctx context.Context, userID int64, title string, filters domain.Filters) ([]*domain.Task, domain.Metadata, error) {
+			return t.tu.GetAllAssigned(ctx, userID, assigneeID, title, filters)
+		}
+	}
+
+	tasks, metadata, err := getAll(ctx, user.ID, input.Title, input.Filters)
 	if err != nil {
 		switch {
 		case errors.KindIs(err, errors.KindRecordNotFound):
@@ -135,6 +201,7 @@ func (t *taskAPI) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param X-Workspace header string false "Slug of the workspace, omit it for personal tasks"
 // @Param taskID path int true "Task ID"
 // @Success 200 {object} GetAllTasksResponse
 // @Failure 400 {object} reactor.ErrorResponse
@@ -177,6 +244,7 @@ func (t *taskAPI) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param X-Workspace header string false "Slug of the workspace, omit it for personal tasks"
 // @Param reqBody body CreateTaskRequest true "create task request body"
 // @Success 201 {object} domain.Task
 // @Failure 400 {object} reactor.ErrorResponse
@@ -232,6 +300,7 @@ func (t *taskAPI) Insert(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param X-Workspace header string false "Slug of the workspace, omit it for personal tasks"
 // @Param taskID path int true "Task ID"
 // @Param reqBody body UpdateTaskByIDRequest true "request body"
 // @Success 200 {object} domain.Task
@@ -253,8 +322,14 @@ func (t *taskAPI) Update(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	task, err := t.tu.GetByID(ctx, user.ID, taskID)
 	if err != nil {
-		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
-		return
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			t.rc.NotFoundResponse(w, r)
+			return
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+			return
+		}
 	}
 
 	var input struct {
@@ -295,12 +370,15 @@ func (t *taskAPI) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx = r.Context()
-	err = t.tu.Update(ctx, task)
+	err = t.tu.Update(ctx, user.ID, task)
 	if err != nil {
 		switch {
 		case errors.KindIs(err, errors.KindEditConflict):
 			t.rc.EditConflictResponse(w, r)
 			return
+		case errors.KindIs(err, errors.KindNotPermitted):
+			t.rc.NotPermittedResponse(w, r)
+			return
 		default:
 			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
 			return
@@ -319,6 +397,7 @@ func (t *taskAPI) Update(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param X-Workspace header string false "Slug of the workspace, omit it for personal tasks"
 // @Param taskID path int true "Task ID"
 // @Success 200 {object} DeleteTaskByIDResponse
 // @Failure 400 {object} reactor.ErrorResponse
@@ -345,6 +424,9 @@ func (t *taskAPI) Delete(w http.ResponseWriter, r *http.Request) {
 		case errors.KindIs(err, errors.KindRecordNotFound):
 			t.rc.NotFoundResponse(w, r)
 			return
+		case errors.KindIs(err, errors.KindNotPermitted):
+			t.rc.NotPermittedResponse(w, r)
+			return
 		default:
 			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
 			return
@@ -357,3 +439,315 @@ func (t *taskAPI) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// Assign assigns a task to a user.
+// @Summary Assign a task to a user by email.
+// @Description: Only the owner of the task can assign it, the assignee is notified by email
+// @Description: and can read the task and mark it as done.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param X-Workspace header string false "Slug of the workspace, omit it for personal tasks"
+// @Param taskID path int true "Task ID"
+// @Param reqBody body AssignTaskRequest true "request body"
+// @Success 200 {object} AssignTaskResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tasks/{taskID}/assignee [put]
+func (t *taskAPI) Assign(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("taskAPI.Assign")
+
+	user := helpers.ContextGetUser(r)
+
+	taskID, err := t.rc.ReadIDParam(r)
+	if err != nil {
+		t.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	var input AssignTaskRequest
+
+	err = t.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		t.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if domain.ValidateEmail(v, input.Email); !v.Valid() {
+		t.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+
+	// Look up the task first, so that we can tell a missing task apart from
+	// a missing assignee.
+	_, err = t.tu.GetByID(ctx, user.ID, taskID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			t.rc.NotFoundResponse(w, r)
+			return
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+			return
+		}
+	}
+
+	task, err := t.tu.Assign(ctx, user.ID, taskID, input.Email)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindNotPermitted):
+			t.rc.NotPermittedResponse(w, r)
+			return
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			v.AddError("email", noUserMessage(r))
+			t.rc.FailedValidationResponse(w, r, v.Err())
+			return
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+			return
+		}
+	}
+
+	err = t.rc.WriteJSON(w, http.StatusOK, &AssignTaskResponse{task})
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// Unassign removes the assignee of a task.
+// @Summary Remove the assignee of a task.
+// @Description: The owner can unassign anyone, the assignee can unassign themselves.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param X-Workspace header string false "Slug of the workspace, omit it for personal tasks"
+// @Param taskID path int true "Task ID"
+// @Success 200 {object} AssignTaskResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tasks/{taskID}/assignee [delete]
+func (t *taskAPI) Unassign(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("taskAPI.Unassign")
+
+	user := helpers.ContextGetUser(r)
+
+	taskID, err := t.rc.ReadIDParam(r)
+	if err != nil {
+		t.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	task, err := t.tu.Unassign(ctx, user.ID, taskID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			t.rc.NotFoundResponse(w, r)
+			return
+		case errors.KindIs(err, errors.KindNotPermitted):
+			t.rc.NotPermittedResponse(w, r)
+			return
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+			return
+		}
+	}
+
+	err = t.rc.WriteJSON(w, http.StatusOK, &AssignTaskResponse{task})
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// GetShares lists the users a task is shared with.
+// @Summary List the users a task is shared with.
+// @Description: None.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param X-Workspace header string false "Slug of the workspace, omit it for personal tasks"
+// @Param taskID path int true "Task ID"
+// @Success 200 {object} GetTaskSharesResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tasks/{taskID}/shares [get]
+func (t *taskAPI) GetShares(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("taskAPI.GetShares")
+
+	user := helpers.ContextGetUser(r)
+
+	taskID, err := t.rc.ReadIDParam(r)
+	if err != nil {
+		t.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	shares, err := t.tu.GetShares(ctx, user.ID, taskID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			t.rc.NotFoundResponse(w, r)
+			return
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+			return
+		}
+	}
+
+	err = t.rc.WriteJSON(w, http.StatusOK, &GetTaskSharesResponse{shares})
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// CreateShare shares a task with another user.
+// @Summary Share a task with another user by email, as editor or viewer.
+// @Description: Only the owner of the task can share it, the invitee is notified by email.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param X-Workspace header string false "Slug of the workspace, omit it for personal tasks"
+// @Param taskID path int true "Task ID"
+// @Param reqBody body CreateTaskShareRequest true "request body"
+// @Success 201 {object} CreateTaskShareResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tasks/{taskID}/shares [post]
+func (t *taskAPI) CreateShare(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("taskAPI.CreateShare")
+
+	user := helpers.ContextGetUser(r)
+
+	taskID, err := t.rc.ReadIDParam(r)
+	if err != nil {
+		t.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	var input CreateTaskShareRequest
+
+	err = t.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		t.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if domain.ValidateTaskShare(v, input.Email, input.Role); !v.Valid() {
+		t.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+
+	// Look up the task first, so that we can tell a missing task apart from
+	// a missing invitee.
+	_, err = t.tu.GetByID(ctx, user.ID, taskID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			t.rc.NotFoundResponse(w, r)
+			return
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+			return
+		}
+	}
+
+	share, err := t.tu.Share(ctx, user.ID, taskID, input.Email, input.Role)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindNotPermitted):
+			t.rc.NotPermittedResponse(w, r)
+			return
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			v.AddError("email", noUserMessage(r))
+			t.rc.FailedValidationResponse(w, r, v.Err())
+			return
+		case errors.KindIs(err, errors.KindFailedValidation):
+			v.AddError("email", "you cannot share a task with yourself")
+			t.rc.FailedValidationResponse(w, r, v.Err())
+			return
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+			return
+		}
+	}
+
+	err = t.rc.WriteJSON(w, http.StatusCreated, &CreateTaskShareResponse{share})
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// DeleteShare stops sharing a task with a user.
+// @Summary Stop sharing a task with a user.
+// @Description: The owner can remove any user, other users can only remove themselves.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param X-Workspace header string false "Slug of the workspace, omit it for personal tasks"
+// @Param taskID path int true "Task ID"
+// @Param userID path int true "User ID"
+// @Success 200 {object} DeleteTaskShareResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tasks/{taskID}/shares/{userID} [delete]
+func (t *taskAPI) DeleteShare(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("taskAPI.DeleteShare")
+
+	user := helpers.ContextGetUser(r)
+
+	taskID, err := t.rc.ReadIDParam(r)
+	if err != nil {
+		t.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	shareUserID, err := t.rc.ReadInt64Param(r, "user_id")
+	if err != nil {
+		t.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	err = t.tu.Unshare(ctx, user.ID, taskID, shareUserID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			t.rc.NotFoundResponse(w, r)
+			return
+		case errors.KindIs(err, errors.KindNotPermitted):
+			t.rc.NotPermittedResponse(w, r)
+			return
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+			return
+		}
+	}
+
+	err = t.rc.WriteJSON(w, http.StatusOK, &DeleteTaskShareResponse{"task successfully unshared"})
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// noUserMessage returns the validation message for an email address which doesn't
+// belong to any user who can be given access to the tasks of the current workspace.
+func noUserMessage(r *http.Request) string {
+	if helpers.ContextGetWorkspace(r) != nil {
+		return "no member of this workspace with this email address was found"
+	}
+	return "no user with this email address was found"
+}
@@ -17,21 +17,92 @@ func NewTaskRepo(DB *sql.DB) domain.TaskRepository {
 	return &taskRepo{DB}
 }
 
+// GetAll returns the tasks owned by the user with given userID, if ctx carries
+// a workspace, all tasks of the workspace are returned instead.
 func (tr *taskRepo) GetAll(ctx context.Context, userID int64, title string, filters domain.Filters) ([]*domain.Task, domain.Metadata, error) {
 	const op errors.Op = "taskRepo.GetAll"
 	query := fmt.Sprintf(`
-        SELECT count(*) OVER(), id, user_id, created_at, title, content, done, version
+        SELECT count(*) OVER(), tasks.id, tasks.user_id, tasks.assignee_id, tasks.workspace_id, tasks.created_at, tasks.title, tasks.content, tasks.done, tasks.version,
+        %s
         FROM tasks
-        WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '') 
-	AND user_id = $2
-        ORDER BY %s %s, id ASC
+        LEFT JOIN task_shares
+        ON tasks.id = task_shares.task_id AND task_shares.user_id = $2
+        WHERE (to_tsvector('simple', tasks.title) @@ plainto_tsquery('simple', $1) OR $1 = '') 
+	AND tasks.workspace_id IS NOT DISTINCT FROM $5
+	AND (tasks.user_id = $2 OR tasks.workspace_id IS NOT NULL)
+        ORDER BY tasks.%s %s, tasks.id ASC
+	LIMIT $3 OFFSET $4`, roleColumn, filters.SortColumn(), filters.SortDirection())
+
+	args := []interface{}{title, userID, filters.Limit(), filters.Offset(), workspaceID(ctx)}
+
+	tasks, metadata, err := tr.queryTasks(ctx, query, args, filters)
+	if err != nil {
+		return nil, domain.CalculateMetadata(0, 0, 0), errors.E(op, err)
+	}
+
+	return tasks, metadata, nil
+}
+
+// GetAllShared returns the tasks other users have shared with the user with given userID.
+func (tr *taskRepo) GetAllShared(ctx context.Context, userID int64, title string, filters domain.Filters) ([]*domain.Task, domain.Metadata, error) {
+	const op errors.Op = "taskRepo.GetAllShared"
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), tasks.id, tasks.user_id, tasks.assignee_id, tasks.workspace_id, tasks.created_at, tasks.title, tasks.content, tasks.done, tasks.version, task_shares.role
+        FROM tasks
+        INNER JOIN task_shares
+        ON tasks.id = task_shares.task_id
+        WHERE (to_tsvector('simple', tasks.title) @@ plainto_tsquery('simple', $1) OR $1 = '') 
+	AND task_shares.user_id = $2
+	AND tasks.workspace_id IS NOT DISTINCT FROM $5
+        ORDER BY tasks.%s %s, tasks.id ASC
 	LIMIT $3 OFFSET $4`, filters.SortColumn(), filters.SortDirection())
 
-	args := []interface{}{title, userID, filters.Limit(), filters.Offset()}
+	args := []interface{}{title, userID, filters.Limit(), filters.Offset(), workspaceID(ctx)}
+
+	tasks, metadata, err := tr.queryTasks(ctx, query, args, filters)
+	if err != nil {
+		return nil, domain.CalculateMetadata(0, 0, 0), errors.E(op, err)
+	}
+
+	return tasks, metadata, nil
+}
+
+// GetAllAssigned returns the tasks assigned to the user with given assigneeID, which
+// the user with given userID can access.
+func (tr *taskRepo) GetAllAssigned(ctx context.Context, userID int64, assigneeID int64, title string, filters domain.Filters) ([]*domain.Task, domain.Metadata, error) {
+	const op errors.Op = "taskRepo.GetAllAssigned"
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), tasks.id, tasks.user_id, tasks.assignee_id, tasks.workspace_id, tasks.created_at, tasks.title, tasks.content, tasks.done, tasks.version,
+        %s
+        FROM tasks
+        LEFT JOIN task_shares
+        ON tasks.id = task_shares.task_id AND task_shares.user_id = $2
+        WHERE (to_tsvector('simple', tasks.title) @@ plainto_tsquery('simple', $1) OR $1 = '') 
+	AND tasks.assignee_id = $3
+	AND tasks.workspace_id IS NOT DISTINCT FROM $6
+	AND %s
+        ORDER BY tasks.%s %s, tasks.id ASC
+	LIMIT $4 OFFSET $5`, roleColumn, visibleCondition, filters.SortColumn(), filters.SortDirection())
+
+	args := []interface{}{title, userID, assigneeID, filters.Limit(), filters.Offset(), workspaceID(ctx)}
+
+	tasks, metadata, err := tr.queryTasks(ctx, query, args, filters)
+	if err != nil {
+		return nil, domain.CalculateMetadata(0, 0, 0), errors.E(op, err)
+	}
+
+	return tasks, metadata, nil
+}
+
+// queryTasks runs the paginated task query and scans the result set.
+// Every row is expected to start with the total count of records, followed by
+// the task columns and the role of the requesting user.
+func (tr *taskRepo) queryTasks(ctx context.Context, query string, args []interface{}, filters domain.Filters) ([]*domain.Task, domain.Metadata, error) {
+	const op errors.Op = "taskRepo.queryTasks"
 
 	rows, err := tr.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, domain.Metadata{}, err
+		return nil, domain.Metadata{}, errors.E(op, errors.KindDatabase, err)
 	}
 	defer rows.Close()
 
@@ -41,21 +112,29 @@ func (tr *taskRepo) GetAll(ctx context.Context, userID int64, title string, filt
 	// Use rows.Next to iterate through the rows in the resultset.
 	for rows.Next() {
 		var task domain.Task
+		var assigneeID, workspaceID sql.NullInt64
 
 		err := rows.Scan(
 			&totalRecords,
 			&task.ID,
 			&task.UserID,
+			&assigneeID,
+			&workspaceID,
 			&task.CreatedAt,
 			&task.Title,
 			&task.Content,
 			&task.Done,
 			&task.Version,
+			&task.Role,
 		)
 		if err != nil {
-			return nil, domain.CalculateMetadata(0, 0, 0), err
+			return nil, domain.CalculateMetadata(0, 0, 0), errors.E(op, errors.KindDatabase, err)
 		}
 
+		task.AssigneeID = assigneeID.Int64
+		task.WorkspaceID = workspaceID.Int64
+		task.Role = withWorkspaceRole(ctx, task.Role)
+
 		// Add the Task struct to the slice.
 		tasks = append(tasks, &task)
 	}
@@ -76,6 +155,54 @@ func (tr *taskRepo) GetAll(ctx context.Context, userID int64, title string, filt
 	return tasks, metadata, nil
 }
 
+// roleColumn computes the role of the user identified by the $2 placeholder on the
+// task, the query must left join task_shares of that user.
+// Members of a workspace are viewers of the other tasks in the workspace, unless the
+// tasks are shared with or assigned to them, see withWorkspaceRole for the admins.
+// If the user has more than one role on the task, the most privileged one is used.
+const roleColumn = `CASE
+        WHEN tasks.user_id = $2 THEN 'owner'
+        WHEN task_shares.role = 'editor' THEN 'editor'
+        WHEN tasks.assignee_id = $2 THEN 'assignee'
+        ELSE COALESCE(task_shares.role, 'viewer')
+        END`
+
+// withWorkspaceRole returns the role of the user on the task of the workspace carried by ctx,
+// given the role computed by roleColumn. The owner and admins of the workspace are editors of
+// all its tasks.
+func withWorkspaceRole(ctx context.Context, role string) string {
+	ws, ok := domain.WorkspaceFromContext(ctx)
+	if !ok || role == domain.RoleOwner {
+		return role
+	}
+
+	if ws.TaskRole() == domain.RoleEditor {
+		return domain.RoleEditor
+	}
+
+	return role
+}
+
+// visibleCondition matches the tasks the user identified by the $2 placeholder can access,
+// the query must left join task_shares of that user. Every query must also restrict
+// tasks.workspace_id to the workspace returned by workspaceID, whose membership has
+// already been checked by the workspace middleware.
+const visibleCondition = `(tasks.user_id = $2 OR tasks.assignee_id = $2 OR task_shares.user_id IS NOT NULL OR tasks.workspace_id IS NOT NULL)`
+
+// workspaceID returns the id of the workspace stored in ctx, or NULL if the request
+// operates on the personal space of the user, it isolates the tasks of every workspace.
+func workspaceID(ctx context.Context) sql.NullInt64 {
+	ws, ok := domain.WorkspaceFromContext(ctx)
+	if !ok {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: ws.ID, Valid: true}
+}
+
+// GetByID returns the task with given taskID if the user with given userID
+// owns it, it has been shared with the user, it's assigned to the user or
+// it belongs to the workspace carried by ctx.
+// The role of the user on the task is stored in task.Role.
 func (tr *taskRepo) GetByID(ctx context.Context, userID int64, taskID int64) (*domain.Task, error) {
 	const op errors.Op = "taskRepo.GetByID"
 	if taskID < 1 {
@@ -83,21 +210,29 @@ func (tr *taskRepo) GetByID(ctx context.Context, userID int64, taskID int64) (*d
 	}
 
 	query := `
-	SELECT id, user_id, created_at, title, content, done, version
+	SELECT tasks.id, tasks.user_id, tasks.assignee_id, tasks.workspace_id, tasks.created_at, tasks.title, tasks.content, tasks.done, tasks.version,
+	` + roleColumn + `
 	FROM tasks
-	WHERE id = $1
-	AND user_id = $2`
+	LEFT JOIN task_shares
+	ON tasks.id = task_shares.task_id AND task_shares.user_id = $2
+	WHERE tasks.id = $1
+	AND tasks.workspace_id IS NOT DISTINCT FROM $3
+	AND ` + visibleCondition
 
 	var task domain.Task
+	var assigneeID, wsID sql.NullInt64
 
-	err := tr.DB.QueryRowContext(ctx, query, taskID, userID).Scan(
+	err := tr.DB.QueryRowContext(ctx, query, taskID, userID, workspaceID(ctx)).Scan(
 		&task.ID,
 		&task.UserID,
+		&assigneeID,
+		&wsID,
 		&task.CreatedAt,
 		&task.Title,
 		&task.Content,
 		&task.Done,
 		&task.Version,
+		&task.Role,
 	)
 	if err != nil {
 		switch {
@@ -108,22 +243,32 @@ func (tr *taskRepo) GetByID(ctx context.Context, userID int64, taskID int64) (*d
 		}
 	}
 
+	task.AssigneeID = assigneeID.Int64
+	task.WorkspaceID = wsID.Int64
+	task.Role = withWorkspaceRole(ctx, task.Role)
+
 	return &task, nil
 }
 
 func (tr *taskRepo) Insert(ctx context.Context, userID int64, task *domain.Task) error {
 	const op errors.Op = "taskRepo.Insert"
 
-	query := `INSERT INTO tasks (user_id, title, content, done)
-	      VALUES ($1, $2, $3, $4)
+	wsID := workspaceID(ctx)
+
+	query := `INSERT INTO tasks (user_id, title, content, done, workspace_id)
+	      VALUES ($1, $2, $3, $4, $5)
 	      RETURNING id, created_at, version`
-	args := []interface{}{userID, task.Title, task.Content, task.Done}
+	args := []interface{}{userID, task.Title, task.Content, task.Done, wsID}
 
 	err := tr.DB.QueryRowContext(ctx, query, args...).Scan(&task.ID, &task.CreatedAt, &task.Version)
 	if err != nil {
 		return errors.E(op, errors.KindDatabase, err)
 	}
 
+	task.UserID = userID
+	task.WorkspaceID = wsID.Int64
+	task.Role = domain.RoleOwner
+
 	return nil
 }
 
@@ -132,7 +277,7 @@ func (tr *taskRepo) Update(ctx context.Context, task *domain.Task) error {
 
 	query := `UPDATE tasks
         SET title = $1, content = $2, done = $3, version = version + 1
-	WHERE id = $4 AND user_id = $5 AND version = $6
+	WHERE id = $4 AND user_id = $5 AND version = $6 AND workspace_id IS NOT DISTINCT FROM $7
 	RETURNING version`
 
 	args := []interface{}{
@@ -142,6 +287,7 @@ func (tr *taskRepo) Update(ctx context.Context, task *domain.Task) error {
 		task.ID,
 		task.UserID,
 		task.Version,
+		workspaceID(ctx),
 	}
 
 	if err := tr.DB.QueryRowContext(ctx, query, args...).Scan(&task.Version); err != nil {
@@ -160,9 +306,9 @@ func (tr *taskRepo) Delete(ctx context.Context, userID int64, taskID int64) erro
 	const op errors.Op = "taskRepo.Delete"
 
 	query := `DELETE FROM tasks
-        WHERE id = $1 AND user_id = $2`
+        WHERE id = $1 AND user_id = $2 AND workspace_id IS NOT DISTINCT FROM $3`
 
-	result, err := tr.DB.ExecContext(ctx, query, taskID, userID)
+	result, err := tr.DB.ExecContext(ctx, query, taskID, userID, workspaceID(ctx))
 	if err != nil {
 		return errors.E(op, errors.KindDatabase, err)
 	}
@@ -181,3 +327,135 @@ func (tr *taskRepo) Delete(ctx context.Context, userID int64, taskID int64) erro
 
 	return nil
 }
+
+// UpdateAssignee assigns the task with given taskID to the user with given assigneeID,
+// if assigneeID is 0, the task is unassigned.
+func (tr *taskRepo) UpdateAssignee(ctx context.Context, taskID int64, assigneeID int64) error {
+	const op errors.Op = "taskRepo.UpdateAssignee"
+
+	query := `
+        UPDATE tasks
+        SET assignee_id = $1, version = version + 1
+        WHERE id = $2 AND workspace_id IS NOT DISTINCT FROM $3`
+
+	// Store NULL instead of 0 so that the foreign key constraint is satisfied.
+	assignee := sql.NullInt64{Int64: assigneeID, Valid: assigneeID != 0}
+
+	result, err := tr.DB.ExecContext(ctx, query, assignee, taskID, workspaceID(ctx))
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// InsertShare shares the task with the user specified in share, if the task has
+// already been shared with the user, the role is updated instead.
+func (tr *taskRepo) InsertShare(ctx context.Context, share *domain.TaskShare) error {
+	const op errors.Op = "taskRepo.InsertShare"
+
+	query := `
+        INSERT INTO task_shares (task_id, user_id, role)
+        SELECT $1, $2, $3
+        WHERE EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND workspace_id IS NOT DISTINCT FROM $4)
+        ON CONFLICT (task_id, user_id) DO UPDATE SET role = EXCLUDED.role
+        RETURNING created_at`
+
+	args := []interface{}{share.TaskID, share.UserID, share.Role, workspaceID(ctx)}
+
+	err := tr.DB.QueryRowContext(ctx, query, args...).Scan(&share.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+		default:
+			return errors.E(op, errors.KindDatabase, err)
+		}
+	}
+
+	return nil
+}
+
+// GetShares returns all users the task with given taskID has been shared with.
+func (tr *taskRepo) GetShares(ctx context.Context, taskID int64) ([]*domain.TaskShare, error) {
+	const op errors.Op = "taskRepo.GetShares"
+
+	query := `
+        SELECT task_shares.task_id, task_shares.user_id, users.email, task_shares.role, task_shares.created_at
+        FROM task_shares
+        INNER JOIN users
+        ON task_shares.user_id = users.id
+        INNER JOIN tasks
+        ON task_shares.task_id = tasks.id
+        WHERE task_shares.task_id = $1
+        AND tasks.workspace_id IS NOT DISTINCT FROM $2
+        ORDER BY task_shares.created_at ASC, task_shares.user_id ASC`
+
+	rows, err := tr.DB.QueryContext(ctx, query, taskID, workspaceID(ctx))
+	if err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+	defer rows.Close()
+
+	shares := []*domain.TaskShare{}
+
+	for rows.Next() {
+		var share domain.TaskShare
+
+		err := rows.Scan(
+			&share.TaskID,
+			&share.UserID,
+			&share.Email,
+			&share.Role,
+			&share.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+
+		shares = append(shares, &share)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+
+	return shares, nil
+}
+
+// DeleteShare stops sharing the task with given taskID with the user with given userID.
+func (tr *taskRepo) DeleteShare(ctx context.Context, taskID int64, userID int64) error {
+	const op errors.Op = "taskRepo.DeleteShare"
+
+	query := `
+        DELETE FROM task_shares
+        USING tasks
+        WHERE task_shares.task_id = tasks.id
+        AND task_shares.task_id = $1 AND task_shares.user_id = $2
+        AND tasks.workspace_id IS NOT DISTINCT FROM $3`
+
+	result, err := tr.DB.ExecContext(ctx, query, taskID, userID, workspaceID(ctx))
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	return nil
+}
@@ -360,3 +360,176 @@ func (suite *TaskRepoTestSuite) TestDelete() {
 		suite.T().Skip("TODO: finish the implementation")
 	})
 }
+
+func (suite *TaskRepoTestSuite) TestShares() {
+	suite.Run("Success", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewTaskRepo(suite.db)
+
+		// Create the user we share the task with.
+		bob := testutil.NewFakeUser(suite.T(), "Bob Ross", "bob.ross@example.com", "pa55word", true)
+		query := `
+		INSERT INTO users (name, email, password_hash, activated)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+		err := suite.db.QueryRowContext(ctx, query, bob.Name, bob.Email, bob.Password.Hash, bob.Activated).Scan(&bob.ID)
+		if err != nil {
+			suite.T().Fatal(err)
+		}
+
+		task := &domain.Task{Title: "Do housework", Content: "It's boring!"}
+		if err := repo.Insert(ctx, suite.fakeuser.ID, task); err != nil {
+			suite.T().Fatalf("failed to insert task %v to database: %v", task, err)
+		}
+
+		// Bob can't see the task before it's shared with him.
+		_, err = repo.GetByID(ctx, bob.ID, task.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		share := &domain.TaskShare{TaskID: task.ID, UserID: bob.ID, Role: domain.RoleViewer}
+		suite.NoError(repo.InsertShare(ctx, share))
+
+		gotTask, err := repo.GetByID(ctx, bob.ID, task.ID)
+		suite.NoError(err)
+		suite.Equal(domain.RoleViewer, gotTask.Role)
+		suite.Equal(suite.fakeuser.ID, gotTask.UserID)
+
+		gotTask, err = repo.GetByID(ctx, suite.fakeuser.ID, task.ID)
+		suite.NoError(err)
+		suite.Equal(domain.RoleOwner, gotTask.Role)
+
+		filters := domain.Filters{CurrentPage: 1, PageSize: 10, Sort: "id", SortSafelist: []string{"id"}}
+		sharedTasks, _, err := repo.GetAllShared(ctx, bob.ID, "", filters)
+		suite.NoError(err)
+		suite.Len(sharedTasks, 1)
+
+		shares, err := repo.GetShares(ctx, task.ID)
+		suite.NoError(err)
+		suite.Len(shares, 1)
+		suite.Equal(bob.Email, shares[0].Email)
+
+		suite.NoError(repo.DeleteShare(ctx, task.ID, bob.ID))
+
+		_, err = repo.GetByID(ctx, bob.ID, task.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		err = repo.DeleteShare(ctx, task.ID, bob.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
+
+func (suite *TaskRepoTestSuite) TestUpdateAssignee() {
+	suite.Run("Success", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewTaskRepo(suite.db)
+
+		bob := testutil.NewFakeUser(suite.T(), "Bob Ross", "bob.ross@example.com", "pa55word", true)
+		query := `
+		INSERT INTO users (name, email, password_hash, activated)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+		err := suite.db.QueryRowContext(ctx, query, bob.Name, bob.Email, bob.Password.Hash, bob.Activated).Scan(&bob.ID)
+		if err != nil {
+			suite.T().Fatal(err)
+		}
+
+		task := &domain.Task{Title: "Do housework", Content: "It's boring!"}
+		if err := repo.Insert(ctx, suite.fakeuser.ID, task); err != nil {
+			suite.T().Fatalf("failed to insert task %v to database: %v", task, err)
+		}
+
+		suite.NoError(repo.UpdateAssignee(ctx, task.ID, bob.ID))
+
+		gotTask, err := repo.GetByID(ctx, bob.ID, task.ID)
+		suite.NoError(err)
+		suite.Equal(domain.RoleAssignee, gotTask.Role)
+		suite.Equal(bob.ID, gotTask.AssigneeID)
+
+		filters := domain.Filters{CurrentPage: 1, PageSize: 10, Sort: "id", SortSafelist: []string{"id"}}
+		assignedTasks, _, err := repo.GetAllAssigned(ctx, suite.fakeuser.ID, bob.ID, "", filters)
+		suite.NoError(err)
+		suite.Len(assignedTasks, 1)
+
+		suite.NoError(repo.UpdateAssignee(ctx, task.ID, 0))
+
+		_, err = repo.GetByID(ctx, bob.ID, task.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
+
+func (suite *TaskRepoTestSuite) TestWorkspaceIsolation() {
+	suite.Run("Success", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewTaskRepo(suite.db)
+
+		ws := &domain.Workspace{Name: "Acme", Slug: "acme"}
+		err := suite.db.QueryRowContext(ctx, `INSERT INTO workspaces (name, slug) VALUES ($1, $2) RETURNING id`, ws.Name, ws.Slug).Scan(&ws.ID)
+		if err != nil {
+			suite.T().Fatal(err)
+		}
+		wsCtx := domain.ContextWithWorkspace(ctx, ws)
+
+		personalTask := &domain.Task{Title: "Do housework", Content: "It's boring!"}
+		if err := repo.Insert(ctx, suite.fakeuser.ID, personalTask); err != nil {
+			suite.T().Fatalf("failed to insert task %v to database: %v", personalTask, err)
+		}
+
+		workspaceTask := &domain.Task{Title: "Ship the release", Content: "It's fun!"}
+		if err := repo.Insert(wsCtx, suite.fakeuser.ID, workspaceTask); err != nil {
+			suite.T().Fatalf("failed to insert task %v to database: %v", workspaceTask, err)
+		}
+		suite.Equal(ws.ID, workspaceTask.WorkspaceID)
+
+		filters := domain.Filters{CurrentPage: 1, PageSize: 10, Sort: "id", SortSafelist: []string{"id"}}
+
+		tasks, _, err := repo.GetAll(ctx, suite.fakeuser.ID, "", filters)
+		suite.NoError(err)
+		suite.Len(tasks, 1)
+		suite.Equal(personalTask.ID, tasks[0].ID)
+
+		tasks, _, err = repo.GetAll(wsCtx, suite.fakeuser.ID, "", filters)
+		suite.NoError(err)
+		suite.Len(tasks, 1)
+		suite.Equal(workspaceTask.ID, tasks[0].ID)
+
+		// Tasks of other workspaces are invisible, even for their owner.
+		_, err = repo.GetByID(wsCtx, suite.fakeuser.ID, personalTask.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+		_, err = repo.GetByID(ctx, suite.fakeuser.ID, workspaceTask.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		err = repo.Delete(ctx, suite.fakeuser.ID, workspaceTask.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		// Other members of the workspace are viewers of the task, its admins are editors.
+		memberCtx := domain.ContextWithWorkspace(ctx, &domain.Workspace{ID: ws.ID, Slug: ws.Slug, Role: domain.WorkspaceRoleMember})
+		gotTask, err := repo.GetByID(memberCtx, suite.fakeuser.ID+1, workspaceTask.ID)
+		suite.NoError(err)
+		suite.Equal(domain.RoleViewer, gotTask.Role)
+
+		tasks, _, err = repo.GetAll(memberCtx, suite.fakeuser.ID+1, "", filters)
+		suite.NoError(err)
+		if suite.Len(tasks, 1) {
+			suite.Equal(domain.RoleViewer, tasks[0].Role)
+		}
+
+		adminCtx := domain.ContextWithWorkspace(ctx, &domain.Workspace{ID: ws.ID, Slug: ws.Slug, Role: domain.WorkspaceRoleAdmin})
+		gotTask, err = repo.GetByID(adminCtx, suite.fakeuser.ID+1, workspaceTask.ID)
+		suite.NoError(err)
+		suite.Equal(domain.RoleEditor, gotTask.Role)
+
+		// The owner of the task stays its owner whatever the workspace role.
+		gotTask, err = repo.GetByID(memberCtx, suite.fakeuser.ID, workspaceTask.ID)
+		suite.NoError(err)
+		suite.Equal(domain.RoleOwner, gotTask.Role)
+	})
+}
@@ -6,16 +6,38 @@ import (
 
 	"github.com/unknowntpo/todos/internal/domain"
 	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/logger"
+	"github.com/unknowntpo/todos/internal/mailer"
+	"github.com/unknowntpo/todos/internal/tracing"
+	"github.com/unknowntpo/todos/pkg/naivepool"
 )
 
 type taskUsecase struct {
 	taskRepo       domain.TaskRepository
+	userRepo       domain.UserRepository
+	workspaceRepo  domain.WorkspaceRepository
+	pool           *naivepool.Pool
+	mailer         *mailer.Mailer
+	logger         logger.Logger
 	contextTimeout time.Duration
 }
 
-func NewTaskUsecase(t domain.TaskRepository, timeout time.Duration) domain.TaskUsecase {
+func NewTaskUsecase(
+	t domain.TaskRepository,
+	ur domain.UserRepository,
+	wr domain.WorkspaceRepository,
+	p *naivepool.Pool,
+	mailer *mailer.Mailer,
+	logger logger.Logger,
+	timeout time.Duration,
+) domain.TaskUsecase {
 	return &taskUsecase{
 		taskRepo:       t,
+		userRepo:       ur,
+		workspaceRepo:  wr,
+		pool:           p,
+		mailer:         mailer,
+		logger:         logger,
 		contextTimeout: timeout,
 	}
 }
@@ -33,6 +55,35 @@ func (tu *taskUsecase) GetAll(ctx context.Context, userID int64, title string, f
 	return tasks, metadata, nil
 }
 
+// GetAllShared returns the tasks which are shared with the user.
+func (tu *taskUsecase) GetAllShared(ctx context.Context, userID int64, title string, filters domain.Filters) ([]*domain.Task, domain.Metadata, error) {
+	const op errors.Op = "taskUsecase.GetAllShared"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	tasks, metadata, err := tu.taskRepo.GetAllShared(ctx, userID, title, filters)
+	if err != nil {
+		return nil, domain.Metadata{}, errors.E(op, err)
+	}
+	return tasks, metadata, nil
+}
+
+// GetAllAssigned returns the tasks assigned to the user with given assigneeID,
+// which the user with given userID can access.
+func (tu *taskUsecase) GetAllAssigned(ctx context.Context, userID int64, assigneeID int64, title string, filters domain.Filters) ([]*domain.Task, domain.Metadata, error) {
+	const op errors.Op = "taskUsecase.GetAllAssigned"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	tasks, metadata, err := tu.taskRepo.GetAllAssigned(ctx, userID, assigneeID, title, filters)
+	if err != nil {
+		return nil, domain.Metadata{}, errors.E(op, err)
+	}
+	return tasks, metadata, nil
+}
+
 // Just call repo layer method for now.
 func (tu *taskUsecase) GetByID(ctx context.Context, userID int64, taskID int64) (*domain.Task, error) {
 	const op errors.Op = "taskUsecase.GetByID"
@@ -59,13 +110,29 @@ func (tu *taskUsecase) Insert(ctx context.Context, userID int64, task *domain.Ta
 
 	return nil
 }
-func (tu *taskUsecase) Update(ctx context.Context, task *domain.Task) error {
+
+// Update updates the task on behalf of the user with given userID,
+// the owner and editors of the task are permitted to do so, the assignee
+// is only permitted to change whether the task is done.
+func (tu *taskUsecase) Update(ctx context.Context, userID int64, task *domain.Task) error {
 	const op errors.Op = "taskUsecase.Update"
 
 	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
 	defer cancel()
 
-	err := tu.taskRepo.Update(ctx, task)
+	current, err := tu.taskRepo.GetByID(ctx, userID, task.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	switch {
+	case current.CanEdit():
+	case current.CanComplete() && task.Title == current.Title && task.Content == current.Content:
+	default:
+		return errors.E(op, errors.KindNotPermitted, domain.ErrNotPermitted)
+	}
+
+	err = tu.taskRepo.Update(ctx, task)
 	if err != nil {
 		return errors.E(op, err)
 	}
@@ -73,16 +140,259 @@ func (tu *taskUsecase) Update(ctx context.Context, task *domain.Task) error {
 	return nil
 }
 
+// Delete deletes the task, only the owner of the task is permitted to do so.
 func (tu *taskUsecase) Delete(ctx context.Context, userID int64, taskID int64) error {
 	const op errors.Op = "taskUsecase.Delete"
 
 	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
 	defer cancel()
 
-	err := tu.taskRepo.Delete(ctx, userID, taskID)
+	task, err := tu.taskRepo.GetByID(ctx, userID, taskID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if !task.IsOwner() {
+		return errors.E(op, errors.KindNotPermitted, domain.ErrNotPermitted)
+	}
+
+	err = tu.taskRepo.Delete(ctx, userID, taskID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// Assign assigns the task to the user who owns the given email address and notifies
+// that user by email. Only the owner of the task is permitted to assign it.
+// If there's no user with given email, or the user isn't a member of the workspace
+// of the task, the error with kind errors.KindRecordNotFound is returned.
+func (tu *taskUsecase) Assign(ctx context.Context, userID int64, taskID int64, email string) (*domain.Task, error) {
+	const op errors.Op = "taskUsecase.Assign"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	task, err := tu.taskRepo.GetByID(ctx, userID, taskID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	if !task.IsOwner() {
+		return nil, errors.E(op, errors.KindNotPermitted, domain.ErrNotPermitted)
+	}
+
+	assignee, err := tu.getMemberByEmail(ctx, email)
+	if err != nil {
+		return nil, errors.E(op, errors.UserEmail(email), err)
+	}
+
+	err = tu.taskRepo.UpdateAssignee(ctx, task.ID, assignee.ID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	task.AssigneeID = assignee.ID
+	task.Version++
+
+	// There's no need to notify the owner who assigns the task to themselves.
+	if assignee.ID == userID {
+		return task, nil
+	}
+
+	log := logger.FromContext(ctx, tu.logger)
+
+	const sendOp errors.Op = "taskUsecase.Assign.sendNotification"
+
+	tu.pool.Schedule(tracing.Job(ctx, sendOp, func(ctx context.Context) {
+		data := map[string]interface{}{
+			"taskID":    task.ID,
+			"taskTitle": task.Title,
+		}
+
+		err := tu.mailer.Send(ctx, assignee.Email, "task_assigned.tmpl", data)
+		if err != nil {
+			log.PrintError(
+				errors.E(
+					sendOp,
+					errors.UserEmail(assignee.Email),
+					errors.KindInternal,
+					errors.Msg("failed to send task assignment email"),
+					err,
+				),
+				nil,
+			)
+			return
+		}
+	}))
+
+	return task, nil
+}
+
+// Unassign removes the assignee of the task. The owner can unassign anyone,
+// the assignee can unassign themselves.
+func (tu *taskUsecase) Unassign(ctx context.Context, userID int64, taskID int64) (*domain.Task, error) {
+	const op errors.Op = "taskUsecase.Unassign"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	task, err := tu.taskRepo.GetByID(ctx, userID, taskID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	if !task.IsOwner() && task.AssigneeID != userID {
+		return nil, errors.E(op, errors.KindNotPermitted, domain.ErrNotPermitted)
+	}
+
+	err = tu.taskRepo.UpdateAssignee(ctx, task.ID, 0)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	task.AssigneeID = 0
+	task.Version++
+
+	return task, nil
+}
+
+// Share shares the task with the user who owns the given email address and sends
+// an invitation email to that user. Only the owner of the task is permitted to share it.
+// If there's no user with given email, or the user isn't a member of the workspace
+// of the task, the error with kind errors.KindRecordNotFound is returned, if the owner tries to share the task with their own email address, the
+// error with kind errors.KindFailedValidation is returned.
+func (tu *taskUsecase) Share(ctx context.Context, userID int64, taskID int64, email, role string) (*domain.TaskShare, error) {
+	const op errors.Op = "taskUsecase.Share"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	task, err := tu.taskRepo.GetByID(ctx, userID, taskID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	if !task.IsOwner() {
+		return nil, errors.E(op, errors.KindNotPermitted, domain.ErrNotPermitted)
+	}
+
+	invitee, err := tu.getMemberByEmail(ctx, email)
+	if err != nil {
+		return nil, errors.E(op, errors.UserEmail(email), err)
+	}
+
+	if invitee.ID == userID {
+		return nil, errors.E(op, errors.KindFailedValidation, domain.ErrFailedValidation)
+	}
+
+	share := &domain.TaskShare{
+		TaskID: task.ID,
+		UserID: invitee.ID,
+		Email:  invitee.Email,
+		Role:   role,
+	}
+
+	err = tu.taskRepo.InsertShare(ctx, share)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	log := logger.FromContext(ctx, tu.logger)
+
+	const sendOp errors.Op = "taskUsecase.Share.sendInvitation"
+
+	tu.pool.Schedule(tracing.Job(ctx, sendOp, func(ctx context.Context) {
+		data := map[string]interface{}{
+			"taskID":    task.ID,
+			"taskTitle": task.Title,
+			"role":      share.Role,
+		}
+
+		err := tu.mailer.Send(ctx, invitee.Email, "task_shared.tmpl", data)
+		if err != nil {
+			log.PrintError(
+				errors.E(
+					sendOp,
+					errors.UserEmail(invitee.Email),
+					errors.KindInternal,
+					errors.Msg("failed to send task invitation email"),
+					err,
+				),
+				nil,
+			)
+			return
+		}
+	}))
+
+	return share, nil
+}
+
+// GetShares lists the users the task is shared with, every user who can access the
+// task is permitted to see it.
+func (tu *taskUsecase) GetShares(ctx context.Context, userID int64, taskID int64) ([]*domain.TaskShare, error) {
+	const op errors.Op = "taskUsecase.GetShares"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	// Make sure the user can access the task.
+	_, err := tu.taskRepo.GetByID(ctx, userID, taskID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	shares, err := tu.taskRepo.GetShares(ctx, taskID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return shares, nil
+}
+
+// Unshare stops sharing the task with the user with given shareUserID.
+// The owner can remove anyone, other users can only remove themselves.
+func (tu *taskUsecase) Unshare(ctx context.Context, userID int64, taskID int64, shareUserID int64) error {
+	const op errors.Op = "taskUsecase.Unshare"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	task, err := tu.taskRepo.GetByID(ctx, userID, taskID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if !task.IsOwner() && shareUserID != userID {
+		return errors.E(op, errors.KindNotPermitted, domain.ErrNotPermitted)
+	}
+
+	err = tu.taskRepo.DeleteShare(ctx, taskID, shareUserID)
 	if err != nil {
 		return errors.E(op, err)
 	}
 
 	return nil
 }
+
+// getMemberByEmail returns the user who owns the given email address. If ctx carries
+// a workspace, the user must also be a member of it, otherwise the error with kind
+// errors.KindRecordNotFound is returned.
+func (tu *taskUsecase) getMemberByEmail(ctx context.Context, email string) (*domain.User, error) {
+	const op errors.Op = "taskUsecase.getMemberByEmail"
+
+	user, err := tu.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	if ws, ok := domain.WorkspaceFromContext(ctx); ok {
+		_, err := tu.workspaceRepo.GetMember(ctx, ws.ID, user.ID)
+		if err != nil {
+			return nil, errors.E(op, err)
+		}
+	}
+
+	return user, nil
+}
@@ -1,18 +1,39 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
 
+	"github.com/unknowntpo/todos/config"
 	"github.com/unknowntpo/todos/internal/domain"
 	"github.com/unknowntpo/todos/internal/domain/errors"
 	_repoMock "github.com/unknowntpo/todos/internal/domain/mocks"
+	"github.com/unknowntpo/todos/internal/logger/zerolog"
+	"github.com/unknowntpo/todos/internal/mailer"
+	"github.com/unknowntpo/todos/pkg/naivepool"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+// newTestTaskUsecase creates a taskUsecase with given repositories, and a
+// worker pool which is stopped when the test finishes.
+func newTestTaskUsecase(t *testing.T, taskRepo domain.TaskRepository, userRepo domain.UserRepository, workspaceRepo domain.WorkspaceRepository) domain.TaskUsecase {
+	pool := naivepool.New(5, 5, 5)
+	poolCtx, poolCancel := context.WithCancel(context.Background())
+	pool.Start(poolCtx)
+	t.Cleanup(func() {
+		poolCancel()
+		pool.Wait()
+	})
+
+	logger := zerolog.New(new(bytes.Buffer))
+
+	return NewTaskUsecase(taskRepo, userRepo, workspaceRepo, pool, mailer.New(&config.Smtp{}), logger, 3*time.Second)
+}
+
 func TestGetAll(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		// init mock taskrepo
@@ -60,7 +81,7 @@ func TestGetAll(t *testing.T) {
 		repo.On("GetAll", mock.Anything, fakeUserID, input.Title, input.Filters).
 			Return(wantTasks, wantMeta, nil)
 
-		taskUserUsecase := NewTaskUsecase(repo, 3*time.Second)
+		taskUserUsecase := newTestTaskUsecase(t, repo, new(_repoMock.UserRepository), new(_repoMock.WorkspaceRepository))
 
 		ctx := context.TODO()
 		gotTasks, gotMeta, err := taskUserUsecase.GetAll(ctx, fakeUserID, input.Title, input.Filters)
@@ -104,7 +125,7 @@ func TestGetAll(t *testing.T) {
 		repo.On("GetAll", mock.Anything, fakeUserID, input.Title, input.Filters).
 			Return(wantTasks, wantMeta, wantErr)
 
-		taskUsecase := NewTaskUsecase(repo, 3*time.Second)
+		taskUsecase := newTestTaskUsecase(t, repo, new(_repoMock.UserRepository), new(_repoMock.WorkspaceRepository))
 
 		ctx := context.TODO()
 		gotTasks, gotMeta, err := taskUsecase.GetAll(ctx, fakeUserID, input.Title, input.Filters)
@@ -128,11 +149,215 @@ func TestInsert(t *testing.T) {
 }
 
 func TestUpdate(t *testing.T) {
-	t.Skip("TODO: finish the implementation")
-	t.Fail()
+	t.Run("Success as editor", func(t *testing.T) {
+		repo := new(_repoMock.TaskRepository)
+
+		task := &domain.Task{ID: 1, UserID: 1, Title: "Do housework", Content: "It's boring!", Version: 1}
+		current := &domain.Task{ID: 1, UserID: 1, Role: domain.RoleEditor}
+
+		repo.On("GetByID", mock.Anything, int64(2), int64(1)).Return(current, nil)
+		repo.On("Update", mock.Anything, task).Return(nil)
+
+		taskUsecase := newTestTaskUsecase(t, repo, new(_repoMock.UserRepository), new(_repoMock.WorkspaceRepository))
+
+		err := taskUsecase.Update(context.TODO(), 2, task)
+		assert.NoError(t, err)
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Fail as viewer", func(t *testing.T) {
+		repo := new(_repoMock.TaskRepository)
+
+		task := &domain.Task{ID: 1, UserID: 1, Title: "Do housework", Content: "It's boring!", Version: 1}
+		current := &domain.Task{ID: 1, UserID: 1, Role: domain.RoleViewer}
+
+		repo.On("GetByID", mock.Anything, int64(2), int64(1)).Return(current, nil)
+
+		taskUsecase := newTestTaskUsecase(t, repo, new(_repoMock.UserRepository), new(_repoMock.WorkspaceRepository))
+
+		err := taskUsecase.Update(context.TODO(), 2, task)
+		assert.True(t, errors.KindIs(err, errors.KindNotPermitted), "kind of error should be KindNotPermitted")
+
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestUpdateAsAssignee(t *testing.T) {
+	t.Run("Success on marking the task as done", func(t *testing.T) {
+		repo := new(_repoMock.TaskRepository)
+
+		current := &domain.Task{ID: 1, UserID: 1, AssigneeID: 2, Title: "Do housework", Content: "It's boring!", Version: 1, Role: domain.RoleAssignee}
+		task := &domain.Task{ID: 1, UserID: 1, AssigneeID: 2, Title: "Do housework", Content: "It's boring!", Done: true, Version: 1}
+
+		repo.On("GetByID", mock.Anything, int64(2), int64(1)).Return(current, nil)
+		repo.On("Update", mock.Anything, task).Return(nil)
+
+		taskUsecase := newTestTaskUsecase(t, repo, new(_repoMock.UserRepository), new(_repoMock.WorkspaceRepository))
+
+		err := taskUsecase.Update(context.TODO(), 2, task)
+		assert.NoError(t, err)
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Fail on changing the title", func(t *testing.T) {
+		repo := new(_repoMock.TaskRepository)
+
+		current := &domain.Task{ID: 1, UserID: 1, AssigneeID: 2, Title: "Do housework", Content: "It's boring!", Version: 1, Role: domain.RoleAssignee}
+		task := &domain.Task{ID: 1, UserID: 1, AssigneeID: 2, Title: "Do nothing", Content: "It's boring!", Version: 1}
+
+		repo.On("GetByID", mock.Anything, int64(2), int64(1)).Return(current, nil)
+
+		taskUsecase := newTestTaskUsecase(t, repo, new(_repoMock.UserRepository), new(_repoMock.WorkspaceRepository))
+
+		err := taskUsecase.Update(context.TODO(), 2, task)
+		assert.True(t, errors.KindIs(err, errors.KindNotPermitted), "kind of error should be KindNotPermitted")
+
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAssign(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		repo := new(_repoMock.TaskRepository)
+		userRepo := new(_repoMock.UserRepository)
+
+		assignee := &domain.User{ID: 2, Email: "bob@example.com"}
+
+		repo.On("GetByID", mock.Anything, int64(1), int64(1)).Return(&domain.Task{ID: 1, UserID: 1, Title: "Do housework", Version: 1, Role: domain.RoleOwner}, nil)
+		userRepo.On("GetByEmail", mock.Anything, assignee.Email).Return(assignee, nil)
+		repo.On("UpdateAssignee", mock.Anything, int64(1), assignee.ID).Return(nil)
+
+		taskUsecase := newTestTaskUsecase(t, repo, userRepo, new(_repoMock.WorkspaceRepository))
+
+		task, err := taskUsecase.Assign(context.TODO(), 1, 1, assignee.Email)
+		assert.NoError(t, err)
+		assert.Equal(t, assignee.ID, task.AssigneeID)
+		assert.Equal(t, int32(2), task.Version)
+
+		repo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fail when user is not the owner", func(t *testing.T) {
+		repo := new(_repoMock.TaskRepository)
+		userRepo := new(_repoMock.UserRepository)
+
+		repo.On("GetByID", mock.Anything, int64(2), int64(1)).Return(&domain.Task{ID: 1, UserID: 1, Role: domain.RoleEditor}, nil)
+
+		taskUsecase := newTestTaskUsecase(t, repo, userRepo, new(_repoMock.WorkspaceRepository))
+
+		_, err := taskUsecase.Assign(context.TODO(), 2, 1, "bob@example.com")
+		assert.True(t, errors.KindIs(err, errors.KindNotPermitted), "kind of error should be KindNotPermitted")
+
+		repo.AssertNotCalled(t, "UpdateAssignee", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fail when assignee is not a member of the workspace", func(t *testing.T) {
+		repo := new(_repoMock.TaskRepository)
+		userRepo := new(_repoMock.UserRepository)
+		workspaceRepo := new(_repoMock.WorkspaceRepository)
+
+		ws := &domain.Workspace{ID: 1, Slug: "acme", Role: domain.WorkspaceRoleMember}
+		assignee := &domain.User{ID: 2, Email: "bob@example.com"}
+
+		repo.On("GetByID", mock.Anything, int64(1), int64(1)).Return(&domain.Task{ID: 1, UserID: 1, WorkspaceID: ws.ID, Role: domain.RoleOwner}, nil)
+		userRepo.On("GetByEmail", mock.Anything, assignee.Email).Return(assignee, nil)
+		workspaceRepo.On("GetMember", mock.Anything, ws.ID, assignee.ID).Return(nil, errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound))
+
+		taskUsecase := newTestTaskUsecase(t, repo, userRepo, workspaceRepo)
+
+		ctx := domain.ContextWithWorkspace(context.TODO(), ws)
+		_, err := taskUsecase.Assign(ctx, 1, 1, assignee.Email)
+		assert.True(t, errors.KindIs(err, errors.KindRecordNotFound), "kind of error should be KindRecordNotFound")
+
+		repo.AssertNotCalled(t, "UpdateAssignee", mock.Anything, mock.Anything, mock.Anything)
+		workspaceRepo.AssertExpectations(t)
+	})
 }
 
 func TestDelete(t *testing.T) {
-	t.Skip("TODO: finish the implementation")
-	t.Fail()
+	t.Run("Success as owner", func(t *testing.T) {
+		repo := new(_repoMock.TaskRepository)
+
+		repo.On("GetByID", mock.Anything, int64(1), int64(1)).Return(&domain.Task{ID: 1, UserID: 1, Role: domain.RoleOwner}, nil)
+		repo.On("Delete", mock.Anything, int64(1), int64(1)).Return(nil)
+
+		taskUsecase := newTestTaskUsecase(t, repo, new(_repoMock.UserRepository), new(_repoMock.WorkspaceRepository))
+
+		err := taskUsecase.Delete(context.TODO(), 1, 1)
+		assert.NoError(t, err)
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Fail as editor", func(t *testing.T) {
+		repo := new(_repoMock.TaskRepository)
+
+		repo.On("GetByID", mock.Anything, int64(2), int64(1)).Return(&domain.Task{ID: 1, UserID: 1, Role: domain.RoleEditor}, nil)
+
+		taskUsecase := newTestTaskUsecase(t, repo, new(_repoMock.UserRepository), new(_repoMock.WorkspaceRepository))
+
+		err := taskUsecase.Delete(context.TODO(), 2, 1)
+		assert.True(t, errors.KindIs(err, errors.KindNotPermitted), "kind of error should be KindNotPermitted")
+
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestShare(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		repo := new(_repoMock.TaskRepository)
+		userRepo := new(_repoMock.UserRepository)
+
+		invitee := &domain.User{ID: 2, Email: "bob@example.com"}
+
+		repo.On("GetByID", mock.Anything, int64(1), int64(1)).Return(&domain.Task{ID: 1, UserID: 1, Title: "Do housework", Role: domain.RoleOwner}, nil)
+		userRepo.On("GetByEmail", mock.Anything, invitee.Email).Return(invitee, nil)
+		repo.On("InsertShare", mock.Anything, mock.MatchedBy(func(share *domain.TaskShare) bool {
+			return share.TaskID == 1 && share.UserID == invitee.ID && share.Role == domain.RoleViewer
+		})).Return(nil)
+
+		taskUsecase := newTestTaskUsecase(t, repo, userRepo, new(_repoMock.WorkspaceRepository))
+
+		share, err := taskUsecase.Share(context.TODO(), 1, 1, invitee.Email, domain.RoleViewer)
+		assert.NoError(t, err)
+		assert.Equal(t, invitee.Email, share.Email)
+
+		repo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fail when user is not the owner", func(t *testing.T) {
+		repo := new(_repoMock.TaskRepository)
+		userRepo := new(_repoMock.UserRepository)
+
+		repo.On("GetByID", mock.Anything, int64(2), int64(1)).Return(&domain.Task{ID: 1, UserID: 1, Role: domain.RoleEditor}, nil)
+
+		taskUsecase := newTestTaskUsecase(t, repo, userRepo, new(_repoMock.WorkspaceRepository))
+
+		_, err := taskUsecase.Share(context.TODO(), 2, 1, "carol@example.com", domain.RoleEditor)
+		assert.True(t, errors.KindIs(err, errors.KindNotPermitted), "kind of error should be KindNotPermitted")
+
+		userRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Fail when sharing with yourself", func(t *testing.T) {
+		repo := new(_repoMock.TaskRepository)
+		userRepo := new(_repoMock.UserRepository)
+
+		repo.On("GetByID", mock.Anything, int64(1), int64(1)).Return(&domain.Task{ID: 1, UserID: 1, Role: domain.RoleOwner}, nil)
+		userRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(&domain.User{ID: 1, Email: "alice@example.com"}, nil)
+
+		taskUsecase := newTestTaskUsecase(t, repo, userRepo, new(_repoMock.WorkspaceRepository))
+
+		_, err := taskUsecase.Share(context.TODO(), 1, 1, "alice@example.com", domain.RoleEditor)
+		assert.True(t, errors.KindIs(err, errors.KindFailedValidation), "kind of error should be KindFailedValidation")
+
+		repo.AssertNotCalled(t, "InsertShare", mock.Anything, mock.Anything)
+	})
 }
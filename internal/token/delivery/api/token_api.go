@@ -1,10 +1,15 @@
 package api
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/unknowntpo/todos/internal/domain"
 	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/helpers"
+	"github.com/unknowntpo/todos/internal/middleware"
 	"github.com/unknowntpo/todos/internal/reactor"
 	"github.com/unknowntpo/todos/pkg/validator"
 
@@ -12,30 +17,120 @@ import (
 )
 
 type tokenAPI struct {
-	UU domain.UserUsecase
-	rc *reactor.Reactor
+	UU  domain.UserUsecase
+	TU  domain.TokenUsecase
+	mid *middleware.Middleware
+	rc  *reactor.Reactor
 }
 
+// AuthenticationRequestBody is the login request, browser clients can set UseCookies to get
+// the tokens in HttpOnly cookies rather than the response body, if the session mode is enabled.
 type AuthenticationRequestBody struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	UseCookies bool   `json:"use_cookies"`
 }
 
 type AuthenticationResponse struct {
-	Token *domain.Token `json:"token"`
+	Token        *domain.Token `json:"token"`
+	RefreshToken *domain.Token `json:"refresh_token"`
 }
 
-func NewTokenAPI(router *httprouter.Router, uu domain.UserUsecase, rc *reactor.Reactor) {
-	api := &tokenAPI{UU: uu, rc: rc}
+// SessionResponse is sent instead of AuthenticationResponse when the tokens are set in cookies.
+// CSRFToken has to be sent in the X-CSRF-Token header of the state-changing requests.
+type SessionResponse struct {
+	Expiry        time.Time `json:"expiry"`
+	RefreshExpiry time.Time `json:"refresh_expiry"`
+	CSRFToken     string    `json:"csrf_token"`
+}
+
+type MFAChallengeResponse struct {
+	MFAToken *domain.Token `json:"mfa_token"`
+}
+
+type MFARequestBody struct {
+	MFAToken   string `json:"mfa_token"`
+	Code       string `json:"code"`
+	UseCookies bool   `json:"use_cookies"`
+}
+
+type RefreshRequestBody struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ActivationRequestBody struct {
+	Email string `json:"email"`
+}
+
+type ActivationResponse struct {
+	Message string `json:"message"`
+}
+
+type PasswordResetRequestBody struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetResponse struct {
+	Message string `json:"message"`
+}
+
+type SessionsResponse struct {
+	Sessions []*domain.Session `json:"sessions"`
+}
+
+type SessionMessageResponse struct {
+	Message string `json:"message"`
+}
+
+type PersonalTokenRequestBody struct {
+	Name   string     `json:"name"`
+	Scopes []string   `json:"scopes"`
+	Expiry *time.Time `json:"expiry"`
+}
+
+type PersonalTokenResponse struct {
+	PersonalToken *domain.PersonalAccessToken `json:"personal_token"`
+}
+
+type PersonalTokensResponse struct {
+	PersonalTokens []*domain.PersonalAccessToken `json:"personal_tokens"`
+}
+
+type PersonalTokenMessageResponse struct {
+	Message string `json:"message"`
+}
+
+func NewTokenAPI(router *httprouter.Router, uu domain.UserUsecase, tu domain.TokenUsecase, mid *middleware.Middleware, rc *reactor.Reactor) {
+	api := &tokenAPI{UU: uu, TU: tu, mid: mid, rc: rc}
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/authentication", api.CreateAuthenticationToken)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/mfa", api.CompleteMFALogin)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/refresh", api.RefreshAuthenticationToken)
+	router.Handler(http.MethodDelete, "/v1/tokens/authentication", mid.RequireAuthenticatedUser(http.HandlerFunc(api.DeleteAuthenticationToken)))
+	router.Handler(http.MethodGet, "/v1/tokens/sessions", mid.RequireAuthenticatedUser(http.HandlerFunc(api.GetSessions)))
+	router.Handler(http.MethodDelete, "/v1/tokens/sessions/:id", mid.RequireAuthenticatedUser(http.HandlerFunc(api.DeleteSession)))
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/activation", api.CreateActivationToken)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/password-reset", api.CreatePasswordResetToken)
+	router.Handler(http.MethodPost, "/v1/tokens/personal", mid.RequireActivatedUser(http.HandlerFunc(api.CreatePersonalToken)))
+	router.Handler(http.MethodGet, "/v1/tokens/personal", mid.RequireActivatedUser(http.HandlerFunc(api.GetPersonalTokens)))
+	router.Handler(http.MethodDelete, "/v1/tokens/personal/:id", mid.RequireActivatedUser(http.HandlerFunc(api.DeletePersonalToken)))
+	router.HandlerFunc(http.MethodGet, "/.well-known/jwks.json", api.GetJWKS)
 }
 
 // @Summary Create authentication token for user.
-// @Description None.
+// @Description The authentication token expires in 15 minutes, use the refresh token to get a new one.
+// @Description If the user has enabled two-factor authentication, an MFA token is returned instead,
+// @Description which has to be exchanged with the code at /v1/tokens/mfa within 5 minutes.
 // @Accept  json
 // @Produce  json
 // @Param authentication_request_body body AuthenticationRequestBody true "authentication request body"
-// @Success 200 {object} AuthenticationResponse
+// @Description With use_cookies, the tokens are set in HttpOnly cookies instead, and the CSRF token
+// @Description in the response has to be sent in the X-CSRF-Token header of the state-changing requests.
+// @Success 201 {object} AuthenticationResponse
+// @Success 201 {object} SessionResponse "use_cookies is set"
+// @Success 202 {object} MFAChallengeResponse "the user has enabled two-factor authentication"
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse "the user account hasn't been activated"
+// @Failure 429 {object} reactor.ErrorResponse "too many failed logins, retry after the seconds in the Retry-After header"
 // @Router /v1/tokens/authentication [post]
 func (t *tokenAPI) CreateAuthenticationToken(w http.ResponseWriter, r *http.Request) {
 	const op errors.Op = "tokenAPI.CreateAuthenticationToken"
@@ -54,6 +149,7 @@ func (t *tokenAPI) CreateAuthenticationToken(w http.ResponseWriter, r *http.Requ
 
 	domain.ValidateEmail(v, input.Email)
 	domain.ValidatePasswordPlaintext(v, input.Password)
+	t.validateUseCookies(v, input.UseCookies)
 
 	if !v.Valid() {
 		t.rc.FailedValidationResponse(w, r, v.Err())
@@ -61,23 +157,520 @@ func (t *tokenAPI) CreateAuthenticationToken(w http.ResponseWriter, r *http.Requ
 	}
 
 	ctx := r.Context()
-	token, err := t.UU.Login(ctx, input.Email, input.Password)
+	token, refreshToken, err := t.UU.Login(ctx, input.Email, input.Password)
 	if err != nil {
 		switch {
 		case errors.KindIs(err, errors.KindInvalidCredentials):
 			t.rc.InvalidCredentialsResponse(w, r)
 			return
+		case errors.KindIs(err, errors.KindInactiveAccount):
+			t.rc.InactiveAccountResponse(w, r)
+			return
+		case errors.KindIs(err, errors.KindRateLimitExceeded):
+			t.retryAfterResponse(w, r, err)
+			return
 		default:
 			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
 			return
 		}
 	}
 
+	// The password is correct, but the second factor is still required.
+	if token.Scope == domain.ScopeMFAChallenge {
+		err = t.rc.WriteJSON(w, http.StatusAccepted, &MFAChallengeResponse{MFAToken: token})
+		if err != nil {
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
 	// Encode the token to JSON and send it in the response along with a 201 Created
 	// status code.
-	err = t.rc.WriteJSON(w, http.StatusCreated, &AuthenticationResponse{Token: token})
+	err = t.authenticationResponse(w, token, refreshToken, input.UseCookies)
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		return
+	}
+}
+
+// @Summary Exchange the MFA token and the second factor for authentication token.
+// @Description The code is either the 6-digit code from the authenticator app or an unused recovery code.
+// @Description The MFA token can only be exchanged once.
+// @Accept  json
+// @Produce  json
+// @Param mfa_request_body body MFARequestBody true "MFA request body"
+// @Success 201 {object} AuthenticationResponse
+// @Success 201 {object} SessionResponse "use_cookies is set"
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 429 {object} reactor.ErrorResponse "too many failed logins, retry after the seconds in the Retry-After header"
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tokens/mfa [post]
+func (t *tokenAPI) CompleteMFALogin(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "tokenAPI.CompleteMFALogin"
+
+	var input MFARequestBody
+
+	err := t.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		t.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	v.Check(input.MFAToken != "", "mfa_token", "must be provided")
+	domain.ValidateMFACode(v, input.Code)
+	t.validateUseCookies(v, input.UseCookies)
+
+	if !v.Valid() {
+		t.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+	token, refreshToken, err := t.UU.CompleteLogin(ctx, input.MFAToken, input.Code)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindInvalidCredentials):
+			t.rc.InvalidCredentialsResponse(w, r)
+		case errors.KindIs(err, errors.KindRateLimitExceeded):
+			t.retryAfterResponse(w, r, err)
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = t.authenticationResponse(w, token, refreshToken, input.UseCookies)
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// validateUseCookies checks that the session mode is enabled if the client asks for cookies.
+func (t *tokenAPI) validateUseCookies(v *validator.Validator, useCookies bool) {
+	if useCookies {
+		v.Check(t.mid.SessionEnabled(), "use_cookies", "session cookies are not enabled on this server")
+	}
+}
+
+// authenticationResponse sends the pair of authentication and refresh tokens with a 201 Created
+// status code, either in the response body or in the session cookies.
+func (t *tokenAPI) authenticationResponse(w http.ResponseWriter, access, refresh *domain.Token, useCookies bool) error {
+	if !useCookies {
+		return t.rc.WriteJSON(w, http.StatusCreated, &AuthenticationResponse{Token: access, RefreshToken: refresh})
+	}
+
+	csrfToken, err := t.mid.SetSessionCookies(w, access, refresh)
+	if err != nil {
+		return err
+	}
+
+	return t.rc.WriteJSON(w, http.StatusCreated, &SessionResponse{
+		Expiry:        access.Expiry,
+		RefreshExpiry: refresh.Expiry,
+		CSRFToken:     csrfToken,
+	})
+}
+
+// retryAfterResponse sends the rate limit exceeded response, along with the Retry-After header
+// if err tells how long the client has to wait.
+func (t *tokenAPI) retryAfterResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var retry *domain.RetryAfterError
+	if errors.As(err, &retry) {
+		seconds := int64(math.Ceil(retry.After.Seconds()))
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+
+	t.rc.RateLimitExceededResponse(w, r)
+}
+
+// @Summary Email a new activation token to unactivated user.
+// @Description The response is the same whether or not the email address belongs to an unactivated user.
+// @Description A token is sent to the same email address at most once every 5 minutes.
+// @Accept  json
+// @Produce  json
+// @Param activation_request_body body ActivationRequestBody true "activation request body"
+// @Success 202 {object} ActivationResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 429 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tokens/activation [post]
+func (t *tokenAPI) CreateActivationToken(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "tokenAPI.CreateActivationToken"
+
+	var input ActivationRequestBody
+
+	err := t.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		t.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if domain.ValidateEmail(v, input.Email); !v.Valid() {
+		t.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+	err = t.UU.ResendActivation(ctx, input.Email)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRateLimitExceeded):
+			t.rc.RateLimitExceededResponse(w, r)
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	msg := "if the email address belongs to an unactivated account, an email will be sent to it containing activation instructions"
+	err = t.rc.WriteJSON(w, http.StatusAccepted, &ActivationResponse{Message: msg})
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// @Summary Email a password reset token to user.
+// @Description The response is the same whether or not the email address belongs to a user.
+// @Accept  json
+// @Produce  json
+// @Param password_reset_request_body body PasswordResetRequestBody true "password reset request body"
+// @Success 202 {object} PasswordResetResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tokens/password-reset [post]
+func (t *tokenAPI) CreatePasswordResetToken(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "tokenAPI.CreatePasswordResetToken"
+
+	var input PasswordResetRequestBody
+
+	err := t.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		t.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if domain.ValidateEmail(v, input.Email); !v.Valid() {
+		t.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+	err = t.UU.RequestPasswordReset(ctx, input.Email)
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		return
+	}
+
+	msg := "if the email address belongs to an account, an email will be sent to it containing password reset instructions"
+	err = t.rc.WriteJSON(w, http.StatusAccepted, &PasswordResetResponse{Message: msg})
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// @Summary Exchange refresh token for new authentication token and refresh token.
+// @Description Every refresh token can only be exchanged once. Presenting a refresh token which has been
+// @Description exchanged before revokes every token descended from the same login.
+// @Description Browser clients logged in with use_cookies leave refresh_token empty, the one in the cookie is
+// @Description exchanged instead, which requires the CSRF token in the X-CSRF-Token header.
+// @Accept  json
+// @Produce  json
+// @Param refresh_request_body body RefreshRequestBody true "refresh request body"
+// @Success 201 {object} AuthenticationResponse
+// @Success 201 {object} SessionResponse "the refresh token is in the cookie"
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tokens/refresh [post]
+func (t *tokenAPI) RefreshAuthenticationToken(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "tokenAPI.RefreshAuthenticationToken"
+
+	var input RefreshRequestBody
+
+	err := t.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		t.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	// The refresh token in the cookie is sent along with the requests other sites make
+	// as well, so it takes the CSRF token to exchange it.
+	useCookies := false
+	if cookie, err := r.Cookie(middleware.RefreshCookie); err == nil && input.RefreshToken == "" {
+		if !middleware.ValidCSRF(r) {
+			t.rc.InvalidCSRFTokenResponse(w, r)
+			return
+		}
+
+		input.RefreshToken = cookie.Value
+		useCookies = true
+	}
+
+	v := validator.New()
+
+	if domain.ValidateTokenPlaintext(v, input.RefreshToken); !v.Valid() {
+		t.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+	token, refreshToken, err := t.TU.Refresh(ctx, input.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindInvalidCredentials):
+			if useCookies {
+				middleware.ClearSessionCookies(w)
+			}
+			t.rc.InvalidAuthenticationTokenResponse(w, r)
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = t.authenticationResponse(w, token, refreshToken, useCookies)
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// @Summary Log out by revoking the authentication token of the request.
+// @Description The refresh tokens descended from the same login are revoked as well, and the session cookies are dropped.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success 200 {object} SessionMessageResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tokens/authentication [delete]
+func (t *tokenAPI) DeleteAuthenticationToken(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "tokenAPI.DeleteAuthenticationToken"
+
+	ctx := r.Context()
+	err := t.TU.Delete(ctx, domain.ScopeAuthentication, helpers.ContextGetToken(r))
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		return
+	}
+
+	if _, err := r.Cookie(middleware.SessionCookie); err == nil {
+		middleware.ClearSessionCookies(w)
+	}
+
+	err = t.rc.WriteJSON(w, http.StatusOK, &SessionMessageResponse{Message: "you have been logged out"})
 	if err != nil {
 		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// @Summary List the sessions of the user.
+// @Description Every unexpired authentication token of the user is a session, the one used by the request is marked as current.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success 200 {object} SessionsResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tokens/sessions [get]
+func (t *tokenAPI) GetSessions(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "tokenAPI.GetSessions"
+
+	user := helpers.ContextGetUser(r)
+
+	ctx := r.Context()
+	sessions, err := t.TU.GetSessions(ctx, user.ID, helpers.ContextGetToken(r))
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		return
+	}
+
+	err = t.rc.WriteJSON(w, http.StatusOK, &SessionsResponse{Sessions: sessions})
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// @Summary Revoke a session of the user.
+// @Description None.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param sessionID path int true "Session ID"
+// @Success 200 {object} SessionMessageResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tokens/sessions/{sessionID} [delete]
+func (t *tokenAPI) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "tokenAPI.DeleteSession"
+
+	sessionID, err := t.rc.ReadIDParam(r)
+	if err != nil {
+		t.rc.NotFoundResponse(w, r)
 		return
 	}
+
+	user := helpers.ContextGetUser(r)
+
+	ctx := r.Context()
+	err = t.TU.DeleteSession(ctx, user.ID, sessionID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			t.rc.NotFoundResponse(w, r)
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = t.rc.WriteJSON(w, http.StatusOK, &SessionMessageResponse{Message: "session successfully revoked"})
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// @Summary List the public keys which verify the signed authentication tokens.
+// @Description The key set is empty unless the server issues signed tokens with EdDSA. The kid header of a token refers to the key which signed it.
+// @Produce  json
+// @Success 200 {object} jwt.JWKS
+// @Router /.well-known/jwks.json [get]
+func (t *tokenAPI) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "tokenAPI.GetJWKS"
+
+	// Let verifiers cache the keys for a while, rotated keys are kept long enough anyway.
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	err := t.rc.WriteJSON(w, http.StatusOK, t.TU.JWKS())
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// @Summary Create a personal access token.
+// @Description The token is only good for the routes requiring one of its scopes, which are permission codes, e.g. tasks:read. It never expires unless expiry is given. The plaintext of the token is only shown in this response.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param personal_token_request_body body PersonalTokenRequestBody true "personal access token request body"
+// @Success 201 {object} PersonalTokenResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tokens/personal [post]
+func (t *tokenAPI) CreatePersonalToken(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "tokenAPI.CreatePersonalToken"
+
+	var input PersonalTokenRequestBody
+
+	err := t.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		t.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := helpers.ContextGetUser(r)
+
+	token := &domain.PersonalAccessToken{
+		Name:   input.Name,
+		Scopes: input.Scopes,
+		Expiry: input.Expiry,
+		UserID: user.ID,
+	}
+
+	v := validator.New()
+
+	if domain.ValidatePersonalAccessToken(v, token); !v.Valid() {
+		t.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+	err = t.TU.CreatePersonalToken(ctx, token)
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		return
+	}
+
+	err = t.rc.WriteJSON(w, http.StatusCreated, &PersonalTokenResponse{PersonalToken: token})
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// @Summary List the personal access tokens of the user.
+// @Description Expired tokens are left out.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success 200 {object} PersonalTokensResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tokens/personal [get]
+func (t *tokenAPI) GetPersonalTokens(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "tokenAPI.GetPersonalTokens"
+
+	user := helpers.ContextGetUser(r)
+
+	ctx := r.Context()
+	tokens, err := t.TU.GetPersonalTokens(ctx, user.ID)
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		return
+	}
+
+	err = t.rc.WriteJSON(w, http.StatusOK, &PersonalTokensResponse{PersonalTokens: tokens})
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// @Summary Revoke a personal access token of the user.
+// @Description None.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param tokenID path int true "Personal access token ID"
+// @Success 200 {object} PersonalTokenMessageResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/tokens/personal/{tokenID} [delete]
+func (t *tokenAPI) DeletePersonalToken(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "tokenAPI.DeletePersonalToken"
+
+	tokenID, err := t.rc.ReadIDParam(r)
+	if err != nil {
+		t.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	user := helpers.ContextGetUser(r)
+
+	ctx := r.Context()
+	err = t.TU.DeletePersonalToken(ctx, user.ID, tokenID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			t.rc.NotFoundResponse(w, r)
+		default:
+			t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = t.rc.WriteJSON(w, http.StatusOK, &PersonalTokenMessageResponse{Message: "personal access token successfully revoked"})
+	if err != nil {
+		t.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
 }
@@ -7,9 +7,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/unknowntpo/todos/config"
 	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
 	"github.com/unknowntpo/todos/internal/domain/mocks"
 	"github.com/unknowntpo/todos/internal/logger/zerolog"
+	"github.com/unknowntpo/todos/internal/middleware"
 	"github.com/unknowntpo/todos/internal/reactor"
 	"github.com/unknowntpo/todos/internal/testutil"
 
@@ -33,7 +36,12 @@ func TestCreateAuthenticationToken(t *testing.T) {
 			t.Fatalf("failed to generate token: %v", err)
 		}
 
-		userUsecase.On("Login", mock.Anything, fakeUser.Email, *fakeUser.Password.Plaintext).Return(wantToken, nil)
+		wantRefreshToken, err := domain.GenerateToken(1, 7*24*time.Hour, domain.ScopeRefresh)
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+
+		userUsecase.On("Login", mock.Anything, fakeUser.Email, *fakeUser.Password.Plaintext).Return(wantToken, wantRefreshToken, nil)
 
 		rc := reactor.NewReactor(logger)
 
@@ -48,24 +56,298 @@ func TestCreateAuthenticationToken(t *testing.T) {
 		// deps:
 		// tu, uu, rc
 		router := httprouter.New()
-		NewTokenAPI(router, userUsecase, rc)
+		NewTokenAPI(router, userUsecase, new(mocks.TokenUsecase), nil, rc)
 
 		router.ServeHTTP(rr, r)
 		t.Log(logBuf.String())
 		assert.Equal(t, "", logBuf.String())
 		assert.Contains(t, rr.Body.String(), wantToken.Plaintext)
+		assert.Contains(t, rr.Body.String(), wantRefreshToken.Plaintext)
 		// TODO: How to specify the exact format we want ?
 		assert.Contains(t, rr.Body.String(), "expiry")
 	})
+	t.Run("MFA required", func(t *testing.T) {
+		logBuf := new(bytes.Buffer)
+		rc := reactor.NewReactor(zerolog.New(logBuf))
+
+		challenge, err := domain.GenerateToken(1, 5*time.Minute, domain.ScopeMFAChallenge)
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("Login", mock.Anything, "alice@example.com", "pa55word").Return(challenge, nil, nil)
+
+		reqBody := bytes.NewBufferString(`{"email": "alice@example.com", "password": "pa55word"}`)
+		r, err := http.NewRequest(http.MethodPost, "/v1/tokens/authentication", reqBody)
+		if err != nil {
+			t.Fatalf("failed to create new request: %v", err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := httprouter.New()
+		NewTokenAPI(router, userUsecase, new(mocks.TokenUsecase), nil, rc)
+
+		router.ServeHTTP(rr, r)
+		assert.Equal(t, "", logBuf.String())
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"mfa_token"`)
+		assert.Contains(t, rr.Body.String(), challenge.Plaintext)
+		assert.NotContains(t, rr.Body.String(), "refresh_token")
+	})
 	t.Run("Fail on invalid credentials", func(t *testing.T) {
 		// deps:
 		// tu, uu, rc
 
 	})
+	t.Run("Fail on too many failed logins", func(t *testing.T) {
+		rc := reactor.NewReactor(zerolog.New(new(bytes.Buffer)))
+
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("Login", mock.Anything, "alice@example.com", "pa55word").
+			Return(nil, nil, errors.E(errors.Op("userUsecase.Login"), errors.KindRateLimitExceeded, &domain.RetryAfterError{After: 1500 * time.Millisecond}))
+
+		reqBody := bytes.NewBufferString(`{"email": "alice@example.com", "password": "pa55word"}`)
+		r, err := http.NewRequest(http.MethodPost, "/v1/tokens/authentication", reqBody)
+		if err != nil {
+			t.Fatalf("failed to create new request: %v", err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := httprouter.New()
+		NewTokenAPI(router, userUsecase, new(mocks.TokenUsecase), nil, rc)
+
+		router.ServeHTTP(rr, r)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("Retry-After"), "Retry-After should be rounded up to seconds")
+	})
+	t.Run("Session cookies", func(t *testing.T) {
+		logBuf := new(bytes.Buffer)
+		rc := reactor.NewReactor(zerolog.New(logBuf))
 
+		wantToken, err := domain.GenerateToken(1, 30*time.Minute, domain.ScopeAuthentication)
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+
+		wantRefreshToken, err := domain.GenerateToken(1, 7*24*time.Hour, domain.ScopeRefresh)
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("Login", mock.Anything, "alice@example.com", "pa55word").Return(wantToken, wantRefreshToken, nil)
+
+		reqBody := bytes.NewBufferString(`{"email": "alice@example.com", "password": "pa55word", "use_cookies": true}`)
+		r, err := http.NewRequest(http.MethodPost, "/v1/tokens/authentication", reqBody)
+		if err != nil {
+			t.Fatalf("failed to create new request: %v", err)
+		}
+
+		cfg := &config.Config{Session: config.Session{Enabled: true, Secure: true}}
+		mid := middleware.New(cfg, userUsecase, new(mocks.TokenUsecase), nil, nil, nil, rc)
+
+		rr := httptest.NewRecorder()
+		router := httprouter.New()
+		NewTokenAPI(router, userUsecase, new(mocks.TokenUsecase), mid, rc)
+
+		router.ServeHTTP(rr, r)
+		assert.Equal(t, "", logBuf.String())
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		cookies := make(map[string]*http.Cookie)
+		for _, c := range rr.Result().Cookies() {
+			cookies[c.Name] = c
+		}
+
+		if assert.Contains(t, cookies, middleware.SessionCookie) {
+			assert.Equal(t, wantToken.Plaintext, cookies[middleware.SessionCookie].Value)
+			assert.True(t, cookies[middleware.SessionCookie].HttpOnly)
+			assert.True(t, cookies[middleware.SessionCookie].Secure)
+		}
+		if assert.Contains(t, cookies, middleware.RefreshCookie) {
+			assert.Equal(t, wantRefreshToken.Plaintext, cookies[middleware.RefreshCookie].Value)
+			assert.Equal(t, "/v1/tokens/refresh", cookies[middleware.RefreshCookie].Path)
+		}
+		if assert.Contains(t, cookies, middleware.CSRFCookie) {
+			assert.False(t, cookies[middleware.CSRFCookie].HttpOnly, "CSRF cookie should be readable by scripts")
+			assert.Contains(t, rr.Body.String(), cookies[middleware.CSRFCookie].Value)
+		}
+
+		// The tokens must not be readable by scripts.
+		assert.NotContains(t, rr.Body.String(), wantToken.Plaintext)
+		assert.NotContains(t, rr.Body.String(), wantRefreshToken.Plaintext)
+	})
+	t.Run("Fail on session cookies disabled", func(t *testing.T) {
+		rc := reactor.NewReactor(zerolog.New(new(bytes.Buffer)))
+
+		userUsecase := new(mocks.UserUsecase)
+
+		reqBody := bytes.NewBufferString(`{"email": "alice@example.com", "password": "pa55word", "use_cookies": true}`)
+		r, err := http.NewRequest(http.MethodPost, "/v1/tokens/authentication", reqBody)
+		if err != nil {
+			t.Fatalf("failed to create new request: %v", err)
+		}
+
+		mid := middleware.New(new(config.Config), userUsecase, new(mocks.TokenUsecase), nil, nil, nil, rc)
+
+		rr := httptest.NewRecorder()
+		router := httprouter.New()
+		NewTokenAPI(router, userUsecase, new(mocks.TokenUsecase), mid, rc)
+
+		router.ServeHTTP(rr, r)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "use_cookies")
+		userUsecase.AssertNotCalled(t, "Login", mock.Anything, mock.Anything, mock.Anything)
+	})
 	// InvalidCredentials
 	// rr
 	// r
 	//
 	//	t.Skip("TODO: finish the implementation")
 }
+
+func TestCompleteMFALogin(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		logBuf := new(bytes.Buffer)
+		rc := reactor.NewReactor(zerolog.New(logBuf))
+
+		access, err := domain.GenerateToken(1, 15*time.Minute, domain.ScopeAuthentication)
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+		refresh, err := domain.GenerateToken(1, 7*24*time.Hour, domain.ScopeRefresh)
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("CompleteLogin", mock.Anything, "challenge", "abcde-fghij").Return(access, refresh, nil)
+
+		reqBody := bytes.NewBufferString(`{"mfa_token": "challenge", "code": "abcde-fghij"}`)
+		r, err := http.NewRequest(http.MethodPost, "/v1/tokens/mfa", reqBody)
+		if err != nil {
+			t.Fatalf("failed to create new request: %v", err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := httprouter.New()
+		NewTokenAPI(router, userUsecase, new(mocks.TokenUsecase), nil, rc)
+
+		router.ServeHTTP(rr, r)
+		assert.Equal(t, "", logBuf.String())
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), access.Plaintext)
+		assert.Contains(t, rr.Body.String(), refresh.Plaintext)
+
+		userUsecase.AssertExpectations(t)
+	})
+	t.Run("Fail on invalid code", func(t *testing.T) {
+		rc := reactor.NewReactor(zerolog.New(new(bytes.Buffer)))
+
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("CompleteLogin", mock.Anything, "challenge", "123456").
+			Return(nil, nil, errors.E(errors.Op("userUsecase.CompleteLogin"), errors.KindInvalidCredentials, domain.ErrInvalidCredentials))
+
+		reqBody := bytes.NewBufferString(`{"mfa_token": "challenge", "code": "123456"}`)
+		r, err := http.NewRequest(http.MethodPost, "/v1/tokens/mfa", reqBody)
+		if err != nil {
+			t.Fatalf("failed to create new request: %v", err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := httprouter.New()
+		NewTokenAPI(router, userUsecase, new(mocks.TokenUsecase), nil, rc)
+
+		router.ServeHTTP(rr, r)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+	t.Run("Fail on malformed code", func(t *testing.T) {
+		rc := reactor.NewReactor(zerolog.New(new(bytes.Buffer)))
+
+		userUsecase := new(mocks.UserUsecase)
+
+		reqBody := bytes.NewBufferString(`{"mfa_token": "challenge", "code": "12345"}`)
+		r, err := http.NewRequest(http.MethodPost, "/v1/tokens/mfa", reqBody)
+		if err != nil {
+			t.Fatalf("failed to create new request: %v", err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := httprouter.New()
+		NewTokenAPI(router, userUsecase, new(mocks.TokenUsecase), nil, rc)
+
+		router.ServeHTTP(rr, r)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		userUsecase.AssertNotCalled(t, "CompleteLogin", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreatePasswordResetToken(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		logBuf := new(bytes.Buffer)
+		rc := reactor.NewReactor(zerolog.New(logBuf))
+
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("RequestPasswordReset", mock.Anything, "alice@example.com").Return(nil)
+
+		reqBody := bytes.NewBufferString(`{"email": "alice@example.com"}`)
+		r, err := http.NewRequest(http.MethodPost, "/v1/tokens/password-reset", reqBody)
+		if err != nil {
+			t.Fatalf("failed to create new request: %v", err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := httprouter.New()
+		NewTokenAPI(router, userUsecase, new(mocks.TokenUsecase), nil, rc)
+
+		router.ServeHTTP(rr, r)
+		assert.Equal(t, "", logBuf.String())
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "token")
+
+		userUsecase.AssertExpectations(t)
+	})
+	t.Run("Fail on invalid email", func(t *testing.T) {
+		rc := reactor.NewReactor(zerolog.New(new(bytes.Buffer)))
+
+		userUsecase := new(mocks.UserUsecase)
+
+		reqBody := bytes.NewBufferString(`{"email": "alice"}`)
+		r, err := http.NewRequest(http.MethodPost, "/v1/tokens/password-reset", reqBody)
+		if err != nil {
+			t.Fatalf("failed to create new request: %v", err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := httprouter.New()
+		NewTokenAPI(router, userUsecase, new(mocks.TokenUsecase), nil, rc)
+
+		router.ServeHTTP(rr, r)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+		userUsecase.AssertNotCalled(t, "RequestPasswordReset", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreateActivationToken(t *testing.T) {
+	t.Run("Fail on rate limit exceeded", func(t *testing.T) {
+		rc := reactor.NewReactor(zerolog.New(new(bytes.Buffer)))
+
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("ResendActivation", mock.Anything, "alice@example.com").Return(errors.E(errors.KindRateLimitExceeded, domain.ErrRateLimitExceeded))
+
+		reqBody := bytes.NewBufferString(`{"email": "alice@example.com"}`)
+		r, err := http.NewRequest(http.MethodPost, "/v1/tokens/activation", reqBody)
+		if err != nil {
+			t.Fatalf("failed to create new request: %v", err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := httprouter.New()
+		NewTokenAPI(router, userUsecase, new(mocks.TokenUsecase), nil, rc)
+
+		router.ServeHTTP(rr, r)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	})
+}
@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+
+	"github.com/lib/pq"
+)
+
+type personalTokenRepo struct {
+	DB *sql.DB
+}
+
+func NewPersonalTokenRepo(DB *sql.DB) domain.PersonalAccessTokenRepository {
+	return &personalTokenRepo{DB}
+}
+
+// Insert adds the personal access token, and sets its ID and creation time.
+func (pr *personalTokenRepo) Insert(ctx context.Context, token *domain.PersonalAccessToken) error {
+	const op errors.Op = "personalTokenRepo.Insert"
+
+	query := `
+        INSERT INTO personal_access_tokens (hash, user_id, name, scopes, expiry)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at`
+
+	args := []interface{}{token.Hash, token.UserID, token.Name, pq.Array([]string(token.Scopes)), token.Expiry}
+
+	err := pr.DB.QueryRowContext(ctx, query, args...).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	return nil
+}
+
+// GetAllForUser returns the unexpired personal access tokens of the user, the newest first.
+func (pr *personalTokenRepo) GetAllForUser(ctx context.Context, userID int64) ([]*domain.PersonalAccessToken, error) {
+	const op errors.Op = "personalTokenRepo.GetAllForUser"
+
+	query := `
+        SELECT id, user_id, name, scopes, created_at, expiry, last_used_at
+        FROM personal_access_tokens
+        WHERE user_id = $1 AND (expiry IS NULL OR expiry > NOW())
+        ORDER BY id DESC`
+
+	rows, err := pr.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+	defer rows.Close()
+
+	tokens := []*domain.PersonalAccessToken{}
+
+	for rows.Next() {
+		token, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+
+	return tokens, nil
+}
+
+// GetForPlaintext returns the unexpired personal access token with given plaintext. The plaintext
+// of the returned token is left empty. If there's no such token, the error with kind
+// errors.KindRecordNotFound is returned.
+func (pr *personalTokenRepo) GetForPlaintext(ctx context.Context, tokenPlaintext string) (*domain.PersonalAccessToken, error) {
+	const op errors.Op = "personalTokenRepo.GetForPlaintext"
+
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `
+        SELECT id, user_id, name, scopes, created_at, expiry, last_used_at
+        FROM personal_access_tokens
+        WHERE hash = $1 AND (expiry IS NULL OR expiry > NOW())`
+
+	token, err := scanPersonalToken(pr.DB.QueryRowContext(ctx, query, tokenHash[:]))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+		default:
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+	}
+
+	return token, nil
+}
+
+// Touch records that the personal access token with given tokenID is used. To save writes,
+// the record is only updated if the last use is more than a minute ago.
+func (pr *personalTokenRepo) Touch(ctx context.Context, tokenID int64) error {
+	const op errors.Op = "personalTokenRepo.Touch"
+
+	query := `
+        UPDATE personal_access_tokens
+        SET last_used_at = NOW()
+        WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+
+	_, err := pr.DB.ExecContext(ctx, query, tokenID)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+	return nil
+}
+
+// DeleteForUser deletes the personal access token with given tokenID of the user. If there's
+// no such token, the error with kind errors.KindRecordNotFound is returned.
+func (pr *personalTokenRepo) DeleteForUser(ctx context.Context, userID int64, tokenID int64) error {
+	const op errors.Op = "personalTokenRepo.DeleteForUser"
+
+	query := `
+        DELETE FROM personal_access_tokens
+        WHERE id = $1 AND user_id = $2`
+
+	result, err := pr.DB.ExecContext(ctx, query, tokenID, userID)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// DeleteAllForUser deletes all personal access tokens of the user.
+func (pr *personalTokenRepo) DeleteAllForUser(ctx context.Context, userID int64) error {
+	const op errors.Op = "personalTokenRepo.DeleteAllForUser"
+
+	query := `
+        DELETE FROM personal_access_tokens
+        WHERE user_id = $1`
+
+	_, err := pr.DB.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	return nil
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPersonalToken(s scanner) (*domain.PersonalAccessToken, error) {
+	var token domain.PersonalAccessToken
+	var expiry, lastUsedAt sql.NullTime
+
+	err := s.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		pq.Array((*[]string)(&token.Scopes)),
+		&token.CreatedAt,
+		&expiry,
+		&lastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiry.Valid {
+		token.Expiry = &expiry.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+)
+
+func (suite *TokenRepoTestSuite) TestPersonalTokens() {
+	suite.Run("Success", func() {
+		repo := NewPersonalTokenRepo(suite.db)
+		ctx := context.TODO()
+
+		token := &domain.PersonalAccessToken{
+			Name:   "ci",
+			Scopes: domain.Permissions{domain.PermissionTasksRead},
+			UserID: suite.fakeuser.ID,
+		}
+		suite.Require().NoError(domain.GeneratePersonalAccessToken(token))
+		suite.Require().NoError(repo.Insert(ctx, token))
+		suite.NotZero(token.ID)
+
+		expiry := time.Now().Add(-time.Hour)
+		expired := &domain.PersonalAccessToken{
+			Name:   "expired",
+			Scopes: domain.Permissions{domain.PermissionTasksWrite},
+			Expiry: &expiry,
+			UserID: suite.fakeuser.ID,
+		}
+		suite.Require().NoError(domain.GeneratePersonalAccessToken(expired))
+		suite.Require().NoError(repo.Insert(ctx, expired))
+
+		got, err := repo.GetForPlaintext(ctx, token.Plaintext)
+		suite.Require().NoError(err)
+		suite.Equal(token.ID, got.ID)
+		suite.Equal(token.Scopes, got.Scopes)
+		suite.Nil(got.Expiry)
+		suite.Nil(got.LastUsedAt)
+
+		_, err = repo.GetForPlaintext(ctx, expired.Plaintext)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		suite.NoError(repo.Touch(ctx, token.ID))
+
+		tokens, err := repo.GetAllForUser(ctx, suite.fakeuser.ID)
+		suite.NoError(err)
+		suite.Require().Len(tokens, 1)
+		suite.NotNil(tokens[0].LastUsedAt)
+
+		suite.NoError(repo.DeleteForUser(ctx, suite.fakeuser.ID, token.ID))
+
+		err = repo.DeleteForUser(ctx, suite.fakeuser.ID, token.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		another := &domain.PersonalAccessToken{
+			Name:   "deploy",
+			Scopes: domain.Permissions{domain.PermissionTasksRead},
+			UserID: suite.fakeuser.ID,
+		}
+		suite.Require().NoError(domain.GeneratePersonalAccessToken(another))
+		suite.Require().NoError(repo.Insert(ctx, another))
+
+		suite.NoError(repo.DeleteAllForUser(ctx, suite.fakeuser.ID))
+
+		_, err = repo.GetForPlaintext(ctx, another.Plaintext)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
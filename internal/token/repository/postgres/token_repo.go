@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
 
 	"github.com/unknowntpo/todos/internal/domain"
@@ -16,15 +17,18 @@ func NewTokenRepo(DB *sql.DB) domain.TokenRepository {
 	return &tokenRepo{DB}
 }
 
-// Insert adds the data for a specific token to the tokens table.
+// Insert adds the data for a specific token to the tokens table,
+// along with the client stored in ctx.
 func (tr *tokenRepo) Insert(ctx context.Context, token *domain.Token) error {
 	const op errors.Op = "tokenRepo.Insert"
 
 	query := `
-        INSERT INTO tokens (hash, user_id, expiry, scope) 
-        VALUES ($1, $2, $3, $4)`
+        INSERT INTO tokens (hash, user_id, expiry, scope, ip, user_agent, family) 
+        VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''::bytea))`
 
-	args := []interface{}{token.Hash, token.UserID, token.Expiry, token.Scope}
+	client := domain.ClientFromContext(ctx)
+
+	args := []interface{}{token.Hash, token.UserID, token.Expiry, token.Scope, client.IP, client.UserAgent, token.Family}
 
 	_, err := tr.DB.ExecContext(ctx, query, args...)
 	if err != nil {
@@ -47,3 +51,229 @@ func (tr *tokenRepo) DeleteAllForUser(ctx context.Context, scope string, userID
 	}
 	return nil
 }
+
+// Delete deletes the token with given scope and plaintext, along with the other tokens of its family.
+func (tr *tokenRepo) Delete(ctx context.Context, scope, tokenPlaintext string) error {
+	const op errors.Op = "tokenRepo.Delete"
+
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `
+        DELETE FROM tokens 
+        WHERE (hash = $1 AND scope = $2)
+        OR family = (SELECT family FROM tokens WHERE hash = $1 AND scope = $2)`
+
+	_, err := tr.DB.ExecContext(ctx, query, tokenHash[:], scope)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+	return nil
+}
+
+// Touch records that the token with given scope and plaintext is used by the client stored in ctx.
+// To save writes, the record is only updated if the client changes or the last use is more than
+// a minute ago.
+func (tr *tokenRepo) Touch(ctx context.Context, scope, tokenPlaintext string) error {
+	const op errors.Op = "tokenRepo.Touch"
+
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+	client := domain.ClientFromContext(ctx)
+
+	query := `
+        UPDATE tokens
+        SET last_used_at = NOW(), ip = $3, user_agent = $4
+        WHERE hash = $1 AND scope = $2
+        AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute' OR ip <> $3 OR user_agent <> $4)`
+
+	_, err := tr.DB.ExecContext(ctx, query, tokenHash[:], scope, client.IP, client.UserAgent)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+	return nil
+}
+
+// GetAllForUser returns the unexpired tokens with given scope of the user, the most recently used first.
+// The rotated refresh tokens are left out, so that there's one refresh token for each family.
+func (tr *tokenRepo) GetAllForUser(ctx context.Context, scope string, userID int64) ([]*domain.Session, error) {
+	const op errors.Op = "tokenRepo.GetAllForUser"
+
+	query := `
+        SELECT id, created_at, last_used_at, expiry, ip, user_agent, hash, COALESCE(family, ''::bytea)
+        FROM tokens
+        WHERE scope = $1 AND user_id = $2 AND expiry > NOW() AND rotated_at IS NULL
+        ORDER BY COALESCE(last_used_at, created_at) DESC, id DESC`
+
+	rows, err := tr.DB.QueryContext(ctx, query, scope, userID)
+	if err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+	defer rows.Close()
+
+	sessions := []*domain.Session{}
+
+	for rows.Next() {
+		var session domain.Session
+		var lastUsedAt sql.NullTime
+
+		err := rows.Scan(
+			&session.ID,
+			&session.CreatedAt,
+			&lastUsedAt,
+			&session.Expiry,
+			&session.IP,
+			&session.UserAgent,
+			&session.Hash,
+			&session.Family,
+		)
+		if err != nil {
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+
+		if lastUsedAt.Valid {
+			session.LastUsedAt = &lastUsedAt.Time
+		}
+
+		sessions = append(sessions, &session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteForUser deletes the token with given scope and tokenID of the user, along with the other
+// tokens of its family. If there's no such token, the error with kind errors.KindRecordNotFound
+// is returned.
+func (tr *tokenRepo) DeleteForUser(ctx context.Context, scope string, userID int64, tokenID int64) error {
+	const op errors.Op = "tokenRepo.DeleteForUser"
+
+	query := `
+        DELETE FROM tokens 
+        WHERE user_id = $3 AND ((id = $1 AND scope = $2)
+        OR family = (SELECT family FROM tokens WHERE id = $1 AND scope = $2 AND user_id = $3))`
+
+	result, err := tr.DB.ExecContext(ctx, query, tokenID, scope, userID)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// Get returns the unexpired token with given scope and plaintext. The plaintext of the
+// returned token is left empty. If there's no such token, the error with kind
+// errors.KindRecordNotFound is returned.
+func (tr *tokenRepo) Get(ctx context.Context, scope, tokenPlaintext string) (*domain.Token, error) {
+	const op errors.Op = "tokenRepo.Get"
+
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `
+        SELECT hash, user_id, expiry, scope, COALESCE(family, ''::bytea), rotated_at IS NOT NULL
+        FROM tokens
+        WHERE hash = $1 AND scope = $2 AND expiry > NOW()`
+
+	var token domain.Token
+
+	err := tr.DB.QueryRowContext(ctx, query, tokenHash[:], scope).Scan(
+		&token.Hash,
+		&token.UserID,
+		&token.Expiry,
+		&token.Scope,
+		&token.Family,
+		&token.Rotated,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+		default:
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+	}
+
+	return &token, nil
+}
+
+// Rotate marks the token as rotated. The rotated token is kept until it expires, so that
+// its reuse can be detected. If the token has been rotated already, e.g. by a concurrent
+// request, the error with kind errors.KindEditConflict is returned.
+func (tr *tokenRepo) Rotate(ctx context.Context, token *domain.Token) error {
+	const op errors.Op = "tokenRepo.Rotate"
+
+	query := `
+        UPDATE tokens
+        SET rotated_at = NOW()
+        WHERE hash = $1 AND scope = $2 AND rotated_at IS NULL`
+
+	result, err := tr.DB.ExecContext(ctx, query, token.Hash, token.Scope)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindEditConflict, domain.ErrEditConflict)
+	}
+
+	token.Rotated = true
+
+	return nil
+}
+
+// DeleteFamily deletes the tokens with given scope in the family.
+func (tr *tokenRepo) DeleteFamily(ctx context.Context, scope string, family []byte) error {
+	const op errors.Op = "tokenRepo.DeleteFamily"
+
+	query := `
+        DELETE FROM tokens 
+        WHERE scope = $1 AND family = $2`
+
+	_, err := tr.DB.ExecContext(ctx, query, scope, family)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+	return nil
+}
+
+// DeleteExpired deletes at most limit expired tokens of any scope, and returns the number of
+// tokens deleted. The rows locked by other transactions are skipped, so that the deletion
+// doesn't wait for the requests using them.
+func (tr *tokenRepo) DeleteExpired(ctx context.Context, limit int) (int64, error) {
+	const op errors.Op = "tokenRepo.DeleteExpired"
+
+	query := `
+        DELETE FROM tokens
+        WHERE hash IN (
+            SELECT hash FROM tokens
+            WHERE expiry < NOW()
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED)`
+
+	result, err := tr.DB.ExecContext(ctx, query, limit)
+	if err != nil {
+		return 0, errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.E(op, errors.KindDatabase, err)
+	}
+
+	return rowsAffected, nil
+}
@@ -219,3 +219,116 @@ func (suite *TokenRepoTestSuite) TestDeleteAllForUser() {
 		suite.ErrorIs(err, context.DeadlineExceeded)
 	})
 }
+
+func (suite *TokenRepoTestSuite) TestDeleteExpired() {
+	repo := NewTokenRepo(suite.db)
+	ctx := context.TODO()
+
+	// Tokens with negative ttl are already expired.
+	for i := 0; i < 3; i++ {
+		expired, err := domain.GenerateToken(suite.fakeuser.ID, -time.Hour, domain.ScopeRefresh)
+		if err != nil {
+			suite.T().Fatal("fail to generate refresh token")
+		}
+		suite.NoError(repo.Insert(ctx, expired))
+	}
+
+	valid, err := domain.GenerateToken(suite.fakeuser.ID, 30*time.Minute, domain.ScopeAuthentication)
+	if err != nil {
+		suite.T().Fatal("fail to generate authentication token")
+	}
+	suite.NoError(repo.Insert(ctx, valid))
+
+	n, err := repo.DeleteExpired(ctx, 2)
+	suite.NoError(err)
+	suite.Equal(int64(2), n, "should delete at most limit tokens")
+
+	n, err = repo.DeleteExpired(ctx, 2)
+	suite.NoError(err)
+	suite.Equal(int64(1), n)
+
+	var count int
+	err = suite.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tokens WHERE user_id = $1`, suite.fakeuser.ID).Scan(&count)
+	suite.NoError(err)
+	suite.Equal(1, count, "unexpired token should be kept")
+}
+
+func (suite *TokenRepoTestSuite) TestSessions() {
+	suite.Run("Success", func() {
+		authToken, err := domain.GenerateToken(suite.fakeuser.ID, 30*time.Minute, domain.ScopeAuthentication)
+		if err != nil {
+			suite.T().Fatal("fail to generate authentication token")
+		}
+
+		repo := NewTokenRepo(suite.db)
+
+		ctx := domain.ContextWithClient(context.TODO(), domain.Client{IP: "203.0.113.7", UserAgent: "curl/7.79.1"})
+		suite.NoError(repo.Insert(ctx, authToken))
+
+		sessions, err := repo.GetAllForUser(ctx, domain.ScopeAuthentication, suite.fakeuser.ID)
+		suite.NoError(err)
+		suite.Require().Len(sessions, 1)
+		suite.Equal("203.0.113.7", sessions[0].IP)
+		suite.Empty(sessions[0].Family)
+		suite.Equal("curl/7.79.1", sessions[0].UserAgent)
+		suite.Nil(sessions[0].LastUsedAt, "token should not be used yet")
+
+		ctx = domain.ContextWithClient(context.TODO(), domain.Client{IP: "198.51.100.2", UserAgent: "Mozilla/5.0"})
+		suite.NoError(repo.Touch(ctx, domain.ScopeAuthentication, authToken.Plaintext))
+
+		sessions, err = repo.GetAllForUser(ctx, domain.ScopeAuthentication, suite.fakeuser.ID)
+		suite.NoError(err)
+		suite.Require().Len(sessions, 1)
+		suite.Equal("198.51.100.2", sessions[0].IP)
+		suite.NotNil(sessions[0].LastUsedAt)
+
+		suite.NoError(repo.DeleteForUser(ctx, domain.ScopeAuthentication, suite.fakeuser.ID, sessions[0].ID))
+
+		err = repo.DeleteForUser(ctx, domain.ScopeAuthentication, suite.fakeuser.ID, sessions[0].ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
+
+func (suite *TokenRepoTestSuite) TestRefreshTokenFamily() {
+	suite.Run("Success", func() {
+		family, err := domain.GenerateTokenFamily()
+		suite.Require().NoError(err)
+
+		authToken, err := domain.GenerateToken(suite.fakeuser.ID, 15*time.Minute, domain.ScopeAuthentication)
+		suite.Require().NoError(err)
+		refreshToken, err := domain.GenerateToken(suite.fakeuser.ID, 24*time.Hour, domain.ScopeRefresh)
+		suite.Require().NoError(err)
+
+		repo := NewTokenRepo(suite.db)
+
+		ctx := context.TODO()
+		for _, token := range []*domain.Token{authToken, refreshToken} {
+			token.Family = family
+			suite.NoError(repo.Insert(ctx, token))
+		}
+
+		got, err := repo.Get(ctx, domain.ScopeRefresh, refreshToken.Plaintext)
+		suite.Require().NoError(err)
+		suite.Equal(family, got.Family)
+		suite.False(got.Rotated)
+
+		suite.NoError(repo.Rotate(ctx, got))
+
+		// A refresh token can only be rotated once.
+		err = repo.Rotate(ctx, got)
+		suite.True(errors.KindIs(err, errors.KindEditConflict))
+
+		got, err = repo.Get(ctx, domain.ScopeRefresh, refreshToken.Plaintext)
+		suite.Require().NoError(err)
+		suite.True(got.Rotated)
+
+		sessions, err := repo.GetAllForUser(ctx, domain.ScopeRefresh, suite.fakeuser.ID)
+		suite.NoError(err)
+		suite.Empty(sessions, "rotated refresh tokens should not be listed as sessions")
+
+		suite.NoError(repo.DeleteFamily(ctx, domain.ScopeAuthentication, family))
+
+		_, err = repo.Get(ctx, domain.ScopeAuthentication, authToken.Plaintext)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
@@ -1,22 +1,39 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
-
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
 	"time"
 
 	"github.com/unknowntpo/todos/internal/domain"
 	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/pkg/jwt"
+)
+
+const (
+	// accessTokenTTL is the lifetime of the authentication tokens issued on login and refresh.
+	accessTokenTTL = 15 * time.Minute
+	// refreshTokenTTL is the lifetime of the refresh tokens.
+	refreshTokenTTL = 7 * 24 * time.Hour
 )
 
 type tokenUsecase struct {
 	tr             domain.TokenRepository
+	pr             domain.PersonalAccessTokenRepository
+	keys           *jwt.KeySet
 	contextTimeout time.Duration
 }
 
-func NewTokenUsecase(tr domain.TokenRepository, timeout time.Duration) domain.TokenUsecase {
+// NewTokenUsecase returns the token usecase. If keys is not nil, the authentication tokens
+// are signed by it instead of being stored in database.
+func NewTokenUsecase(tr domain.TokenRepository, pr domain.PersonalAccessTokenRepository, keys *jwt.KeySet, timeout time.Duration) domain.TokenUsecase {
 	return &tokenUsecase{
 		tr:             tr,
+		pr:             pr,
+		keys:           keys,
 		contextTimeout: timeout,
 	}
 }
@@ -46,3 +63,415 @@ func (tu *tokenUsecase) DeleteAllForUser(ctx context.Context, scope string, user
 
 	return nil
 }
+
+// Delete deletes the token with given scope and plaintext, e.g. when the user logs out.
+// Signed authentication tokens can't be deleted, the refresh tokens of their family are
+// deleted instead, so that they can't be renewed once they expire.
+func (tu *tokenUsecase) Delete(ctx context.Context, scope, tokenPlaintext string) error {
+	const op errors.Op = "tokenUsecase.Delete"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	if scope == domain.ScopeAuthentication && domain.IsSignedToken(tokenPlaintext) {
+		token, err := tu.Verify(tokenPlaintext)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		err = tu.deleteFamily(ctx, token.Family)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		return nil
+	}
+
+	err := tu.tr.Delete(ctx, scope, tokenPlaintext)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// Touch records that the authentication token is used by the client stored in ctx.
+func (tu *tokenUsecase) Touch(ctx context.Context, tokenPlaintext string) error {
+	const op errors.Op = "tokenUsecase.Touch"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	err := tu.tr.Touch(ctx, domain.ScopeAuthentication, tokenPlaintext)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// GetSessions returns the unexpired authentication tokens of the user,
+// the one matches currentTokenPlaintext is marked as current. Signed authentication
+// tokens aren't stored, so the sessions are the refresh token families of the user instead,
+// and the one which currentTokenPlaintext belongs to is marked as current.
+func (tu *tokenUsecase) GetSessions(ctx context.Context, userID int64, currentTokenPlaintext string) ([]*domain.Session, error) {
+	const op errors.Op = "tokenUsecase.GetSessions"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	sessions, err := tu.tr.GetAllForUser(ctx, tu.sessionScope(), userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	if tu.keys != nil {
+		// An invalid current token just leaves every session as not current.
+		if current, err := tu.Verify(currentTokenPlaintext); err == nil {
+			for _, session := range sessions {
+				session.Current = bytes.Equal(session.Family, current.Family)
+			}
+		}
+
+		return sessions, nil
+	}
+
+	currentHash := sha256.Sum256([]byte(currentTokenPlaintext))
+	for _, session := range sessions {
+		session.Current = bytes.Equal(session.Hash, currentHash[:])
+	}
+
+	return sessions, nil
+}
+
+// DeleteSession revokes the authentication token with given sessionID of the user, along with
+// the other tokens of its family. If the authentication tokens are signed, the session is a refresh
+// token, so its family can't be renewed once the signed token expires.
+func (tu *tokenUsecase) DeleteSession(ctx context.Context, userID int64, sessionID int64) error {
+	const op errors.Op = "tokenUsecase.DeleteSession"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	err := tu.tr.DeleteForUser(ctx, tu.sessionScope(), userID, sessionID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// CreatePersonalToken generates the plaintext of the personal access token and stores it.
+func (tu *tokenUsecase) CreatePersonalToken(ctx context.Context, token *domain.PersonalAccessToken) error {
+	const op errors.Op = "tokenUsecase.CreatePersonalToken"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	err := domain.GeneratePersonalAccessToken(token)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = tu.pr.Insert(ctx, token)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// GetPersonalTokens returns the unexpired personal access tokens of the user.
+func (tu *tokenUsecase) GetPersonalTokens(ctx context.Context, userID int64) ([]*domain.PersonalAccessToken, error) {
+	const op errors.Op = "tokenUsecase.GetPersonalTokens"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	tokens, err := tu.pr.GetAllForUser(ctx, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return tokens, nil
+}
+
+// DeletePersonalToken revokes the personal access token with given tokenID of the user.
+func (tu *tokenUsecase) DeletePersonalToken(ctx context.Context, userID int64, tokenID int64) error {
+	const op errors.Op = "tokenUsecase.DeletePersonalToken"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	err := tu.pr.DeleteForUser(ctx, userID, tokenID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// DeleteAllPersonalTokens revokes all personal access tokens of the user, e.g. when the user
+// is deactivated or the password is reset.
+func (tu *tokenUsecase) DeleteAllPersonalTokens(ctx context.Context, userID int64) error {
+	const op errors.Op = "tokenUsecase.DeleteAllPersonalTokens"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	err := tu.pr.DeleteAllForUser(ctx, userID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// AuthenticatePersonalToken returns the unexpired personal access token with given plaintext
+// and records that it's used. If there's no such token, the error with kind
+// errors.KindRecordNotFound is returned.
+func (tu *tokenUsecase) AuthenticatePersonalToken(ctx context.Context, tokenPlaintext string) (*domain.PersonalAccessToken, error) {
+	const op errors.Op = "tokenUsecase.AuthenticatePersonalToken"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	token, err := tu.pr.GetForPlaintext(ctx, tokenPlaintext)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	err = tu.pr.Touch(ctx, token.ID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return token, nil
+}
+
+// DeleteExpired deletes all the expired tokens in batches of batchSize, and returns the number
+// of tokens deleted. Every batch is a short statement of its own with the usual timeout, so that
+// a large backlog doesn't hold locks for long; ctx bounds the whole run.
+func (tu *tokenUsecase) DeleteExpired(ctx context.Context, batchSize int) (int64, error) {
+	const op errors.Op = "tokenUsecase.DeleteExpired"
+
+	var total int64
+
+	for {
+		batchCtx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+		n, err := tu.tr.DeleteExpired(batchCtx, batchSize)
+		cancel()
+
+		total += n
+
+		if err != nil {
+			return total, errors.E(op, err)
+		}
+
+		if n < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
+// IssueAuthTokens issues a short-lived authentication token and a refresh token of a new family
+// to the user who just logged in.
+func (tu *tokenUsecase) IssueAuthTokens(ctx context.Context, userID int64) (*domain.Token, *domain.Token, error) {
+	const op errors.Op = "tokenUsecase.IssueAuthTokens"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	family, err := domain.GenerateTokenFamily()
+	if err != nil {
+		return nil, nil, errors.E(op, err)
+	}
+
+	access, refresh, err := tu.issue(ctx, userID, family)
+	if err != nil {
+		return nil, nil, errors.E(op, err)
+	}
+
+	return access, refresh, nil
+}
+
+// Refresh exchanges the refresh token for a new authentication token and a new refresh token
+// of the same family, the authentication tokens issued before in the family are revoked.
+// A refresh token can only be exchanged once, if a rotated one is presented again, it has
+// probably been stolen, so the whole family is revoked. In both cases of invalid and reused
+// refresh token, the error with kind errors.KindInvalidCredentials is returned.
+func (tu *tokenUsecase) Refresh(ctx context.Context, refreshTokenPlaintext string) (*domain.Token, *domain.Token, error) {
+	const op errors.Op = "tokenUsecase.Refresh"
+
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	token, err := tu.tr.Get(ctx, domain.ScopeRefresh, refreshTokenPlaintext)
+	if err != nil {
+		if errors.KindIs(err, errors.KindRecordNotFound) {
+			return nil, nil, errors.E(op, errors.KindInvalidCredentials, err)
+		}
+		return nil, nil, errors.E(op, err)
+	}
+
+	reused := token.Rotated
+	if !reused {
+		err = tu.tr.Rotate(ctx, token)
+		switch {
+		case err == nil:
+		case errors.KindIs(err, errors.KindEditConflict):
+			// A concurrent request has exchanged it just now.
+			reused = true
+		default:
+			return nil, nil, errors.E(op, err)
+		}
+	}
+
+	if reused {
+		err = tu.deleteFamily(ctx, token.Family)
+		if err != nil {
+			return nil, nil, errors.E(op, err)
+		}
+
+		return nil, nil, errors.E(op, errors.KindInvalidCredentials, errors.Msg("refresh token reused"), domain.ErrInvalidCredentials)
+	}
+
+	err = tu.tr.DeleteFamily(ctx, domain.ScopeAuthentication, token.Family)
+	if err != nil {
+		return nil, nil, errors.E(op, err)
+	}
+
+	access, refresh, err := tu.issue(ctx, token.UserID, token.Family)
+	if err != nil {
+		return nil, nil, errors.E(op, err)
+	}
+
+	return access, refresh, nil
+}
+
+// Verify verifies the signed authentication token without hitting database, and returns
+// the token described by its claims. If the token is invalid or expired, or the tokens
+// aren't signed, the error with kind errors.KindInvalidCredentials is returned.
+func (tu *tokenUsecase) Verify(tokenPlaintext string) (*domain.Token, error) {
+	const op errors.Op = "tokenUsecase.Verify"
+
+	if tu.keys == nil {
+		return nil, errors.E(op, errors.KindInvalidCredentials, domain.ErrInvalidCredentials)
+	}
+
+	claims, err := tu.keys.Verify(tokenPlaintext, time.Now())
+	if err != nil {
+		return nil, errors.E(op, errors.KindInvalidCredentials, err)
+	}
+
+	if claims.Scope != domain.ScopeAuthentication {
+		return nil, errors.E(op, errors.KindInvalidCredentials, domain.ErrInvalidCredentials)
+	}
+
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, errors.E(op, errors.KindInvalidCredentials, err)
+	}
+
+	family, err := base64.RawURLEncoding.DecodeString(claims.SessionID)
+	if err != nil {
+		return nil, errors.E(op, errors.KindInvalidCredentials, err)
+	}
+
+	token := &domain.Token{
+		Plaintext: tokenPlaintext,
+		UserID:    userID,
+		Expiry:    time.Unix(claims.ExpiresAt, 0),
+		Scope:     claims.Scope,
+		Family:    family,
+	}
+
+	return token, nil
+}
+
+// JWKS returns the public keys which verify the signed authentication tokens.
+func (tu *tokenUsecase) JWKS() *jwt.JWKS {
+	if tu.keys == nil {
+		return &jwt.JWKS{Keys: []jwt.JWK{}}
+	}
+
+	return tu.keys.JWKS()
+}
+
+// issue inserts a new authentication token and a new refresh token in the family.
+// If the tokens are signed, the authentication token is signed instead of being inserted.
+func (tu *tokenUsecase) issue(ctx context.Context, userID int64, family []byte) (*domain.Token, *domain.Token, error) {
+	const op errors.Op = "tokenUsecase.issue"
+
+	access, err := domain.GenerateToken(userID, accessTokenTTL, domain.ScopeAuthentication)
+	if err != nil {
+		return nil, nil, errors.E(op, err)
+	}
+
+	refresh, err := domain.GenerateToken(userID, refreshTokenTTL, domain.ScopeRefresh)
+	if err != nil {
+		return nil, nil, errors.E(op, err)
+	}
+
+	for _, token := range []*domain.Token{access, refresh} {
+		token.Family = family
+
+		if token.Scope == domain.ScopeAuthentication && tu.keys != nil {
+			err = tu.sign(token)
+		} else {
+			err = tu.tr.Insert(ctx, token)
+		}
+		if err != nil {
+			return nil, nil, errors.E(op, err)
+		}
+	}
+
+	return access, refresh, nil
+}
+
+// sign replaces the plaintext of the token with the signed token carrying its user ID, scope,
+// expiry and family.
+func (tu *tokenUsecase) sign(token *domain.Token) error {
+	const op errors.Op = "tokenUsecase.sign"
+
+	claims := &jwt.Claims{
+		Subject:   strconv.FormatInt(token.UserID, 10),
+		Scope:     token.Scope,
+		SessionID: base64.RawURLEncoding.EncodeToString(token.Family),
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: token.Expiry.Unix(),
+	}
+
+	plaintext, err := tu.keys.Sign(claims)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	token.Plaintext = plaintext
+	token.Hash = nil
+
+	return nil
+}
+
+// sessionScope returns the scope of the tokens which stand for the sessions of the users.
+func (tu *tokenUsecase) sessionScope() string {
+	if tu.keys != nil {
+		return domain.ScopeRefresh
+	}
+
+	return domain.ScopeAuthentication
+}
+
+// deleteFamily deletes every token in the family.
+func (tu *tokenUsecase) deleteFamily(ctx context.Context, family []byte) error {
+	const op errors.Op = "tokenUsecase.deleteFamily"
+
+	for _, scope := range []string{domain.ScopeAuthentication, domain.ScopeRefresh} {
+		err := tu.tr.DeleteFamily(ctx, scope, family)
+		if err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	return nil
+}
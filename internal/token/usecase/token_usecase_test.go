@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/unknowntpo/todos/internal/domain"
 	"github.com/unknowntpo/todos/internal/domain/errors"
 	_repoMock "github.com/unknowntpo/todos/internal/domain/mocks"
+	"github.com/unknowntpo/todos/pkg/jwt"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -23,7 +25,7 @@ func TestInsert(t *testing.T) {
 		ctx := context.TODO()
 		repo.On("Insert", mock.Anything, token).Return(nil)
 
-		tokenUsecase := NewTokenUsecase(repo, 3*time.Second)
+		tokenUsecase := NewTokenUsecase(repo, nil, nil, 3*time.Second)
 		err = tokenUsecase.Insert(ctx, token)
 		assert.NoError(t, err)
 
@@ -44,7 +46,7 @@ func TestInsert(t *testing.T) {
 		dummyErr := errors.E(errors.New("error in mock token repo"))
 		repo.On("Insert", mock.Anything, mock.Anything).Return(dummyErr)
 
-		tokenUsecase := NewTokenUsecase(repo, 3*time.Second)
+		tokenUsecase := NewTokenUsecase(repo, nil, nil, 3*time.Second)
 		gotErr := tokenUsecase.Insert(ctx, token)
 
 		wantErr := errors.E(op, dummyErr)
@@ -66,7 +68,7 @@ func TestDeleteAllForUser(t *testing.T) {
 			return userID == 1
 		})).Return(nil)
 
-		tokenUsecase := NewTokenUsecase(repo, 3*time.Second)
+		tokenUsecase := NewTokenUsecase(repo, nil, nil, 3*time.Second)
 
 		ctx := context.TODO()
 		gotErr := tokenUsecase.DeleteAllForUser(ctx, domain.ScopeActivation, 1)
@@ -87,7 +89,7 @@ func TestDeleteAllForUser(t *testing.T) {
 			return userID == dummyUserID
 		})).Return(dummyErr)
 
-		tokenUsecase := NewTokenUsecase(repo, 3*time.Second)
+		tokenUsecase := NewTokenUsecase(repo, nil, nil, 3*time.Second)
 		gotErr := tokenUsecase.DeleteAllForUser(ctx, domain.ScopeActivation, dummyUserID)
 
 		wantErr := errors.E(op, dummyErr)
@@ -96,3 +98,266 @@ func TestDeleteAllForUser(t *testing.T) {
 		repo.AssertExpectations(t)
 	})
 }
+
+func TestDeleteExpired(t *testing.T) {
+	// It should keep deleting until a batch comes back short.
+	t.Run("Success", func(t *testing.T) {
+		repo := new(_repoMock.TokenRepository)
+		repo.On("DeleteExpired", mock.Anything, 100).Return(int64(100), nil).Twice()
+		repo.On("DeleteExpired", mock.Anything, 100).Return(int64(42), nil).Once()
+
+		tokenUsecase := NewTokenUsecase(repo, nil, nil, 3*time.Second)
+		n, err := tokenUsecase.DeleteExpired(context.TODO(), 100)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(242), n)
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Fail with some errors", func(t *testing.T) {
+		const op errors.Op = "tokenUsecase.DeleteExpired"
+
+		repo := new(_repoMock.TokenRepository)
+		dummyErr := errors.E(errors.New("error in mock token repo"))
+		repo.On("DeleteExpired", mock.Anything, 100).Return(int64(100), nil).Once()
+		repo.On("DeleteExpired", mock.Anything, 100).Return(int64(0), dummyErr).Once()
+
+		tokenUsecase := NewTokenUsecase(repo, nil, nil, 3*time.Second)
+		n, err := tokenUsecase.DeleteExpired(context.TODO(), 100)
+		assert.Equal(t, errors.E(op, dummyErr), err)
+		assert.Equal(t, int64(100), n, "should count the batches deleted before the error")
+
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestGetSessions(t *testing.T) {
+	current, err := domain.GenerateToken(1, 30*time.Minute, domain.ScopeAuthentication)
+	assert.NoError(t, err)
+	other, err := domain.GenerateToken(1, 30*time.Minute, domain.ScopeAuthentication)
+	assert.NoError(t, err)
+
+	repo := new(_repoMock.TokenRepository)
+	repo.On("GetAllForUser", mock.Anything, domain.ScopeAuthentication, int64(1)).Return([]*domain.Session{
+		{ID: 1, Hash: other.Hash},
+		{ID: 2, Hash: current.Hash},
+	}, nil)
+
+	tokenUsecase := NewTokenUsecase(repo, nil, nil, 3*time.Second)
+	sessions, err := tokenUsecase.GetSessions(context.TODO(), 1, current.Plaintext)
+	assert.NoError(t, err)
+	if assert.Len(t, sessions, 2) {
+		assert.False(t, sessions[0].Current)
+		assert.True(t, sessions[1].Current, "the session of the given token should be marked as current")
+	}
+
+	repo.AssertExpectations(t)
+}
+
+func TestIssueAuthTokens(t *testing.T) {
+	repo := new(_repoMock.TokenRepository)
+	repo.On("Insert", mock.Anything, mock.AnythingOfType("*domain.Token")).Return(nil)
+
+	tokenUsecase := NewTokenUsecase(repo, nil, nil, 3*time.Second)
+	access, refresh, err := tokenUsecase.IssueAuthTokens(context.TODO(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ScopeAuthentication, access.Scope)
+	assert.Equal(t, domain.ScopeRefresh, refresh.Scope)
+	assert.NotEmpty(t, access.Family)
+	assert.Equal(t, access.Family, refresh.Family, "both tokens should belong to the same family")
+
+	repo.AssertNumberOfCalls(t, "Insert", 2)
+}
+
+func TestRefresh(t *testing.T) {
+	newRefreshToken := func(t *testing.T) *domain.Token {
+		token, err := domain.GenerateToken(1, 24*time.Hour, domain.ScopeRefresh)
+		assert.NoError(t, err)
+		token.Family, err = domain.GenerateTokenFamily()
+		assert.NoError(t, err)
+		return token
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		token := newRefreshToken(t)
+
+		repo := new(_repoMock.TokenRepository)
+		repo.On("Get", mock.Anything, domain.ScopeRefresh, token.Plaintext).Return(token, nil)
+		repo.On("Rotate", mock.Anything, token).Return(nil)
+		repo.On("DeleteFamily", mock.Anything, domain.ScopeAuthentication, token.Family).Return(nil)
+		repo.On("Insert", mock.Anything, mock.MatchedBy(func(got *domain.Token) bool {
+			return string(got.Family) == string(token.Family)
+		})).Return(nil)
+
+		tokenUsecase := NewTokenUsecase(repo, nil, nil, 3*time.Second)
+		access, refresh, err := tokenUsecase.Refresh(context.TODO(), token.Plaintext)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ScopeAuthentication, access.Scope)
+		assert.Equal(t, domain.ScopeRefresh, refresh.Scope)
+		assert.NotEqual(t, token.Plaintext, refresh.Plaintext)
+
+		repo.AssertExpectations(t)
+		repo.AssertNumberOfCalls(t, "Insert", 2)
+	})
+
+	// A rotated refresh token which is presented again should revoke the whole family.
+	t.Run("Reused token", func(t *testing.T) {
+		token := newRefreshToken(t)
+		token.Rotated = true
+
+		repo := new(_repoMock.TokenRepository)
+		repo.On("Get", mock.Anything, domain.ScopeRefresh, token.Plaintext).Return(token, nil)
+		repo.On("DeleteFamily", mock.Anything, domain.ScopeAuthentication, token.Family).Return(nil)
+		repo.On("DeleteFamily", mock.Anything, domain.ScopeRefresh, token.Family).Return(nil)
+
+		tokenUsecase := NewTokenUsecase(repo, nil, nil, 3*time.Second)
+		_, _, err := tokenUsecase.Refresh(context.TODO(), token.Plaintext)
+		assert.True(t, errors.KindIs(err, errors.KindInvalidCredentials), "wrong kind of error: %v", err)
+
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rotated concurrently", func(t *testing.T) {
+		token := newRefreshToken(t)
+
+		repo := new(_repoMock.TokenRepository)
+		repo.On("Get", mock.Anything, domain.ScopeRefresh, token.Plaintext).Return(token, nil)
+		repo.On("Rotate", mock.Anything, token).Return(errors.E(errors.KindEditConflict, domain.ErrEditConflict))
+		repo.On("DeleteFamily", mock.Anything, domain.ScopeAuthentication, token.Family).Return(nil)
+		repo.On("DeleteFamily", mock.Anything, domain.ScopeRefresh, token.Family).Return(nil)
+
+		tokenUsecase := NewTokenUsecase(repo, nil, nil, 3*time.Second)
+		_, _, err := tokenUsecase.Refresh(context.TODO(), token.Plaintext)
+		assert.True(t, errors.KindIs(err, errors.KindInvalidCredentials), "wrong kind of error: %v", err)
+
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		repo := new(_repoMock.TokenRepository)
+		repo.On("Get", mock.Anything, domain.ScopeRefresh, "unknown").Return(nil, errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound))
+
+		tokenUsecase := NewTokenUsecase(repo, nil, nil, 3*time.Second)
+		_, _, err := tokenUsecase.Refresh(context.TODO(), "unknown")
+		assert.True(t, errors.KindIs(err, errors.KindInvalidCredentials), "wrong kind of error: %v", err)
+
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestSignedTokens(t *testing.T) {
+	key, err := jwt.NewKey("k1", jwt.AlgEdDSA, bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+	keys, err := jwt.NewKeySet(key)
+	assert.NoError(t, err)
+
+	repo := new(_repoMock.TokenRepository)
+	// Only the refresh token is stored.
+	repo.On("Insert", mock.Anything, mock.MatchedBy(func(token *domain.Token) bool {
+		return token.Scope == domain.ScopeRefresh
+	})).Return(nil).Once()
+
+	tokenUsecase := NewTokenUsecase(repo, nil, keys, 3*time.Second)
+	access, refresh, err := tokenUsecase.IssueAuthTokens(context.TODO(), 1)
+	assert.NoError(t, err)
+	assert.True(t, domain.IsSignedToken(access.Plaintext))
+	assert.False(t, domain.IsSignedToken(refresh.Plaintext))
+
+	got, err := tokenUsecase.Verify(access.Plaintext)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), got.UserID)
+	assert.Equal(t, domain.ScopeAuthentication, got.Scope)
+	assert.Equal(t, refresh.Family, got.Family)
+
+	_, err = tokenUsecase.Verify(access.Plaintext + "x")
+	assert.True(t, errors.KindIs(err, errors.KindInvalidCredentials), "wrong kind of error: %v", err)
+
+	// Logging out revokes the family, since signed tokens can't be deleted.
+	repo.On("DeleteFamily", mock.Anything, domain.ScopeAuthentication, refresh.Family).Return(nil)
+	repo.On("DeleteFamily", mock.Anything, domain.ScopeRefresh, refresh.Family).Return(nil)
+
+	err = tokenUsecase.Delete(context.TODO(), domain.ScopeAuthentication, access.Plaintext)
+	assert.NoError(t, err)
+
+	assert.Len(t, tokenUsecase.JWKS().Keys, 1)
+
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Signed authentication tokens aren't stored, so the sessions are the refresh token families.
+func TestSignedSessions(t *testing.T) {
+	key, err := jwt.NewKey("k1", jwt.AlgEdDSA, bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+	keys, err := jwt.NewKeySet(key)
+	assert.NoError(t, err)
+
+	repo := new(_repoMock.TokenRepository)
+	repo.On("Insert", mock.Anything, mock.AnythingOfType("*domain.Token")).Return(nil)
+
+	tokenUsecase := NewTokenUsecase(repo, nil, keys, 3*time.Second)
+	access, refresh, err := tokenUsecase.IssueAuthTokens(context.TODO(), 1)
+	assert.NoError(t, err)
+
+	other, err := domain.GenerateTokenFamily()
+	assert.NoError(t, err)
+
+	repo.On("GetAllForUser", mock.Anything, domain.ScopeRefresh, int64(1)).Return([]*domain.Session{
+		{ID: 1, Hash: refresh.Hash, Family: other},
+		{ID: 2, Hash: refresh.Hash, Family: refresh.Family},
+	}, nil)
+
+	sessions, err := tokenUsecase.GetSessions(context.TODO(), 1, access.Plaintext)
+	assert.NoError(t, err)
+	if assert.Len(t, sessions, 2) {
+		assert.False(t, sessions[0].Current)
+		assert.True(t, sessions[1].Current, "the session of the family of the given token should be marked as current")
+	}
+
+	repo.On("DeleteForUser", mock.Anything, domain.ScopeRefresh, int64(1), int64(1)).Return(nil)
+
+	err = tokenUsecase.DeleteSession(context.TODO(), 1, 1)
+	assert.NoError(t, err)
+
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "GetAllForUser", mock.Anything, domain.ScopeAuthentication, mock.Anything)
+}
+
+func TestPersonalTokens(t *testing.T) {
+	t.Run("Create", func(t *testing.T) {
+		pr := new(_repoMock.PersonalAccessTokenRepository)
+		pr.On("Insert", mock.Anything, mock.AnythingOfType("*domain.PersonalAccessToken")).Return(nil)
+
+		token := &domain.PersonalAccessToken{Name: "ci", Scopes: domain.Permissions{domain.PermissionTasksRead}, UserID: 1}
+
+		tokenUsecase := NewTokenUsecase(nil, pr, nil, 3*time.Second)
+		err := tokenUsecase.CreatePersonalToken(context.TODO(), token)
+		assert.NoError(t, err)
+		assert.True(t, domain.IsPersonalAccessToken(token.Plaintext))
+		assert.NotEmpty(t, token.Hash)
+
+		pr.AssertExpectations(t)
+	})
+
+	t.Run("Authenticate", func(t *testing.T) {
+		token := &domain.PersonalAccessToken{ID: 7, Scopes: domain.Permissions{domain.PermissionTasksRead}, UserID: 1}
+
+		pr := new(_repoMock.PersonalAccessTokenRepository)
+		pr.On("GetForPlaintext", mock.Anything, "pat_valid").Return(token, nil)
+		pr.On("Touch", mock.Anything, int64(7)).Return(nil)
+		pr.On("GetForPlaintext", mock.Anything, "pat_unknown").Return(nil, errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound))
+
+		tokenUsecase := NewTokenUsecase(nil, pr, nil, 3*time.Second)
+		got, err := tokenUsecase.AuthenticatePersonalToken(context.TODO(), "pat_valid")
+		assert.NoError(t, err)
+		assert.Equal(t, token, got)
+
+		_, err = tokenUsecase.AuthenticatePersonalToken(context.TODO(), "pat_unknown")
+		assert.True(t, errors.KindIs(err, errors.KindRecordNotFound), "wrong kind of error: %v", err)
+
+		pr.AssertExpectations(t)
+	})
+}
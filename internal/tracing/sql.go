@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapConnector returns the connector whose connections record the spans of the queries and the
+// statements executed, named by the operation, e.g. "SELECT", with the statement as the
+// db.statement attribute. The arguments aren't recorded, since they may be secrets. The system is
+// the db.system attribute, e.g. "postgresql".
+//
+//	connector, err := pq.NewConnector(dsn)
+//	db := sql.OpenDB(tracing.WrapConnector(connector, "postgresql"))
+func WrapConnector(c driver.Connector, system string) driver.Connector {
+	return &connector{Connector: c, system: system}
+}
+
+type connector struct {
+	driver.Connector
+	system string
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{Conn: cn, system: c.system}, nil
+}
+
+// conn forwards the optional interfaces to the connection of the driver, and falls back the
+// way database/sql does when the driver doesn't implement them.
+type conn struct {
+	driver.Conn
+	system string
+}
+
+func (c *conn) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, operation(query), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", c.system),
+		attribute.String("db.statement", query),
+	))
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, span := c.start(ctx, query)
+	defer span.End()
+
+	rows, err := queryer.QueryContext(ctx, query, args)
+	recordError(span, err)
+	return rows, err
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, span := c.start(ctx, query)
+	defer span.End()
+
+	res, err := execer.ExecContext(ctx, query, args)
+	recordError(span, err)
+	return res, err
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	//lint:ignore SA1019 Begin is the fallback of drivers without BeginTx.
+	return c.Conn.Begin() // nolint:staticcheck
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// recordError marks the span as failed by err, unless the driver skipped the fast path, in which
+// case database/sql prepares the statement instead.
+func recordError(span trace.Span, err error) {
+	if err == nil || err == driver.ErrSkip {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// operation returns the first keyword of the statement in upper case, e.g. "SELECT".
+func operation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+
+	return strings.ToUpper(fields[0])
+}
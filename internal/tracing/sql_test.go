@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errFakeExec = errors.New("relation does not exist")
+
+// fakeConnector connects to a fake database, which fails the statements starting with "DROP".
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(ctx context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                            { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if operation(query) == "DROP" {
+		return nil, errFakeExec
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string              { return []string{"id"} }
+func (fakeRows) Close() error                   { return nil }
+func (fakeRows) Next(dest []driver.Value) error { return io.EOF }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func TestWrapConnector(t *testing.T) {
+	r := newRecorder(t)
+
+	db := sql.OpenDB(WrapConnector(fakeConnector{}, "fake"))
+	defer db.Close()
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "insert into tasks (title) values ($1)", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.QueryContext(ctx, "  select id from tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	_, err = db.ExecContext(ctx, "DROP TABLE tasks")
+	assert.Equal(t, errFakeExec, err)
+
+	parent.End()
+
+	spans := byName(r)
+	for _, name := range []string{"INSERT", "SELECT", "DROP"} {
+		span, ok := spans[name]
+		if !assert.True(t, ok, "the span of %s should be recorded", name) {
+			continue
+		}
+
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID(), "the span of %s should be a child of the span of the context", name)
+		assert.Contains(t, span.Attributes(), attribute.String("db.system", "fake"))
+	}
+
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("db.system", "fake"),
+		attribute.String("db.statement", "insert into tasks (title) values ($1)"),
+	}, spans["INSERT"].Attributes(), "the arguments shouldn't be recorded")
+
+	assert.Equal(t, codes.Unset, spans["SELECT"].Status().Code)
+	assert.Equal(t, codes.Error, spans["DROP"].Status().Code)
+}
@@ -0,0 +1,62 @@
+// Package tracing records the spans at the boundaries of the server besides the API: the
+// statements sent to the database, the jobs of the pool and the emails sent by the mailer. The
+// spans of the jobs and the emails are named by their errors.Op, e.g. "mailer.Send".
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain/errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer returns the tracer of the server, which is a no-op one until a tracer provider is set
+// by otel.SetTracerProvider.
+func Tracer() trace.Tracer {
+	return otel.Tracer("github.com/unknowntpo/todos")
+}
+
+// Start starts the span of the operation, which is a child of the span in ctx.
+func Start(ctx context.Context, op errors.Op, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, op.String(), trace.WithAttributes(attrs...))
+}
+
+// End records err, if any, along with the operation it happened in and its kind, and ends the
+// span.
+func End(span trace.Span, err error) {
+	if err != nil && span.IsRecording() {
+		var attrs []attribute.KeyValue
+		if op := errors.Origin(err); op != "" {
+			attrs = append(attrs, attribute.String("error.op", op.String()))
+		}
+		attrs = append(attrs, attribute.String("error.kind", errors.KindOf(err).String()))
+
+		span.SetAttributes(attrs...)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// Job returns the job for the pool which runs fn in the span of the operation. The span is a
+// child of the span in ctx, but fn gets a context without its deadline, since the job runs once
+// the request is served.
+func Job(ctx context.Context, op errors.Op, fn func(ctx context.Context)) func() {
+	parent := trace.SpanFromContext(ctx)
+	scheduled := time.Now()
+
+	return func() {
+		ctx, span := Start(trace.ContextWithSpan(context.Background(), parent), op)
+		defer span.End()
+
+		span.SetAttributes(attribute.Float64("job.wait_ms", float64(time.Since(scheduled).Microseconds())/1000))
+
+		fn(ctx)
+	}
+}
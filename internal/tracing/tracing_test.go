@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/unknowntpo/todos/internal/domain/errors"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newRecorder sets the tracer provider recording every span for the test, and returns the
+// recorder of the spans.
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	r := tracetest.NewSpanRecorder()
+
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(r)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	return r
+}
+
+// byName returns the spans ended, keyed by their names.
+func byName(r *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range r.Ended() {
+		spans[s.Name()] = s
+	}
+	return spans
+}
+
+func TestEnd(t *testing.T) {
+	r := newRecorder(t)
+
+	_, span := Start(context.Background(), "taskUsecase.GetByID")
+	End(span, errors.E(errors.Op("taskUsecase.GetByID"), errors.E(errors.Op("taskRepo.GetByID"), errors.KindRecordNotFound, errors.New("no rows"))))
+
+	_, span = Start(context.Background(), "taskUsecase.GetAll")
+	End(span, nil)
+
+	spans := byName(r)
+
+	failed := spans["taskUsecase.GetByID"]
+	assert.Equal(t, codes.Error, failed.Status().Code)
+	assert.Contains(t, failed.Attributes(), attribute.String("error.op", "taskRepo.GetByID"), "the operation the error happened in should be recorded")
+	assert.Contains(t, failed.Attributes(), attribute.String("error.kind", errors.KindRecordNotFound.String()))
+
+	succeeded := spans["taskUsecase.GetAll"]
+	assert.Equal(t, codes.Unset, succeeded.Status().Code)
+	assert.Empty(t, succeeded.Attributes())
+}
+
+func TestJob(t *testing.T) {
+	r := newRecorder(t)
+
+	ctx, parent := Start(context.Background(), "taskUsecase.Assign")
+	ctx, cancel := context.WithCancel(ctx)
+
+	job := Job(ctx, "taskUsecase.Assign.sendNotification", func(ctx context.Context) {
+		assert.NoError(t, ctx.Err(), "the job shouldn't be canceled along with the request")
+		assert.True(t, trace.SpanFromContext(ctx).IsRecording())
+	})
+
+	// The request is served before the job runs.
+	cancel()
+	End(parent, nil)
+	job()
+
+	span := byName(r)["taskUsecase.Assign.sendNotification"]
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID(), "the job should be a child of the span it's scheduled in")
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/unknowntpo/todos/internal/domain"
 	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/helpers"
+	"github.com/unknowntpo/todos/internal/middleware"
 	"github.com/unknowntpo/todos/pkg/validator"
 
 	"github.com/unknowntpo/todos/internal/reactor"
@@ -13,9 +15,10 @@ import (
 )
 
 type userAPI struct {
-	uu domain.UserUsecase
-	tu domain.TokenUsecase
-	rc *reactor.Reactor
+	uu  domain.UserUsecase
+	tu  domain.TokenUsecase
+	mid *middleware.Middleware
+	rc  *reactor.Reactor
 }
 
 type UserRegistrationResponse struct {
@@ -32,15 +35,53 @@ type UserActivationResponse struct {
 	User *domain.User `json:"user"`
 }
 
+type UserPasswordResetRequest struct {
+	Password string `json:"password"`
+	Token    string `json:"token"`
+}
+
+type UserPasswordResetResponse struct {
+	Message string `json:"message"`
+}
+
+type UserAccountResponse struct {
+	User *domain.User `json:"user"`
+}
+
+type UserAccountUpdateRequest struct {
+	Name            *string `json:"name"`
+	Email           *string `json:"email"`
+	Password        *string `json:"password"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+type UserEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+type UserAccountDeletionRequest struct {
+	Password string `json:"password"`
+}
+
+type UserAccountDeletionResponse struct {
+	Message string `json:"message"`
+}
+
 func NewUserAPI(router *httprouter.Router,
 	uu domain.UserUsecase,
 	tu domain.TokenUsecase,
+	mid *middleware.Middleware,
 	rc *reactor.Reactor) {
 
-	api := &userAPI{uu: uu, tu: tu, rc: rc}
+	api := &userAPI{uu: uu, tu: tu, mid: mid, rc: rc}
 
 	router.HandlerFunc(http.MethodPost, "/v1/users/registration", api.RegisterUser)
 	router.HandlerFunc(http.MethodPut, "/v1/users/activation", api.ActivateUser)
+	router.HandlerFunc(http.MethodPut, "/v1/users/password", api.ResetPassword)
+	router.HandlerFunc(http.MethodPut, "/v1/users/email", api.ConfirmEmailChange)
+	router.Handler(http.MethodGet, "/v1/users/me", mid.RequireAuthenticatedUser(http.HandlerFunc(api.GetAccount)))
+	router.Handler(http.MethodPatch, "/v1/users/me", mid.RequireAuthenticatedUser(http.HandlerFunc(api.UpdateAccount)))
+	router.Handler(http.MethodDelete, "/v1/users/me", mid.RequireAuthenticatedUser(http.HandlerFunc(api.DeleteAccount)))
 }
 
 // RegisterUser registers user based on given information.
@@ -116,6 +157,7 @@ func (u *userAPI) RegisterUser(w http.ResponseWriter, r *http.Request) {
 // @Param token query string true "activation token"
 // @Success 200 {object} UserActivationResponse
 // @Failure 400 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse
 // @Failure 404 {object} reactor.ErrorResponse
 // @Failure 500 {object} reactor.ErrorResponse
 // @Router /v1/users/activation [put]
@@ -143,6 +185,9 @@ func (u *userAPI) ActivateUser(w http.ResponseWriter, r *http.Request) {
 			v.AddError("token", "invalid or expired activation token")
 			u.rc.FailedValidationResponse(w, r, v.Err())
 			return
+		case errors.KindIs(err, errors.KindNotPermitted):
+			u.rc.NotPermittedResponse(w, r)
+			return
 		case errors.KindIs(err, errors.KindEditConflict):
 			u.rc.EditConflictResponse(w, r)
 			return
@@ -183,3 +228,319 @@ func (u *userAPI) ActivateUser(w http.ResponseWriter, r *http.Request) {
 		u.rc.ServerErrorResponse(w, r, errors.E(op, err))
 	}
 }
+
+// ResetPassword sets a new password for user based on given password reset token.
+// @Summary Set a new password for user based on given password reset token.
+// @Description: All authentication tokens of the user are revoked.
+// @Accept  json
+// @Produce  json
+// @Param reqBody body UserPasswordResetRequest true "request body"
+// @Success 200 {object} UserPasswordResetResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 409 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/users/password [put]
+func (u *userAPI) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "userAPI.ResetPassword"
+
+	var input UserPasswordResetRequest
+
+	err := u.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		u.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	domain.ValidatePasswordPlaintext(v, input.Password)
+	domain.ValidateTokenPlaintext(v, input.Token)
+
+	if !v.Valid() {
+		u.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+
+	_, err = u.uu.ResetPassword(ctx, input.Token, input.Password)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			v.AddError("token", "invalid or expired password reset token")
+			u.rc.FailedValidationResponse(w, r, v.Err())
+		case errors.KindIs(err, errors.KindFailedValidation):
+			// The password is refused by the password policy.
+			var verrs validator.ValidationErrors
+			if !errors.As(err, &verrs) {
+				u.rc.ServerErrorResponse(w, r, errors.E(op, err))
+				return
+			}
+			u.rc.FailedValidationResponse(w, r, verrs)
+		case errors.KindIs(err, errors.KindEditConflict):
+			u.rc.EditConflictResponse(w, r)
+		default:
+			u.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = u.rc.WriteJSON(w, http.StatusOK, &UserPasswordResetResponse{Message: "your password was successfully reset"})
+	if err != nil {
+		u.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// GetAccount shows the account of the current user.
+// @Summary Show the account of the current user.
+// @Description: None.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success 200 {object} UserAccountResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/users/me [get]
+func (u *userAPI) GetAccount(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "userAPI.GetAccount"
+
+	user, err := u.account(r)
+	if err != nil {
+		u.accountErrorResponse(w, r, errors.E(op, err))
+		return
+	}
+
+	err = u.rc.WriteJSON(w, http.StatusOK, &UserAccountResponse{User: user})
+	if err != nil {
+		u.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// UpdateAccount updates the account of the current user.
+// @Summary Update name, email or password of the current user.
+// @Description: Changing email or password requires current_password. The new email address
+// @Description: is shown as pending_email until it's confirmed with the token emailed to it.
+// @Description: Changing password revokes every session of the user, including the current one.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param reqBody body UserAccountUpdateRequest true "request body"
+// @Success 200 {object} UserAccountResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 409 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/users/me [patch]
+func (u *userAPI) UpdateAccount(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "userAPI.UpdateAccount"
+
+	var input UserAccountUpdateRequest
+
+	err := u.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		u.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	changes := &domain.UserUpdate{
+		Name:            input.Name,
+		Email:           input.Email,
+		Password:        input.Password,
+		CurrentPassword: input.CurrentPassword,
+	}
+
+	v := validator.New()
+
+	if domain.ValidateUserUpdate(v, changes); !v.Valid() {
+		u.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	user, err := u.account(r)
+	if err != nil {
+		u.accountErrorResponse(w, r, errors.E(op, err))
+		return
+	}
+
+	// The new password mustn't contain the current nor the new name and email address.
+	if changes.Password != nil {
+		updated := *user
+		if changes.Name != nil {
+			updated.Name = *changes.Name
+		}
+		if changes.Email != nil {
+			updated.Email = *changes.Email
+		}
+
+		domain.ValidatePasswordPolicy(v, *changes.Password, user)
+		domain.ValidatePasswordPolicy(v, *changes.Password, &updated)
+
+		if !v.Valid() {
+			u.rc.FailedValidationResponse(w, r, v.Err())
+			return
+		}
+	}
+
+	ctx := r.Context()
+	err = u.uu.UpdateAccount(ctx, user, changes)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindInvalidCredentials):
+			v.AddError("current_password", "is incorrect")
+			u.rc.FailedValidationResponse(w, r, v.Err())
+		case errors.KindIs(err, errors.KindDuplicateEmail):
+			v.AddError("email", "a user with this email address already exists")
+			u.rc.FailedValidationResponse(w, r, v.Err())
+		case errors.KindIs(err, errors.KindEditConflict):
+			u.rc.EditConflictResponse(w, r)
+		default:
+			u.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = u.rc.WriteJSON(w, http.StatusOK, &UserAccountResponse{User: user})
+	if err != nil {
+		u.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// ConfirmEmailChange confirms the new email address of user based on given email change token.
+// @Summary Confirm the new email address of user based on given email change token.
+// @Description: None.
+// @Accept  json
+// @Produce  json
+// @Param reqBody body UserEmailChangeRequest true "request body"
+// @Success 200 {object} UserAccountResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 409 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/users/email [put]
+func (u *userAPI) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "userAPI.ConfirmEmailChange"
+
+	var input UserEmailChangeRequest
+
+	err := u.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		u.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if domain.ValidateTokenPlaintext(v, input.Token); !v.Valid() {
+		u.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+	user, err := u.uu.ConfirmEmailChange(ctx, input.Token)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			v.AddError("token", "invalid or expired email change token")
+			u.rc.FailedValidationResponse(w, r, v.Err())
+		case errors.KindIs(err, errors.KindDuplicateEmail):
+			v.AddError("email", "a user with this email address already exists")
+			u.rc.FailedValidationResponse(w, r, v.Err())
+		case errors.KindIs(err, errors.KindEditConflict):
+			u.rc.EditConflictResponse(w, r)
+		default:
+			u.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = u.rc.WriteJSON(w, http.StatusOK, &UserAccountResponse{User: user})
+	if err != nil {
+		u.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// DeleteAccount deletes the account of the current user.
+// @Summary Delete the account of the current user along with everything that belongs to the user.
+// @Description: Requires the password of the user.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param reqBody body UserAccountDeletionRequest true "request body"
+// @Success 200 {object} UserAccountDeletionResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 401 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/users/me [delete]
+func (u *userAPI) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	const op errors.Op = "userAPI.DeleteAccount"
+
+	var input UserAccountDeletionRequest
+
+	err := u.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		u.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if v.Check(input.Password != "", "password", "must be provided"); !v.Valid() {
+		u.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	user, err := u.account(r)
+	if err != nil {
+		u.accountErrorResponse(w, r, errors.E(op, err))
+		return
+	}
+
+	ctx := r.Context()
+	err = u.uu.DeleteAccount(ctx, user, input.Password)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindInvalidCredentials):
+			v.AddError("password", "is incorrect")
+			u.rc.FailedValidationResponse(w, r, v.Err())
+		default:
+			u.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = u.rc.WriteJSON(w, http.StatusOK, &UserAccountDeletionResponse{Message: "your account was successfully deleted"})
+	if err != nil {
+		u.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// account returns the current user. Signed authentication tokens only carry the ID
+// of the user, so the rest of the account is loaded from database in that case.
+func (u *userAPI) account(r *http.Request) (*domain.User, error) {
+	const op errors.Op = "userAPI.account"
+
+	user := helpers.ContextGetUser(r)
+	if !domain.IsSignedToken(helpers.ContextGetToken(r)) {
+		return user, nil
+	}
+
+	user, err := u.uu.GetByID(r.Context(), user.ID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return user, nil
+}
+
+// accountErrorResponse sends the response for the error returned by account, the account
+// of a signed token may have been deleted since the token was issued.
+func (u *userAPI) accountErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.KindIs(err, errors.KindRecordNotFound):
+		u.rc.InvalidAuthenticationTokenResponse(w, r)
+	default:
+		u.rc.ServerErrorResponse(w, r, err)
+	}
+}
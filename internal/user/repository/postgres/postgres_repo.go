@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/unknowntpo/todos/internal/domain"
@@ -48,13 +49,103 @@ func (ur *userRepo) Insert(ctx context.Context, user *domain.User) error {
 	return nil
 }
 
+// GetAll returns the users whose name or email contains search, if search is empty,
+// all users are returned.
+func (ur *userRepo) GetAll(ctx context.Context, search string, filters domain.Filters) ([]*domain.User, domain.Metadata, error) {
+	const op errors.Op = "userRepo.GetAll"
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, created_at, name, email, password_hash, activated, deactivated, COALESCE(pending_email, ''), version
+        FROM users
+        WHERE (name ILIKE '%%' || $1 || '%%' OR email ILIKE '%%' || $1 || '%%' OR $1 = '')
+        ORDER BY %s %s, id ASC
+        LIMIT $2 OFFSET $3`, filters.SortColumn(), filters.SortDirection())
+
+	rows, err := ur.DB.QueryContext(ctx, query, search, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, domain.Metadata{}, errors.E(op, errors.KindDatabase, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	users := []*domain.User{}
+
+	for rows.Next() {
+		var user domain.User
+
+		err := rows.Scan(
+			&totalRecords,
+			&user.ID,
+			&user.CreatedAt,
+			&user.Name,
+			&user.Email,
+			&user.Password.Hash,
+			&user.Activated,
+			&user.Deactivated,
+			&user.PendingEmail,
+			&user.Version,
+		)
+		if err != nil {
+			return nil, domain.Metadata{}, errors.E(op, errors.KindDatabase, err)
+		}
+
+		users = append(users, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, domain.Metadata{}, errors.E(op, errors.KindDatabase, err)
+	}
+
+	metadata := domain.CalculateMetadata(totalRecords, filters.CurrentPage, filters.PageSize)
+
+	return users, metadata, nil
+}
+
+// GetByID gets the User details from the database based on the user's id.
+// If there's no record, domain.ErrRecordNotFound will be returned.
+func (ur *userRepo) GetByID(ctx context.Context, userID int64) (*domain.User, error) {
+	const op errors.Op = "userRepo.GetByID"
+	if userID < 1 {
+		return nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	query := `
+        SELECT id, created_at, name, email, password_hash, activated, deactivated, COALESCE(pending_email, ''), version
+        FROM users
+        WHERE id = $1`
+
+	var user domain.User
+
+	err := ur.DB.QueryRowContext(ctx, query, userID).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Name,
+		&user.Email,
+		&user.Password.Hash,
+		&user.Activated,
+		&user.Deactivated,
+		&user.PendingEmail,
+		&user.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+		default:
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+	}
+
+	return &user, nil
+}
+
 // GetByEmail gets the User details from the database based on the user's email address.
 // If there's no record, domain.ErrRecordNotFound will be returned.
 func (ur *userRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	const op errors.Op = "userRepo.GetByEmail"
 
 	query := `
-        SELECT id, created_at, name, email, password_hash, activated, version
+        SELECT id, created_at, name, email, password_hash, activated, deactivated, COALESCE(pending_email, ''), version
         FROM users
         WHERE email = $1`
 
@@ -67,6 +158,8 @@ func (ur *userRepo) GetByEmail(ctx context.Context, email string) (*domain.User,
 		&user.Email,
 		&user.Password.Hash,
 		&user.Activated,
+		&user.Deactivated,
+		&user.PendingEmail,
 		&user.Version,
 	)
 
@@ -92,8 +185,8 @@ func (ur *userRepo) Update(ctx context.Context, user *domain.User) error {
 
 	query := `
         UPDATE users 
-        SET name = $1, email = $2, password_hash = $3, activated = $4, version = version + 1
-        WHERE id = $5 AND version = $6
+        SET name = $1, email = $2, password_hash = $3, activated = $4, deactivated = $5, pending_email = NULLIF($6, ''), version = version + 1
+        WHERE id = $7 AND version = $8
         RETURNING version`
 
 	args := []interface{}{
@@ -101,6 +194,8 @@ func (ur *userRepo) Update(ctx context.Context, user *domain.User) error {
 		user.Email,
 		user.Password.Hash,
 		user.Activated,
+		user.Deactivated,
+		user.PendingEmail,
 		user.ID,
 		user.Version,
 	}
@@ -129,7 +224,7 @@ func (ur *userRepo) GetForToken(ctx context.Context, tokenScope, tokenPlaintext
 	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
 
 	query := `
-        SELECT users.id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.version
+        SELECT users.id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.deactivated, COALESCE(users.pending_email, ''), users.version
         FROM users
         INNER JOIN tokens
         ON users.id = tokens.user_id
@@ -150,6 +245,8 @@ func (ur *userRepo) GetForToken(ctx context.Context, tokenScope, tokenPlaintext
 		&user.Email,
 		&user.Password.Hash,
 		&user.Activated,
+		&user.Deactivated,
+		&user.PendingEmail,
 		&user.Version,
 	)
 	if err != nil {
@@ -163,3 +260,29 @@ func (ur *userRepo) GetForToken(ctx context.Context, tokenScope, tokenPlaintext
 
 	return &user, nil
 }
+
+// Delete deletes the user with given userID, along with the tasks, tokens
+// and everything else that belongs to the user.
+func (ur *userRepo) Delete(ctx context.Context, userID int64) error {
+	const op errors.Op = "userRepo.Delete"
+
+	query := `
+        DELETE FROM users
+        WHERE id = $1`
+
+	result, err := ur.DB.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	return nil
+}
@@ -228,6 +228,33 @@ func (suite *UserRepoTestSuite) TestUpdate() {
 		suite.Equal(updatedUser.Name, newName, "user name should be equal")
 		suite.Equal(oldVersion+1, user.Version)
 	})
+	suite.Run("Success on pending email", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		repo := NewUserRepo(suite.db)
+		user := testutil.NewFakeUser(suite.T(), "Alice Smith", "alice@example.com", "pa55word", true)
+
+		ctx := context.TODO()
+		if err := repo.Insert(ctx, user); err != nil {
+			suite.T().Fatalf("failed to insert user into database: %v", err)
+		}
+
+		user.PendingEmail = "alice@example.org"
+		suite.NoError(repo.Update(ctx, user))
+
+		gotUser, err := repo.GetByID(ctx, user.ID)
+		suite.NoError(err)
+		suite.Equal("alice@example.com", gotUser.Email, "email should not change until it's confirmed")
+		suite.Equal("alice@example.org", gotUser.PendingEmail)
+
+		gotUser.PendingEmail = ""
+		suite.NoError(repo.Update(ctx, gotUser))
+
+		gotUser, err = repo.GetByID(ctx, user.ID)
+		suite.NoError(err)
+		suite.Equal("", gotUser.PendingEmail)
+	})
 	suite.Run("Fail on timeout", func() {
 		suite.TearDownTest()
 		suite.SetupTest()
@@ -406,3 +433,90 @@ func (suite *UserRepoTestSuite) TestGetForToken() {
 		suite.ErrorIs(err, domain.ErrRecordNotFound)
 	})
 }
+
+func (suite *UserRepoTestSuite) TestGetAll() {
+	suite.Run("Success with search", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		repo := NewUserRepo(suite.db)
+		ctx := context.TODO()
+		for _, user := range []*domain.User{
+			testutil.NewFakeUser(suite.T(), "Alice Smith", "alice@example.com", "pa55word", true),
+			testutil.NewFakeUser(suite.T(), "Ben Johnson", "ben@example.com", "pa55word", true),
+			testutil.NewFakeUser(suite.T(), "John Smith", "john@example.com", "pa55word", true),
+		} {
+			if err := repo.Insert(ctx, user); err != nil {
+				suite.T().Fatalf("failed to insert user into database: %v", err)
+			}
+		}
+
+		filters := domain.Filters{CurrentPage: 1, PageSize: 20, Sort: "-name", SortSafelist: []string{"-name"}}
+		users, metadata, err := repo.GetAll(ctx, "smith", filters)
+		suite.NoError(err)
+		suite.Equal(2, metadata.TotalRecords)
+		if suite.Len(users, 2) {
+			suite.Equal("John Smith", users[0].Name)
+			suite.Equal("Alice Smith", users[1].Name)
+		}
+	})
+}
+
+func (suite *UserRepoTestSuite) TestGetByID() {
+	suite.Run("Success", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		repo := NewUserRepo(suite.db)
+		user := testutil.NewFakeUser(suite.T(), "Alice Smith", "alice@example.com", "pa55word", true)
+
+		ctx := context.TODO()
+		if err := repo.Insert(ctx, user); err != nil {
+			suite.T().Fatalf("failed to insert user into database: %v", err)
+		}
+
+		gotUser, err := repo.GetByID(ctx, user.ID)
+		suite.NoError(err)
+		suite.Equal(user.Email, gotUser.Email, "email should be equal")
+		suite.Equal(user.Password.Hash, gotUser.Password.Hash, "password_hash should be equal")
+	})
+	suite.Run("Fail on record not found", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		repo := NewUserRepo(suite.db)
+
+		_, err := repo.GetByID(context.TODO(), 1)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+		suite.ErrorIs(err, domain.ErrRecordNotFound)
+	})
+}
+
+func (suite *UserRepoTestSuite) TestDelete() {
+	suite.Run("Success", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		repo := NewUserRepo(suite.db)
+		user := testutil.NewFakeUser(suite.T(), "Alice Smith", "alice@example.com", "pa55word", true)
+
+		ctx := context.TODO()
+		if err := repo.Insert(ctx, user); err != nil {
+			suite.T().Fatalf("failed to insert user into database: %v", err)
+		}
+
+		suite.NoError(repo.Delete(ctx, user.ID))
+
+		_, err := repo.GetByID(ctx, user.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+	suite.Run("Fail on record not found", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		repo := NewUserRepo(suite.db)
+
+		err := repo.Delete(context.TODO(), 1)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
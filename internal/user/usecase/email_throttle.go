@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// emailThrottle allows emailing each address at most once every interval, e.g. resending the
+// activation token, so that nobody can flood the inbox of someone else.
+type emailThrottle struct {
+	interval time.Duration
+
+	mu        sync.Mutex
+	sentAt    map[string]time.Time
+	lastSweep time.Time
+}
+
+func newEmailThrottle(interval time.Duration) *emailThrottle {
+	return &emailThrottle{interval: interval, sentAt: make(map[string]time.Time)}
+}
+
+// allow reports whether the email address can be emailed at now, and if so, records the time of it.
+func (et *emailThrottle) allow(email string, now time.Time) bool {
+	email = strings.ToLower(email)
+
+	et.mu.Lock()
+	defer et.mu.Unlock()
+
+	// Forget the addresses which can be emailed again once in a while, rather than on every
+	// call, so that the map doesn't grow forever.
+	if now.Sub(et.lastSweep) >= et.interval {
+		for e, t := range et.sentAt {
+			if now.Sub(t) >= et.interval {
+				delete(et.sentAt, e)
+			}
+		}
+		et.lastSweep = now
+	}
+
+	if t, found := et.sentAt[email]; found && now.Sub(t) < et.interval {
+		return false
+	}
+
+	et.sentAt[email] = now
+
+	return true
+}
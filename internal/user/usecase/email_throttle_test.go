@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailThrottle(t *testing.T) {
+	start := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Once every interval", func(t *testing.T) {
+		et := newEmailThrottle(5 * time.Minute)
+
+		assert.True(t, et.allow("alice@example.com", start))
+		assert.False(t, et.allow("Alice@example.com", start.Add(time.Minute)))
+		assert.True(t, et.allow("bob@example.com", start.Add(time.Minute)), "other email addresses shouldn't be affected")
+		assert.True(t, et.allow("alice@example.com", start.Add(5*time.Minute)))
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		et := newEmailThrottle(5 * time.Minute)
+
+		et.allow("alice@example.com", start)
+		et.allow("bob@example.com", start.Add(4*time.Minute))
+		assert.Len(t, et.sentAt, 2)
+
+		et.allow("carol@example.com", start.Add(3*time.Minute))
+		assert.Len(t, et.sentAt, 3, "entries shouldn't be swept before the interval since the last sweep")
+
+		et.allow("carol@example.com", start.Add(10*time.Minute))
+		assert.Len(t, et.sentAt, 1, "expired entries should be swept")
+	})
+}
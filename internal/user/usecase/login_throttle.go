@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/unknowntpo/todos/config"
+)
+
+// loginSweepInterval is how often the expired records are removed from the login throttle.
+const loginSweepInterval = time.Minute
+
+// loginFailures records the recent failed logins for an email address or a client IP.
+type loginFailures struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// loginThrottle slows down password guessing. After each failed login, the email address has to
+// wait for an exponentially growing backoff before the next attempt, and once there are too many
+// failures for the email address or the client IP, it's locked out for a while. Tracking email
+// addresses stops distributed guessing against one account, tracking IPs stops one client
+// guessing across many accounts.
+type loginThrottle struct {
+	cfg config.Login
+
+	mu        sync.Mutex
+	emails    map[string]*loginFailures
+	ips       map[string]*loginFailures
+	lastSweep time.Time
+}
+
+func newLoginThrottle(cfg *config.Login) *loginThrottle {
+	return &loginThrottle{
+		cfg:    *cfg,
+		emails: make(map[string]*loginFailures),
+		ips:    make(map[string]*loginFailures),
+	}
+}
+
+// wait returns how long the client at ip has to wait before it can try to log in as email,
+// zero means it can try now. An empty ip isn't tracked.
+func (lt *loginThrottle) wait(email, ip string, now time.Time) time.Duration {
+	email = strings.ToLower(email)
+
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	// Sweeping scans all the records, so do it once in a while rather than on every login.
+	if now.Sub(lt.lastSweep) >= loginSweepInterval {
+		lt.sweep(now)
+		lt.lastSweep = now
+	}
+
+	var wait time.Duration
+
+	if f, found := lt.emails[email]; found {
+		wait = f.lockedUntil.Sub(now)
+
+		if f.count > 0 && lt.cfg.Backoff > 0 {
+			if d := f.last.Add(lt.backoff(f.count)).Sub(now); d > wait {
+				wait = d
+			}
+		}
+	}
+
+	if f, found := lt.ips[ip]; found && ip != "" {
+		if d := f.lockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
+
+// fail records a failed login as email from ip, and reports whether it locks out the email address.
+func (lt *loginThrottle) fail(email, ip string, now time.Time) (locked bool) {
+	email = strings.ToLower(email)
+
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	if ip != "" {
+		lt.record(lt.ips, ip, lt.cfg.MaxFailuresPerIP, now)
+	}
+
+	return lt.record(lt.emails, email, lt.cfg.MaxFailures, now)
+}
+
+// reset forgets the failed logins as email after the user has logged in. The failures of the
+// client IP are kept, so that logging in to its own account doesn't let a client keep guessing.
+func (lt *loginThrottle) reset(email string) {
+	email = strings.ToLower(email)
+
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	delete(lt.emails, email)
+}
+
+// record counts a failure for key, and locks it out once there are max failures.
+// It reports whether the key got locked out.
+func (lt *loginThrottle) record(m map[string]*loginFailures, key string, max int, now time.Time) bool {
+	f, found := m[key]
+	if !found {
+		f = &loginFailures{}
+		m[key] = f
+	}
+
+	f.count++
+	f.last = now
+
+	if max > 0 && f.count >= max {
+		f.count = 0
+		f.lockedUntil = now.Add(lt.cfg.Lockout)
+		return true
+	}
+
+	return false
+}
+
+// backoff returns the wait after count failures, which is capped at the lockout duration.
+func (lt *loginThrottle) backoff(count int) time.Duration {
+	d := lt.cfg.Backoff
+	for i := 1; i < count && d < lt.cfg.Lockout; i++ {
+		d *= 2
+	}
+
+	if lt.cfg.Lockout > 0 && d > lt.cfg.Lockout {
+		d = lt.cfg.Lockout
+	}
+
+	return d
+}
+
+// sweep removes the records which have been neither locked out nor failed for the lockout duration,
+// so that the maps don't grow forever.
+func (lt *loginThrottle) sweep(now time.Time) {
+	for _, m := range []map[string]*loginFailures{lt.emails, lt.ips} {
+		for k, f := range m {
+			if !now.Before(f.lockedUntil) && now.Sub(f.last) >= lt.cfg.Lockout {
+				delete(m, k)
+			}
+		}
+	}
+}
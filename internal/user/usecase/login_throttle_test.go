@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/unknowntpo/todos/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	start := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Exponential backoff", func(t *testing.T) {
+		lt := newLoginThrottle(&config.Login{Backoff: time.Second, Lockout: 15 * time.Minute})
+
+		assert.Zero(t, lt.wait("alice@example.com", "", start))
+
+		now := start
+		for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second} {
+			assert.False(t, lt.fail("alice@example.com", "", now))
+			assert.Equal(t, want, lt.wait("Alice@example.com", "", now))
+			assert.Zero(t, lt.wait("bob@example.com", "", now), "other email addresses shouldn't be affected")
+			now = now.Add(want)
+		}
+
+		assert.Zero(t, lt.wait("alice@example.com", "", now))
+
+		lt.reset("alice@example.com")
+		assert.False(t, lt.fail("alice@example.com", "", now))
+		assert.Equal(t, time.Second, lt.wait("alice@example.com", "", now), "backoff should start over after reset")
+	})
+
+	t.Run("Backoff capped at lockout duration", func(t *testing.T) {
+		lt := newLoginThrottle(&config.Login{Backoff: time.Minute, Lockout: 5 * time.Minute})
+
+		for i := 0; i < 10; i++ {
+			lt.fail("alice@example.com", "", start)
+		}
+
+		assert.Equal(t, 5*time.Minute, lt.wait("alice@example.com", "", start))
+	})
+
+	t.Run("Lockout", func(t *testing.T) {
+		lt := newLoginThrottle(&config.Login{MaxFailures: 3, MaxFailuresPerIP: 4, Lockout: 15 * time.Minute})
+
+		assert.False(t, lt.fail("alice@example.com", "203.0.113.7", start))
+		assert.False(t, lt.fail("alice@example.com", "203.0.113.7", start))
+		assert.True(t, lt.fail("alice@example.com", "203.0.113.7", start), "third failure should lock out the email address")
+
+		assert.Equal(t, 15*time.Minute, lt.wait("alice@example.com", "198.51.100.1", start))
+		assert.Zero(t, lt.wait("bob@example.com", "198.51.100.1", start))
+
+		assert.False(t, lt.fail("bob@example.com", "203.0.113.7", start), "locking out the IP isn't reported")
+		assert.Equal(t, 15*time.Minute, lt.wait("bob@example.com", "203.0.113.7", start))
+
+		later := start.Add(15 * time.Minute)
+		assert.Zero(t, lt.wait("alice@example.com", "203.0.113.7", later))
+		assert.Empty(t, lt.emails, "expired records should be swept")
+		assert.Empty(t, lt.ips, "expired records should be swept")
+
+		lt.fail("alice@example.com", "", start)
+		lt.wait("bob@example.com", "", later.Add(30*time.Second))
+		assert.Len(t, lt.emails, 1, "records shouldn't be swept more often than loginSweepInterval")
+		lt.wait("bob@example.com", "", later.Add(loginSweepInterval))
+		assert.Empty(t, lt.emails, "expired records should be swept")
+	})
+
+	t.Run("Failures forgotten after lockout duration", func(t *testing.T) {
+		lt := newLoginThrottle(&config.Login{MaxFailures: 2, Lockout: 15 * time.Minute})
+
+		assert.False(t, lt.fail("alice@example.com", "", start))
+		lt.wait("alice@example.com", "", start.Add(15*time.Minute))
+		assert.False(t, lt.fail("alice@example.com", "", start.Add(15*time.Minute)))
+	})
+}
@@ -4,37 +4,65 @@ import (
 	"context"
 	"time"
 
+	"github.com/unknowntpo/todos/config"
 	"github.com/unknowntpo/todos/internal/domain"
 	"github.com/unknowntpo/todos/internal/domain/errors"
 	"github.com/unknowntpo/todos/internal/logger"
 	"github.com/unknowntpo/todos/internal/mailer"
+	"github.com/unknowntpo/todos/internal/tracing"
 	"github.com/unknowntpo/todos/pkg/naivepool"
+	"github.com/unknowntpo/todos/pkg/validator"
 )
 
+// activationResendInterval is the minimum interval between two activation tokens
+// resent to the same email address.
+const activationResendInterval = 5 * time.Minute
+
+// passwordResetInterval is the minimum interval between two password reset tokens
+// emailed to the same email address.
+const passwordResetInterval = 5 * time.Minute
+
 type userUsecase struct {
 	userRepo       domain.UserRepository
 	tokenUsecase   domain.TokenUsecase
+	mfaUsecase     domain.MFAUsecase
+	permissionRepo domain.PermissionRepository
 	pool           *naivepool.Pool
 	mailer         *mailer.Mailer
 	logger         logger.Logger
+	loginThrottle  *loginThrottle
 	contextTimeout time.Duration
+
+	// activationResend and passwordReset limit how often the tokens are emailed to each
+	// email address.
+	activationResend *emailThrottle
+	passwordReset    *emailThrottle
 }
 
 func NewUserUsecase(
 	ur domain.UserRepository,
 	tu domain.TokenUsecase,
+	mu domain.MFAUsecase,
+	pr domain.PermissionRepository,
 	p *naivepool.Pool,
 	mailer *mailer.Mailer,
 	logger logger.Logger,
+	lc *config.Login,
 	timeout time.Duration,
 ) domain.UserUsecase {
 	return &userUsecase{
 		userRepo:       ur,
 		tokenUsecase:   tu,
+		mfaUsecase:     mu,
+		permissionRepo: pr,
 		pool:           p,
 		mailer:         mailer,
 		logger:         logger,
+		loginThrottle:  newLoginThrottle(lc),
 		contextTimeout: timeout,
+
+		activationResend: newEmailThrottle(activationResendInterval),
+		passwordReset:    newEmailThrottle(passwordResetInterval),
 	}
 }
 
@@ -68,6 +96,21 @@ func (uu *userUsecase) Authenticate(ctx context.Context, tokenScope, tokenPlaint
 	return user, nil
 }
 
+// GetByID returns the user with given userID.
+func (uu *userUsecase) GetByID(ctx context.Context, userID int64) (*domain.User, error) {
+	const op errors.Op = "userUsecase.GetByID"
+
+	ctx, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	user, err := uu.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return user, nil
+}
+
 func (uu *userUsecase) Update(ctx context.Context, user *domain.User) error {
 	const op errors.Op = "userUsecase.Update"
 
@@ -82,48 +125,193 @@ func (uu *userUsecase) Update(ctx context.Context, user *domain.User) error {
 	return nil
 }
 
-// Login performs login operation and returns token if succeed,
+// Login performs login operation and returns a short-lived authentication token
+// along with a refresh token if succeed,
+// if the user has enabled MFA, it returns an MFA challenge token and nil refresh token instead,
+// which has to be exchanged by CompleteLogin with the second factor,
 // if failed, it returns nil and errors.ErrInvalidCredentials error,
+// if the user hasn't been activated, it returns nil and domain.ErrInactiveAccount error,
+// if the email address or the client IP is throttled after failed logins, it returns nil and
+// *domain.RetryAfterError with kind errors.KindRateLimitExceeded,
 // if some internal server error happened, returns nil and wrapped error.
-func (uu *userUsecase) Login(ctx context.Context, email, password string) (*domain.Token, error) {
+func (uu *userUsecase) Login(ctx context.Context, email, password string) (*domain.Token, *domain.Token, error) {
 	const op errors.Op = "userUsecase.Login"
 
 	ctx, cancel := context.WithTimeout(ctx, uu.contextTimeout)
 	defer cancel()
 
+	ip := domain.ClientFromContext(ctx).IP
+
+	if wait := uu.loginThrottle.wait(email, ip, time.Now()); wait > 0 {
+		return nil, nil, errors.E(op, errors.UserEmail(email), errors.KindRateLimitExceeded, &domain.RetryAfterError{After: wait})
+	}
+
 	user, err := uu.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return nil, errors.E(op, errors.KindInvalidCredentials, err)
+		if errors.KindIs(err, errors.KindRecordNotFound) {
+			// Unknown email addresses are throttled as well, so that the caller can't tell
+			// whether the email address is registered.
+			uu.loginThrottle.fail(email, ip, time.Now())
+		}
+		return nil, nil, errors.E(op, errors.KindInvalidCredentials, err)
 	}
 
 	// Check if the provided password matches the actual password for the user.
 	match, err := user.Password.Matches(password)
 	if err != nil {
-		return nil, errors.E(op, err)
+		return nil, nil, errors.E(op, err)
 	}
 
 	if !match {
-		return nil, errors.E(op, errors.KindInvalidCredentials, err)
+		uu.failLogin(ctx, user, ip)
+		return nil, nil, errors.E(op, errors.KindInvalidCredentials, domain.ErrInvalidCredentials)
 	}
 
-	// Otherwise, if the password is correct, we generate a new token with a 24-hour
-	// expiry time and the scope 'authentication'.
+	// Only tell the client about the activation state once the password is proven,
+	// so that it can ask for a new activation token if needed.
+	if !user.Activated {
+		return nil, nil, errors.E(op, errors.KindInactiveAccount, domain.ErrInactiveAccount)
+	}
+
+	if user.Password.NeedsRehash() {
+		uu.rehashPassword(ctx, user, password)
+	}
+
+	enabled, err := uu.mfaUsecase.Enabled(ctx, user.ID)
+	if err != nil {
+		return nil, nil, errors.E(op, err)
+	}
+
+	// The failures are kept until the second factor is proven, otherwise anyone who knows
+	// the password could keep guessing the code by logging in again.
+	if enabled {
+		challenge, err := domain.GenerateToken(user.ID, domain.MFAChallengeTTL, domain.ScopeMFAChallenge)
+		if err != nil {
+			return nil, nil, errors.E(op, err)
+		}
+
+		err = uu.tokenUsecase.Insert(ctx, challenge)
+		if err != nil {
+			return nil, nil, errors.E(op, err)
+		}
+
+		return challenge, nil, nil
+	}
+
+	// Otherwise, if the password is correct, we issue a new pair of authentication
+	// and refresh tokens.
 	// At here, user is valid!
-	token, err := domain.GenerateToken(user.ID, 24*time.Hour, domain.ScopeAuthentication)
+	access, refresh, err := uu.tokenUsecase.IssueAuthTokens(ctx, user.ID)
 	if err != nil {
-		return nil, errors.E(op, err)
+		return nil, nil, errors.E(op, err)
 	}
 
-	err = uu.tokenUsecase.Insert(ctx, token)
+	uu.loginThrottle.reset(user.Email)
+
+	return access, refresh, nil
+}
+
+// CompleteLogin exchanges the MFA challenge token issued by Login and the TOTP code or recovery code
+// of the user for a pair of authentication and refresh tokens. The challenge token can only be
+// exchanged once. If the challenge token or the code is invalid, the error with kind
+// errors.KindInvalidCredentials is returned. Wrong codes count as failed logins, so they're
+// throttled the same way as in Login.
+func (uu *userUsecase) CompleteLogin(ctx context.Context, challengePlaintext, code string) (*domain.Token, *domain.Token, error) {
+	const op errors.Op = "userUsecase.CompleteLogin"
+
+	ctx, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	user, err := uu.userRepo.GetForToken(ctx, domain.ScopeMFAChallenge, challengePlaintext)
 	if err != nil {
-		return nil, errors.E(op, err)
+		if errors.KindIs(err, errors.KindRecordNotFound) {
+			return nil, nil, errors.E(op, errors.KindInvalidCredentials, err)
+		}
+		return nil, nil, errors.E(op, err)
+	}
+
+	ip := domain.ClientFromContext(ctx).IP
+
+	if wait := uu.loginThrottle.wait(user.Email, ip, time.Now()); wait > 0 {
+		return nil, nil, errors.E(op, errors.UserEmail(user.Email), errors.KindRateLimitExceeded, &domain.RetryAfterError{After: wait})
+	}
+
+	err = uu.mfaUsecase.Verify(ctx, user.ID, code)
+	if err != nil {
+		if errors.KindIs(err, errors.KindInvalidCredentials) {
+			uu.failLogin(ctx, user, ip)
+		}
+		return nil, nil, errors.E(op, err)
+	}
+
+	err = uu.tokenUsecase.DeleteAllForUser(ctx, domain.ScopeMFAChallenge, user.ID)
+	if err != nil {
+		return nil, nil, errors.E(op, err)
+	}
+
+	access, refresh, err := uu.tokenUsecase.IssueAuthTokens(ctx, user.ID)
+	if err != nil {
+		return nil, nil, errors.E(op, err)
 	}
 
-	return token, nil
+	uu.loginThrottle.reset(user.Email)
+
+	return access, refresh, nil
+}
+
+// rehashPassword replaces the password hash of the user made with outdated parameters, now that
+// the plaintext password is known. The login doesn't depend on it, so the failure is only logged,
+// and the hash is replaced on the next login instead.
+func (uu *userUsecase) rehashPassword(ctx context.Context, user *domain.User, password string) {
+	const op errors.Op = "userUsecase.rehashPassword"
+
+	err := user.Password.Set(password)
+	if err == nil {
+		err = uu.userRepo.Update(ctx, user)
+	}
+
+	if err != nil {
+		logger.FromContext(ctx, uu.logger).PrintError(errors.E(op, errors.UserEmail(user.Email), errors.Msg("failed to rehash password"), err), nil)
+	}
+}
+
+// failLogin records the failed login of the user from ip, and emails the user if it locks out
+// the account, so that the user knows someone is guessing their password.
+func (uu *userUsecase) failLogin(ctx context.Context, user *domain.User, ip string) {
+	if !uu.loginThrottle.fail(user.Email, ip, time.Now()) {
+		return
+	}
+
+	log := logger.FromContext(ctx, uu.logger)
+
+	const notifyOp errors.Op = "userUsecase.failLogin.notify"
+
+	uu.pool.Schedule(tracing.Job(ctx, notifyOp, func(ctx context.Context) {
+		data := map[string]interface{}{
+			"lockoutMinutes": int(uu.loginThrottle.cfg.Lockout.Minutes()),
+			"ip":             ip,
+		}
+
+		err := uu.mailer.Send(ctx, user.Email, "account_locked.tmpl", data)
+		if err != nil {
+			log.PrintError(
+				errors.E(
+					notifyOp,
+					errors.UserEmail(user.Email),
+					errors.KindInternal,
+					errors.Msg("failed to send account locked email"),
+					err,
+				),
+				nil,
+			)
+			return
+		}
+	}))
 }
 
 // Activate performs user activation and returns user, nil if succeed,
 // if failed, it returns nil and errors.ErrInvalidCredentials error,
+// if the user has been deactivated by an admin, it returns nil and domain.ErrNotPermitted error,
 // if some internal server error happened, returns nil and wrapped error.
 func (uu *userUsecase) Activate(ctx context.Context, tokenPlaintext string) (*domain.User, error) {
 	const op errors.Op = "userUsecase.Activate"
@@ -136,6 +324,11 @@ func (uu *userUsecase) Activate(ctx context.Context, tokenPlaintext string) (*do
 		return nil, errors.E(op, err)
 	}
 
+	// Only an admin can reactivate the user who has been deactivated.
+	if user.Deactivated {
+		return nil, errors.E(op, errors.UserEmail(user.Email), errors.KindNotPermitted, domain.ErrNotPermitted)
+	}
+
 	// Update the user's activation status.
 	user.Activated = true
 
@@ -157,7 +350,7 @@ func (uu *userUsecase) Activate(ctx context.Context, tokenPlaintext string) (*do
 }
 
 // Register registers a user and send welcome email with activation token, then insert the user into
-// database by calling userRepo.Insert.
+// database by calling userRepo.Insert and grants the default permissions to the user.
 // It returns error if exists.
 func (uu *userUsecase) Register(ctx context.Context, user *domain.User) error {
 	const op errors.Op = "userUsecase.Register"
@@ -171,6 +364,12 @@ func (uu *userUsecase) Register(ctx context.Context, user *domain.User) error {
 		return errors.E(op, err)
 	}
 
+	// Grant the default permissions, so that the user can manage their tasks.
+	err = uu.permissionRepo.AddForUser(ctx, user.ID, domain.DefaultPermissions)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
 	// After the user record has been created in the database, generate a new activation
 	// token for the user, and insert it to the database.
 	token, err := domain.GenerateToken(user.ID, 3*24*time.Hour, domain.ScopeActivation)
@@ -183,19 +382,21 @@ func (uu *userUsecase) Register(ctx context.Context, user *domain.User) error {
 		return errors.E(op, err)
 	}
 
-	uu.pool.Schedule(func() {
-		const op errors.Op = "userAPI.RegisterUser"
+	log := logger.FromContext(ctx, uu.logger)
+
+	const sendOp errors.Op = "userUsecase.Register.sendWelcome"
 
+	uu.pool.Schedule(tracing.Job(ctx, sendOp, func(ctx context.Context) {
 		data := map[string]interface{}{
 			"activationToken": token.Plaintext,
 			"userID":          user.ID,
 		}
 
-		err = uu.mailer.Send(user.Email, "user_welcome.tmpl", data)
+		err := uu.mailer.Send(ctx, user.Email, "user_welcome.tmpl", data)
 		if err != nil {
-			uu.logger.PrintError(
+			log.PrintError(
 				errors.E(
-					op,
+					sendOp,
 					errors.UserEmail(user.Email),
 					errors.KindInternal,
 					errors.Msg("failed to send welcome email"),
@@ -205,7 +406,357 @@ func (uu *userUsecase) Register(ctx context.Context, user *domain.User) error {
 			)
 			return
 		}
-	})
+	}))
+
+	return nil
+}
+
+// RequestPasswordReset emails a password reset token to the user who owns the given email address.
+// The tokens are emailed to each email address at most once every passwordResetInterval.
+// If there's no such user or the email address has been emailed a token recently, it returns nil
+// without doing anything, so that the caller can't tell whether the email address is registered.
+func (uu *userUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+	const op errors.Op = "userUsecase.RequestPasswordReset"
+
+	ctx, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	if !uu.passwordReset.allow(email, time.Now()) {
+		return nil
+	}
+
+	user, err := uu.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.KindIs(err, errors.KindRecordNotFound) {
+			return nil
+		}
+		return errors.E(op, err)
+	}
+
+	token, err := domain.GenerateToken(user.ID, 45*time.Minute, domain.ScopePasswordReset)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = uu.tokenUsecase.Insert(ctx, token)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	log := logger.FromContext(ctx, uu.logger)
+
+	const sendOp errors.Op = "userUsecase.RequestPasswordReset.sendToken"
+
+	uu.pool.Schedule(tracing.Job(ctx, sendOp, func(ctx context.Context) {
+		data := map[string]interface{}{
+			"passwordResetToken": token.Plaintext,
+		}
+
+		err := uu.mailer.Send(ctx, user.Email, "password_reset.tmpl", data)
+		if err != nil {
+			log.PrintError(
+				errors.E(
+					sendOp,
+					errors.UserEmail(user.Email),
+					errors.KindInternal,
+					errors.Msg("failed to send password reset email"),
+					err,
+				),
+				nil,
+			)
+			return
+		}
+	}))
+
+	return nil
+}
+
+// ResetPassword sets the password of the user who owns the given password reset token,
+// then deletes all password reset, authentication, refresh and personal access tokens of the user,
+// so that every existing session has to log in again with the new password.
+// If the token is invalid or expired, the error with kind errors.KindRecordNotFound
+// is returned. If the password is refused by domain.PasswordPolicy, the error with kind
+// errors.KindFailedValidation wrapping validator.ValidationErrors is returned.
+func (uu *userUsecase) ResetPassword(ctx context.Context, tokenPlaintext, password string) (*domain.User, error) {
+	const op errors.Op = "userUsecase.ResetPassword"
+
+	ctx, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	user, err := uu.userRepo.GetForToken(ctx, domain.ScopePasswordReset, tokenPlaintext)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	// The password can only be checked against the personal information of the user here,
+	// now that the user is known.
+	v := validator.New()
+	if domain.ValidatePasswordPolicy(v, password, user); !v.Valid() {
+		return nil, errors.E(op, errors.KindFailedValidation, v.Err())
+	}
+
+	err = user.Password.Set(password)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	err = uu.userRepo.Update(ctx, user)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	for _, scope := range []string{domain.ScopePasswordReset, domain.ScopeAuthentication, domain.ScopeRefresh} {
+		err = uu.tokenUsecase.DeleteAllForUser(ctx, scope, user.ID)
+		if err != nil {
+			return nil, errors.E(op, err)
+		}
+	}
+
+	err = uu.tokenUsecase.DeleteAllPersonalTokens(ctx, user.ID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return user, nil
+}
+
+// UpdateAccount applies the changes the user makes to their own account. Changing email or
+// password requires the current password, otherwise the error with kind
+// errors.KindInvalidCredentials is returned. Changing the password revokes all authentication
+// and refresh tokens of the user. A new email address doesn't take effect immediately, it's
+// kept as the pending email and a confirmation token is emailed to it.
+func (uu *userUsecase) UpdateAccount(ctx context.Context, user *domain.User, changes *domain.UserUpdate) error {
+	const op errors.Op = "userUsecase.UpdateAccount"
+
+	ctx, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	if changes.Email != nil || changes.Password != nil {
+		match, err := user.Password.Matches(changes.CurrentPassword)
+		if err != nil {
+			return errors.E(op, err)
+		}
+		if !match {
+			return errors.E(op, errors.KindInvalidCredentials, domain.ErrInvalidCredentials)
+		}
+	}
+
+	if changes.Name != nil {
+		user.Name = *changes.Name
+	}
+
+	if changes.Password != nil {
+		err := user.Password.Set(*changes.Password)
+		if err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	emailChanged := changes.Email != nil && *changes.Email != user.Email
+	if emailChanged {
+		_, err := uu.userRepo.GetByEmail(ctx, *changes.Email)
+		switch {
+		case err == nil:
+			return errors.E(op, errors.KindDuplicateEmail, domain.ErrDuplicateEmail)
+		case errors.KindIs(err, errors.KindRecordNotFound):
+		default:
+			return errors.E(op, err)
+		}
+
+		user.PendingEmail = *changes.Email
+	}
+
+	err := uu.userRepo.Update(ctx, user)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	// As with a password reset, every existing session has to log in again with the new
+	// password, including the one of whoever may have stolen the old one.
+	if changes.Password != nil {
+		for _, scope := range []string{domain.ScopeAuthentication, domain.ScopeRefresh} {
+			err = uu.tokenUsecase.DeleteAllForUser(ctx, scope, user.ID)
+			if err != nil {
+				return errors.E(op, err)
+			}
+		}
+	}
+
+	if !emailChanged {
+		return nil
+	}
+
+	// Only the latest requested email address can be confirmed.
+	err = uu.tokenUsecase.DeleteAllForUser(ctx, domain.ScopeEmailChange, user.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	token, err := domain.GenerateToken(user.ID, 24*time.Hour, domain.ScopeEmailChange)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = uu.tokenUsecase.Insert(ctx, token)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	pendingEmail := user.PendingEmail
+
+	log := logger.FromContext(ctx, uu.logger)
+
+	const sendOp errors.Op = "userUsecase.UpdateAccount.sendToken"
+
+	uu.pool.Schedule(tracing.Job(ctx, sendOp, func(ctx context.Context) {
+		data := map[string]interface{}{
+			"emailChangeToken": token.Plaintext,
+		}
+
+		err := uu.mailer.Send(ctx, pendingEmail, "email_change.tmpl", data)
+		if err != nil {
+			log.PrintError(
+				errors.E(
+					sendOp,
+					errors.UserEmail(pendingEmail),
+					errors.KindInternal,
+					errors.Msg("failed to send email change confirmation"),
+					err,
+				),
+				nil,
+			)
+			return
+		}
+	}))
+
+	return nil
+}
+
+// ConfirmEmailChange replaces the email address of the user who owns the given email change token
+// with the pending one. If the token is invalid or expired, the error with kind
+// errors.KindRecordNotFound is returned.
+func (uu *userUsecase) ConfirmEmailChange(ctx context.Context, tokenPlaintext string) (*domain.User, error) {
+	const op errors.Op = "userUsecase.ConfirmEmailChange"
+
+	ctx, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	user, err := uu.userRepo.GetForToken(ctx, domain.ScopeEmailChange, tokenPlaintext)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	if user.PendingEmail == "" {
+		return nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	user.Email = user.PendingEmail
+	user.PendingEmail = ""
+
+	err = uu.userRepo.Update(ctx, user)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	err = uu.tokenUsecase.DeleteAllForUser(ctx, domain.ScopeEmailChange, user.ID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return user, nil
+}
+
+// DeleteAccount deletes the account of the user after checking the password, everything that
+// belongs to the user is deleted along with it. If the password doesn't match, the error with
+// kind errors.KindInvalidCredentials is returned.
+func (uu *userUsecase) DeleteAccount(ctx context.Context, user *domain.User, password string) error {
+	const op errors.Op = "userUsecase.DeleteAccount"
+
+	ctx, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	match, err := user.Password.Matches(password)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !match {
+		return errors.E(op, errors.KindInvalidCredentials, domain.ErrInvalidCredentials)
+	}
+
+	err = uu.userRepo.Delete(ctx, user.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// ResendActivation deletes the activation tokens of the unactivated user who owns the given email
+// address and emails a new one. The tokens are resent to each email address at most once every
+// activationResendInterval, otherwise the error with kind errors.KindRateLimitExceeded is returned.
+// If there's no such user or the user has been activated or deactivated by an admin, it returns nil
+// without doing anything, so that the caller can't tell whether the email address is registered.
+func (uu *userUsecase) ResendActivation(ctx context.Context, email string) error {
+	const op errors.Op = "userUsecase.ResendActivation"
+
+	ctx, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	if !uu.activationResend.allow(email, time.Now()) {
+		return errors.E(op, errors.UserEmail(email), errors.KindRateLimitExceeded, domain.ErrRateLimitExceeded)
+	}
+
+	user, err := uu.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.KindIs(err, errors.KindRecordNotFound) {
+			return nil
+		}
+		return errors.E(op, err)
+	}
+
+	if user.Activated || user.Deactivated {
+		return nil
+	}
+
+	err = uu.tokenUsecase.DeleteAllForUser(ctx, domain.ScopeActivation, user.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	token, err := domain.GenerateToken(user.ID, 3*24*time.Hour, domain.ScopeActivation)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = uu.tokenUsecase.Insert(ctx, token)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	log := logger.FromContext(ctx, uu.logger)
+
+	const sendOp errors.Op = "userUsecase.ResendActivation.sendToken"
+
+	uu.pool.Schedule(tracing.Job(ctx, sendOp, func(ctx context.Context) {
+		data := map[string]interface{}{
+			"activationToken": token.Plaintext,
+		}
+
+		err := uu.mailer.Send(ctx, user.Email, "token_activation.tmpl", data)
+		if err != nil {
+			log.PrintError(
+				errors.E(
+					sendOp,
+					errors.UserEmail(user.Email),
+					errors.KindInternal,
+					errors.Msg("failed to send activation email"),
+					err,
+				),
+				nil,
+			)
+			return
+		}
+	}))
 
 	return nil
 }
@@ -3,6 +3,7 @@ package usecase
 import (
 	"bytes"
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,21 +16,26 @@ import (
 	"github.com/unknowntpo/todos/internal/mailer"
 	"github.com/unknowntpo/todos/internal/testutil"
 	"github.com/unknowntpo/todos/pkg/naivepool"
+	"github.com/unknowntpo/todos/pkg/validator"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type UserUsecaseTestSuite struct {
 	suite.Suite
-	userRepo   *_repoMock.UserRepository
-	tokenRepo  *_repoMock.TokenRepository
-	logBuf     *bytes.Buffer
-	logger     logger.Logger
-	pool       *naivepool.Pool
-	poolCancel context.CancelFunc
-	mailer     *mailer.Mailer
-	fakeUser   *domain.User
+	userRepo       *_repoMock.UserRepository
+	tokenUsecase   *_repoMock.TokenUsecase
+	mfaUsecase     *_repoMock.MFAUsecase
+	permissionRepo *_repoMock.PermissionRepository
+	logBuf         *bytes.Buffer
+	logger         logger.Logger
+	pool           *naivepool.Pool
+	poolCancel     context.CancelFunc
+	mailer         *mailer.Mailer
+	loginConfig    *config.Login
+	fakeUser       *domain.User
 }
 
 func (suite *UserUsecaseTestSuite) SetupSuite() {
@@ -53,10 +59,13 @@ func (suite *UserUsecaseTestSuite) TearDownSuite() {
 // SetupTest do migration up for each test.
 func (suite *UserUsecaseTestSuite) SetupTest() {
 	suite.userRepo = new(_repoMock.UserRepository)
-	suite.tokenRepo = new(_repoMock.TokenRepository)
+	suite.tokenUsecase = new(_repoMock.TokenUsecase)
+	suite.mfaUsecase = new(_repoMock.MFAUsecase)
+	suite.permissionRepo = new(_repoMock.PermissionRepository)
 	suite.logBuf = new(bytes.Buffer)
 	suite.logger = zerolog.New(suite.logBuf)
 	suite.mailer = mailer.New(&config.Smtp{})
+	suite.loginConfig = &config.Login{MaxFailures: 3, MaxFailuresPerIP: 5, Backoff: time.Second, Lockout: 15 * time.Minute}
 	suite.fakeUser = testutil.NewFakeUser(suite.T(), "Alice Smith", "alice@example.com", "pa55word", false)
 
 }
@@ -65,10 +74,13 @@ func (suite *UserUsecaseTestSuite) SetupTest() {
 // this test won't affect to the result of next test.
 func (suite *UserUsecaseTestSuite) TearDownTest() {
 	suite.userRepo = nil
-	suite.tokenRepo = nil
+	suite.tokenUsecase = nil
+	suite.mfaUsecase = nil
+	suite.permissionRepo = nil
 	suite.logBuf = nil
 	suite.logger = nil
 	suite.mailer = nil
+	suite.loginConfig = nil
 	suite.fakeUser = nil
 }
 
@@ -86,7 +98,7 @@ func (suite *UserUsecaseTestSuite) TestInsert() {
 			return user.Name == suite.fakeUser.Name && user.Email == suite.fakeUser.Email
 		})).Return(nil)
 
-		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenRepo, suite.pool, suite.mailer, suite.logger, 3*time.Second)
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
 
 		ctx := context.TODO()
 		err := userUsecase.Insert(ctx, suite.fakeUser)
@@ -107,7 +119,7 @@ func (suite *UserUsecaseTestSuite) TestInsert() {
 			return user.Name == suite.fakeUser.Name && user.Email == suite.fakeUser.Email
 		})).Return(wantErr)
 
-		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenRepo, suite.pool, suite.mailer, suite.logger, 3*time.Second)
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
 
 		ctx := context.TODO()
 
@@ -127,19 +139,84 @@ func (suite *UserUsecaseTestSuite) TestLogin() {
 		suite.TearDownTest()
 		suite.SetupTest()
 
-		suite.tokenRepo.On("Insert", mock.Anything, mock.MatchedBy(func(token *domain.Token) bool {
-			return token.Scope == domain.ScopeAuthentication
-		})).Return(nil)
+		access, err := domain.GenerateToken(suite.fakeUser.ID, 15*time.Minute, domain.ScopeAuthentication)
+		suite.Require().NoError(err)
+		refresh, err := domain.GenerateToken(suite.fakeUser.ID, 7*24*time.Hour, domain.ScopeRefresh)
+		suite.Require().NoError(err)
+
+		suite.tokenUsecase.On("IssueAuthTokens", mock.Anything, suite.fakeUser.ID).Return(access, refresh, nil)
+		suite.fakeUser.Activated = true
 		suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(suite.fakeUser, nil)
+		suite.mfaUsecase.On("Enabled", mock.Anything, suite.fakeUser.ID).Return(false, nil)
 
-		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenRepo, suite.pool, suite.mailer, suite.logger, 3*time.Second)
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
 
 		ctx := context.TODO()
-		token, err := userUsecase.Login(ctx, "alice@example.com", "pa55word")
+		token, refreshToken, err := userUsecase.Login(ctx, "alice@example.com", "pa55word")
 		suite.Equal(domain.ScopeAuthentication, token.Scope)
+		suite.Equal(domain.ScopeRefresh, refreshToken.Scope)
+
+		suite.NoError(err)
+		suite.userRepo.AssertExpectations(suite.T())
+
+		suite.TearDownTest()
+	})
+
+	suite.Run("Rehash outdated password hash", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		// The user signed up when passwords were hashed with bcrypt.
+		hash, err := bcrypt.GenerateFromPassword([]byte("pa55word"), bcrypt.MinCost)
+		suite.Require().NoError(err)
+		suite.fakeUser.Password.Hash = hash
+		suite.fakeUser.Activated = true
+
+		access, err := domain.GenerateToken(suite.fakeUser.ID, 15*time.Minute, domain.ScopeAuthentication)
+		suite.Require().NoError(err)
+		refresh, err := domain.GenerateToken(suite.fakeUser.ID, 7*24*time.Hour, domain.ScopeRefresh)
+		suite.Require().NoError(err)
+
+		suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(suite.fakeUser, nil)
+		suite.userRepo.On("Update", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+			return bytes.HasPrefix(user.Password.Hash, []byte("$argon2id$")) && !user.Password.NeedsRehash()
+		})).Return(nil)
+		suite.mfaUsecase.On("Enabled", mock.Anything, suite.fakeUser.ID).Return(false, nil)
+		suite.tokenUsecase.On("IssueAuthTokens", mock.Anything, suite.fakeUser.ID).Return(access, refresh, nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		_, _, err = userUsecase.Login(context.TODO(), "alice@example.com", "pa55word")
+		suite.NoError(err)
 
+		match, err := suite.fakeUser.Password.Matches("pa55word")
 		suite.NoError(err)
+		suite.True(match)
+
 		suite.userRepo.AssertExpectations(suite.T())
+		suite.TearDownTest()
+	})
+
+	suite.Run("MFA enabled", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		suite.fakeUser.Activated = true
+		suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(suite.fakeUser, nil)
+		suite.mfaUsecase.On("Enabled", mock.Anything, suite.fakeUser.ID).Return(true, nil)
+		suite.tokenUsecase.On("Insert", mock.Anything, mock.MatchedBy(func(token *domain.Token) bool {
+			return token.Scope == domain.ScopeMFAChallenge && token.UserID == suite.fakeUser.ID
+		})).Return(nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		token, refreshToken, err := userUsecase.Login(context.TODO(), "alice@example.com", "pa55word")
+		suite.NoError(err)
+		suite.Equal(domain.ScopeMFAChallenge, token.Scope)
+		suite.Nil(refreshToken, "refresh token should only be issued after the second factor")
+
+		suite.tokenUsecase.AssertNotCalled(suite.T(), "IssueAuthTokens", mock.Anything, mock.Anything)
+		suite.tokenUsecase.AssertExpectations(suite.T())
 
 		suite.TearDownTest()
 	})
@@ -156,10 +233,10 @@ func (suite *UserUsecaseTestSuite) TestLogin() {
 			// When userRepo.GetByEmail is called, it should return nil, err
 			suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(nil, errors.E(errors.Op("userRepo.GetByEmail"), errors.KindRecordNotFound, domain.ErrRecordNotFound))
 
-			userUsecase := NewUserUsecase(suite.userRepo, suite.tokenRepo, suite.pool, suite.mailer, suite.logger, 3*time.Second)
+			userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
 			ctx := context.TODO()
 
-			token, err := userUsecase.Login(ctx, "alice@example.com", "pa55word")
+			token, _, err := userUsecase.Login(ctx, "alice@example.com", "pa55word")
 			suite.Nil(token, "token should be nil because some error happened")
 			suite.True(errors.KindIs(err, errors.KindInvalidCredentials))
 			suite.Equal("userUsecase.Login: kind invalid credentials: >> userRepo.GetByEmail: kind record not found: >> record not found", err.Error())
@@ -168,6 +245,22 @@ func (suite *UserUsecaseTestSuite) TestLogin() {
 
 			suite.TearDownTest()
 		})
+		suite.Run("user is not activated", func() {
+			suite.TearDownTest()
+			suite.SetupTest()
+
+			suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(suite.fakeUser, nil)
+
+			userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+			token, _, err := userUsecase.Login(context.TODO(), "alice@example.com", "pa55word")
+			suite.Nil(token, "token should be nil because the user is not activated")
+			suite.True(errors.KindIs(err, errors.KindInactiveAccount))
+
+			suite.tokenUsecase.AssertNotCalled(suite.T(), "IssueAuthTokens", mock.Anything, mock.Anything)
+
+			suite.TearDownTest()
+		})
 		// FIXME: Do we need this test ?
 		suite.Run("user.Password.Match failed", func() {
 			// Prevent from the scenario that other suite.Run doesn't teardown test manually.
@@ -179,8 +272,94 @@ func (suite *UserUsecaseTestSuite) TestLogin() {
 		suite.Run("password not match", func() {
 			// Prevent from the scenario that other suite.Run doesn't teardown test manually.
 			suite.TearDownTest()
-			// Do something
 			suite.SetupTest()
+
+			suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(suite.fakeUser, nil)
+
+			userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+			token, _, err := userUsecase.Login(context.TODO(), "alice@example.com", "wrong password")
+			suite.Nil(token)
+			suite.True(errors.KindIs(err, errors.KindInvalidCredentials))
+			suite.ErrorIs(err, domain.ErrInvalidCredentials)
+
+			suite.TearDownTest()
+		})
+		suite.Run("backoff after failed login", func() {
+			suite.TearDownTest()
+			suite.SetupTest()
+
+			suite.fakeUser.Activated = true
+			suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(suite.fakeUser, nil)
+
+			userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+			_, _, err := userUsecase.Login(context.TODO(), "alice@example.com", "wrong password")
+			suite.True(errors.KindIs(err, errors.KindInvalidCredentials))
+
+			// Even the right password has to wait for the backoff.
+			_, _, err = userUsecase.Login(context.TODO(), "ALICE@example.com", "pa55word")
+			suite.True(errors.KindIs(err, errors.KindRateLimitExceeded))
+
+			var retry *domain.RetryAfterError
+			if suite.True(errors.As(err, &retry)) {
+				suite.True(retry.After > 0 && retry.After <= time.Second, "should wait for the backoff, got %v", retry.After)
+			}
+
+			suite.userRepo.AssertNumberOfCalls(suite.T(), "GetByEmail", 1)
+			suite.TearDownTest()
+		})
+		suite.Run("locked out after too many failed logins", func() {
+			suite.TearDownTest()
+			suite.SetupTest()
+
+			suite.loginConfig.Backoff = 0
+			suite.fakeUser.Activated = true
+			suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(suite.fakeUser, nil)
+
+			userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+			for i := 0; i < suite.loginConfig.MaxFailures; i++ {
+				_, _, err := userUsecase.Login(context.TODO(), "alice@example.com", "wrong password")
+				suite.True(errors.KindIs(err, errors.KindInvalidCredentials))
+			}
+
+			_, _, err := userUsecase.Login(context.TODO(), "alice@example.com", "pa55word")
+			suite.True(errors.KindIs(err, errors.KindRateLimitExceeded))
+
+			var retry *domain.RetryAfterError
+			if suite.True(errors.As(err, &retry)) {
+				suite.InDelta(suite.loginConfig.Lockout.Seconds(), retry.After.Seconds(), 5)
+			}
+
+			suite.tokenUsecase.AssertNotCalled(suite.T(), "IssueAuthTokens", mock.Anything, mock.Anything)
+			suite.TearDownTest()
+		})
+		suite.Run("client IP locked out across email addresses", func() {
+			suite.TearDownTest()
+			suite.SetupTest()
+
+			suite.loginConfig.Backoff = 0
+			suite.userRepo.On("GetByEmail", mock.Anything, mock.Anything).
+				Return(nil, errors.E(errors.Op("userRepo.GetByEmail"), errors.KindRecordNotFound, domain.ErrRecordNotFound))
+
+			userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+			attacker := domain.ContextWithClient(context.TODO(), domain.Client{IP: "203.0.113.7"})
+			emails := []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"}
+			for _, email := range emails {
+				_, _, err := userUsecase.Login(attacker, email, "pa55word")
+				suite.True(errors.KindIs(err, errors.KindInvalidCredentials))
+			}
+
+			_, _, err := userUsecase.Login(attacker, "f@example.com", "pa55word")
+			suite.True(errors.KindIs(err, errors.KindRateLimitExceeded))
+
+			// Other clients can still log in as the same email address.
+			other := domain.ContextWithClient(context.TODO(), domain.Client{IP: "198.51.100.1"})
+			_, _, err = userUsecase.Login(other, "f@example.com", "pa55word")
+			suite.True(errors.KindIs(err, errors.KindInvalidCredentials))
+
 			suite.TearDownTest()
 		})
 		suite.Run("failed to generate token", func() {
@@ -200,6 +379,68 @@ func (suite *UserUsecaseTestSuite) TestLogin() {
 	})
 }
 
+func (suite *UserUsecaseTestSuite) TestCompleteLogin() {
+	suite.Run("Success", func() {
+		suite.SetupTest()
+
+		access, err := domain.GenerateToken(suite.fakeUser.ID, 15*time.Minute, domain.ScopeAuthentication)
+		suite.Require().NoError(err)
+		refresh, err := domain.GenerateToken(suite.fakeUser.ID, 7*24*time.Hour, domain.ScopeRefresh)
+		suite.Require().NoError(err)
+
+		suite.userRepo.On("GetForToken", mock.Anything, domain.ScopeMFAChallenge, "challenge").Return(suite.fakeUser, nil)
+		suite.mfaUsecase.On("Verify", mock.Anything, suite.fakeUser.ID, "123456").Return(nil)
+		suite.tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeMFAChallenge, suite.fakeUser.ID).Return(nil)
+		suite.tokenUsecase.On("IssueAuthTokens", mock.Anything, suite.fakeUser.ID).Return(access, refresh, nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		gotAccess, gotRefresh, err := userUsecase.CompleteLogin(context.TODO(), "challenge", "123456")
+		suite.NoError(err)
+		suite.Equal(access, gotAccess)
+		suite.Equal(refresh, gotRefresh)
+
+		suite.tokenUsecase.AssertExpectations(suite.T())
+		suite.TearDownTest()
+	})
+
+	suite.Run("Invalid challenge token", func() {
+		suite.SetupTest()
+
+		suite.userRepo.On("GetForToken", mock.Anything, domain.ScopeMFAChallenge, "challenge").
+			Return(nil, errors.E(errors.Op("userRepo.GetForToken"), errors.KindRecordNotFound, domain.ErrRecordNotFound))
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		_, _, err := userUsecase.CompleteLogin(context.TODO(), "challenge", "123456")
+		suite.True(errors.KindIs(err, errors.KindInvalidCredentials))
+
+		suite.mfaUsecase.AssertNotCalled(suite.T(), "Verify", mock.Anything, mock.Anything, mock.Anything)
+		suite.TearDownTest()
+	})
+
+	suite.Run("Invalid code", func() {
+		suite.SetupTest()
+
+		suite.userRepo.On("GetForToken", mock.Anything, domain.ScopeMFAChallenge, "challenge").Return(suite.fakeUser, nil)
+		suite.mfaUsecase.On("Verify", mock.Anything, suite.fakeUser.ID, "123456").
+			Return(errors.E(errors.Op("mfaUsecase.Verify"), errors.KindInvalidCredentials, domain.ErrInvalidCredentials))
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		_, _, err := userUsecase.CompleteLogin(context.TODO(), "challenge", "123456")
+		suite.True(errors.KindIs(err, errors.KindInvalidCredentials))
+
+		// Wrong codes are throttled like wrong passwords.
+		_, _, err = userUsecase.CompleteLogin(context.TODO(), "challenge", "654321")
+		suite.True(errors.KindIs(err, errors.KindRateLimitExceeded))
+
+		suite.mfaUsecase.AssertNumberOfCalls(suite.T(), "Verify", 1)
+		suite.tokenUsecase.AssertNotCalled(suite.T(), "IssueAuthTokens", mock.Anything, mock.Anything)
+		suite.TearDownTest()
+	})
+}
+
 func (suite *UserUsecaseTestSuite) TestAuthenticate() {
 	suite.Run("Success", func() {
 		suite.SetupTest()
@@ -213,7 +454,7 @@ func (suite *UserUsecaseTestSuite) TestAuthenticate() {
 		// it should return user we defined and nil error.
 		suite.userRepo.On("GetForToken", mock.Anything, token.Scope, token.Plaintext).Return(suite.fakeUser, nil)
 
-		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenRepo, suite.pool, suite.mailer, suite.logger, 3*time.Second)
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
 
 		ctx := context.TODO()
 		gotUser, err := userUsecase.Authenticate(ctx, token.Scope, token.Plaintext)
@@ -245,7 +486,7 @@ func (suite *UserUsecaseTestSuite) TestAuthenticate() {
 		// it should return user we defined and nil error.
 		suite.userRepo.On("GetForToken", mock.Anything, token.Scope, token.Plaintext).Return(nil, wantErr)
 
-		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenRepo, suite.pool, suite.mailer, suite.logger, 3*time.Second)
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
 
 		ctx := context.TODO()
 		gotUser, err := userUsecase.Authenticate(ctx, token.Scope, token.Plaintext)
@@ -271,7 +512,7 @@ func (suite *UserUsecaseTestSuite) TestUpdate() {
 			return user.Name == suite.fakeUser.Name && user.Email == suite.fakeUser.Email
 		})).Return(nil)
 
-		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenRepo, suite.pool, suite.mailer, suite.logger, 3*time.Second)
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
 
 		ctx := context.TODO()
 
@@ -303,7 +544,7 @@ func (suite *UserUsecaseTestSuite) TestUpdate() {
 			return user.Name == suite.fakeUser.Name && user.Email == suite.fakeUser.Email
 		})).Return(wantErr)
 
-		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenRepo, suite.pool, suite.mailer, suite.logger, 3*time.Second)
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
 
 		ctx := context.TODO()
 
@@ -320,3 +561,351 @@ func (suite *UserUsecaseTestSuite) TestUpdate() {
 		suite.TearDownTest()
 	})
 }
+
+func (suite *UserUsecaseTestSuite) TestRegister() {
+	suite.Run("Success", func() {
+		suite.SetupTest()
+
+		suite.userRepo.On("Insert", mock.Anything, suite.fakeUser).Return(nil)
+		suite.permissionRepo.On("AddForUser", mock.Anything, suite.fakeUser.ID, domain.DefaultPermissions).Return(nil)
+		suite.tokenUsecase.On("Insert", mock.Anything, mock.MatchedBy(func(token *domain.Token) bool {
+			return token.UserID == suite.fakeUser.ID && token.Scope == domain.ScopeActivation
+		})).Return(nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		err := userUsecase.Register(context.TODO(), suite.fakeUser)
+		suite.NoError(err)
+
+		suite.userRepo.AssertExpectations(suite.T())
+		suite.permissionRepo.AssertExpectations(suite.T())
+		suite.tokenUsecase.AssertExpectations(suite.T())
+
+		suite.TearDownTest()
+	})
+}
+
+func (suite *UserUsecaseTestSuite) TestRequestPasswordReset() {
+	suite.Run("Success", func() {
+		suite.SetupTest()
+
+		suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(suite.fakeUser, nil)
+		suite.tokenUsecase.On("Insert", mock.Anything, mock.MatchedBy(func(token *domain.Token) bool {
+			return token.UserID == suite.fakeUser.ID && token.Scope == domain.ScopePasswordReset
+		})).Return(nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		err := userUsecase.RequestPasswordReset(context.TODO(), suite.fakeUser.Email)
+		suite.NoError(err)
+
+		suite.userRepo.AssertExpectations(suite.T())
+		suite.tokenUsecase.AssertExpectations(suite.T())
+
+		suite.TearDownTest()
+	})
+	suite.Run("Success on unknown email", func() {
+		suite.SetupTest()
+
+		suite.userRepo.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound))
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		err := userUsecase.RequestPasswordReset(context.TODO(), "nobody@example.com")
+		suite.NoError(err, "unknown email should not be revealed to the caller")
+
+		suite.tokenUsecase.AssertNotCalled(suite.T(), "Insert", mock.Anything, mock.Anything)
+
+		suite.TearDownTest()
+	})
+	suite.Run("Throttled", func() {
+		suite.SetupTest()
+
+		suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(suite.fakeUser, nil).Once()
+		suite.tokenUsecase.On("Insert", mock.Anything, mock.Anything).Return(nil).Once()
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		err := userUsecase.RequestPasswordReset(context.TODO(), suite.fakeUser.Email)
+		suite.NoError(err)
+
+		err = userUsecase.RequestPasswordReset(context.TODO(), strings.ToUpper(suite.fakeUser.Email))
+		suite.NoError(err, "throttled request should not be revealed to the caller")
+
+		suite.userRepo.AssertNumberOfCalls(suite.T(), "GetByEmail", 1)
+		suite.tokenUsecase.AssertNumberOfCalls(suite.T(), "Insert", 1)
+
+		suite.TearDownTest()
+	})
+}
+
+func (suite *UserUsecaseTestSuite) TestResetPassword() {
+	suite.Run("Success", func() {
+		suite.SetupTest()
+
+		oldHash := suite.fakeUser.Password.Hash
+
+		suite.userRepo.On("GetForToken", mock.Anything, domain.ScopePasswordReset, "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU").Return(suite.fakeUser, nil)
+		suite.userRepo.On("Update", mock.Anything, suite.fakeUser).Return(nil)
+		suite.tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopePasswordReset, suite.fakeUser.ID).Return(nil)
+		suite.tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeAuthentication, suite.fakeUser.ID).Return(nil)
+		suite.tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeRefresh, suite.fakeUser.ID).Return(nil)
+		suite.tokenUsecase.On("DeleteAllPersonalTokens", mock.Anything, suite.fakeUser.ID).Return(nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		user, err := userUsecase.ResetPassword(context.TODO(), "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU", "n3wpa55word")
+		suite.NoError(err)
+		suite.NotEqual(oldHash, user.Password.Hash, "password hash should be updated")
+
+		match, err := user.Password.Matches("n3wpa55word")
+		suite.NoError(err)
+		suite.True(match)
+
+		suite.userRepo.AssertExpectations(suite.T())
+		suite.tokenUsecase.AssertExpectations(suite.T())
+
+		suite.TearDownTest()
+	})
+	suite.Run("Fail on invalid token", func() {
+		suite.SetupTest()
+
+		suite.userRepo.On("GetForToken", mock.Anything, domain.ScopePasswordReset, "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU").Return(nil, errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound))
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		_, err := userUsecase.ResetPassword(context.TODO(), "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU", "n3wpa55word")
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		suite.userRepo.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything)
+		suite.tokenUsecase.AssertNotCalled(suite.T(), "DeleteAllForUser", mock.Anything, mock.Anything, mock.Anything)
+
+		suite.TearDownTest()
+	})
+	suite.Run("Fail on password refused by policy", func() {
+		suite.SetupTest()
+
+		defer func(rules []domain.PasswordRule) { domain.PasswordPolicy = rules }(domain.PasswordPolicy)
+		domain.PasswordPolicy = []domain.PasswordRule{domain.NoPersonalInfo{}}
+
+		suite.userRepo.On("GetForToken", mock.Anything, domain.ScopePasswordReset, "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU").Return(suite.fakeUser, nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		_, err := userUsecase.ResetPassword(context.TODO(), "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU", "smith4ever")
+		suite.True(errors.KindIs(err, errors.KindFailedValidation))
+
+		var verrs validator.ValidationErrors
+		if suite.True(errors.As(err, &verrs)) {
+			suite.Contains(verrs, "password")
+		}
+
+		suite.userRepo.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything)
+
+		suite.TearDownTest()
+	})
+}
+
+func (suite *UserUsecaseTestSuite) TestUpdateAccount() {
+	suite.Run("Success on email change", func() {
+		suite.SetupTest()
+
+		newEmail := "alice@example.org"
+
+		suite.userRepo.On("GetByEmail", mock.Anything, newEmail).Return(nil, errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound))
+		suite.userRepo.On("Update", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+			return user.Email == "alice@example.com" && user.PendingEmail == newEmail
+		})).Return(nil)
+		suite.tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeEmailChange, suite.fakeUser.ID).Return(nil)
+		suite.tokenUsecase.On("Insert", mock.Anything, mock.MatchedBy(func(token *domain.Token) bool {
+			return token.UserID == suite.fakeUser.ID && token.Scope == domain.ScopeEmailChange
+		})).Return(nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		err := userUsecase.UpdateAccount(context.TODO(), suite.fakeUser, &domain.UserUpdate{Email: &newEmail, CurrentPassword: "pa55word"})
+		suite.NoError(err)
+
+		suite.userRepo.AssertExpectations(suite.T())
+		suite.tokenUsecase.AssertExpectations(suite.T())
+
+		suite.TearDownTest()
+	})
+	suite.Run("Success on password change revokes sessions", func() {
+		suite.SetupTest()
+
+		newPassword := "n3wpa55word"
+
+		suite.userRepo.On("Update", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+			match, err := user.Password.Matches(newPassword)
+			return err == nil && match
+		})).Return(nil)
+		suite.tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeAuthentication, suite.fakeUser.ID).Return(nil)
+		suite.tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeRefresh, suite.fakeUser.ID).Return(nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		err := userUsecase.UpdateAccount(context.TODO(), suite.fakeUser, &domain.UserUpdate{Password: &newPassword, CurrentPassword: "pa55word"})
+		suite.NoError(err)
+
+		suite.userRepo.AssertExpectations(suite.T())
+		suite.tokenUsecase.AssertExpectations(suite.T())
+
+		suite.TearDownTest()
+	})
+	suite.Run("Fail on wrong current password", func() {
+		suite.SetupTest()
+
+		newPassword := "n3wpa55word"
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		err := userUsecase.UpdateAccount(context.TODO(), suite.fakeUser, &domain.UserUpdate{Password: &newPassword, CurrentPassword: "wrongpassword"})
+		suite.True(errors.KindIs(err, errors.KindInvalidCredentials))
+
+		suite.userRepo.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything)
+
+		suite.TearDownTest()
+	})
+}
+
+func (suite *UserUsecaseTestSuite) TestConfirmEmailChange() {
+	suite.Run("Success", func() {
+		suite.SetupTest()
+
+		suite.fakeUser.PendingEmail = "alice@example.org"
+
+		suite.userRepo.On("GetForToken", mock.Anything, domain.ScopeEmailChange, "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU").Return(suite.fakeUser, nil)
+		suite.userRepo.On("Update", mock.Anything, suite.fakeUser).Return(nil)
+		suite.tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeEmailChange, suite.fakeUser.ID).Return(nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		user, err := userUsecase.ConfirmEmailChange(context.TODO(), "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU")
+		suite.NoError(err)
+		suite.Equal("alice@example.org", user.Email)
+		suite.Equal("", user.PendingEmail)
+
+		suite.userRepo.AssertExpectations(suite.T())
+		suite.tokenUsecase.AssertExpectations(suite.T())
+
+		suite.TearDownTest()
+	})
+}
+
+func (suite *UserUsecaseTestSuite) TestDeleteAccount() {
+	suite.Run("Success", func() {
+		suite.SetupTest()
+
+		suite.userRepo.On("Delete", mock.Anything, suite.fakeUser.ID).Return(nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		suite.NoError(userUsecase.DeleteAccount(context.TODO(), suite.fakeUser, "pa55word"))
+
+		suite.userRepo.AssertExpectations(suite.T())
+
+		suite.TearDownTest()
+	})
+	suite.Run("Fail on wrong password", func() {
+		suite.SetupTest()
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		err := userUsecase.DeleteAccount(context.TODO(), suite.fakeUser, "wrongpassword")
+		suite.True(errors.KindIs(err, errors.KindInvalidCredentials))
+
+		suite.userRepo.AssertNotCalled(suite.T(), "Delete", mock.Anything, mock.Anything)
+
+		suite.TearDownTest()
+	})
+}
+
+func (suite *UserUsecaseTestSuite) TestResendActivation() {
+	suite.Run("Success and throttled afterwards", func() {
+		suite.SetupTest()
+
+		suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(suite.fakeUser, nil)
+		suite.tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeActivation, suite.fakeUser.ID).Return(nil)
+		suite.tokenUsecase.On("Insert", mock.Anything, mock.MatchedBy(func(token *domain.Token) bool {
+			return token.UserID == suite.fakeUser.ID && token.Scope == domain.ScopeActivation
+		})).Return(nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		suite.NoError(userUsecase.ResendActivation(context.TODO(), suite.fakeUser.Email))
+
+		err := userUsecase.ResendActivation(context.TODO(), "ALICE@example.com")
+		suite.True(errors.KindIs(err, errors.KindRateLimitExceeded), "email address should be throttled case-insensitively")
+
+		suite.userRepo.AssertNumberOfCalls(suite.T(), "GetByEmail", 1)
+		suite.tokenUsecase.AssertNumberOfCalls(suite.T(), "Insert", 1)
+
+		suite.TearDownTest()
+	})
+	suite.Run("Success on activated user", func() {
+		suite.SetupTest()
+
+		suite.fakeUser.Activated = true
+		suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(suite.fakeUser, nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		suite.NoError(userUsecase.ResendActivation(context.TODO(), suite.fakeUser.Email))
+
+		suite.tokenUsecase.AssertNotCalled(suite.T(), "Insert", mock.Anything, mock.Anything)
+
+		suite.TearDownTest()
+	})
+	suite.Run("Success on deactivated user", func() {
+		suite.SetupTest()
+
+		suite.fakeUser.Deactivated = true
+		suite.userRepo.On("GetByEmail", mock.Anything, suite.fakeUser.Email).Return(suite.fakeUser, nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		suite.NoError(userUsecase.ResendActivation(context.TODO(), suite.fakeUser.Email))
+
+		suite.tokenUsecase.AssertNotCalled(suite.T(), "DeleteAllForUser", mock.Anything, mock.Anything, mock.Anything)
+		suite.tokenUsecase.AssertNotCalled(suite.T(), "Insert", mock.Anything, mock.Anything)
+
+		suite.TearDownTest()
+	})
+}
+
+func (suite *UserUsecaseTestSuite) TestActivate() {
+	suite.Run("Success", func() {
+		suite.SetupTest()
+
+		suite.userRepo.On("GetForToken", mock.Anything, domain.ScopeActivation, "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU").Return(suite.fakeUser, nil)
+		suite.userRepo.On("Update", mock.Anything, suite.fakeUser).Return(nil)
+		suite.tokenUsecase.On("DeleteAllForUser", mock.Anything, domain.ScopeActivation, suite.fakeUser.ID).Return(nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		user, err := userUsecase.Activate(context.TODO(), "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU")
+		suite.NoError(err)
+		suite.True(user.Activated)
+
+		suite.userRepo.AssertExpectations(suite.T())
+		suite.tokenUsecase.AssertExpectations(suite.T())
+
+		suite.TearDownTest()
+	})
+	suite.Run("Fail on deactivated user", func() {
+		suite.SetupTest()
+
+		suite.fakeUser.Deactivated = true
+		suite.userRepo.On("GetForToken", mock.Anything, domain.ScopeActivation, "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU").Return(suite.fakeUser, nil)
+
+		userUsecase := NewUserUsecase(suite.userRepo, suite.tokenUsecase, suite.mfaUsecase, suite.permissionRepo, suite.pool, suite.mailer, suite.logger, suite.loginConfig, 3*time.Second)
+
+		_, err := userUsecase.Activate(context.TODO(), "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU")
+		suite.True(errors.KindIs(err, errors.KindNotPermitted), "only an admin should be able to reactivate the user")
+
+		suite.userRepo.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything)
+
+		suite.TearDownTest()
+	})
+}
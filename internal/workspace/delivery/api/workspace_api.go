@@ -0,0 +1,400 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/helpers"
+	"github.com/unknowntpo/todos/internal/middleware"
+	"github.com/unknowntpo/todos/internal/reactor"
+
+	"github.com/unknowntpo/todos/pkg/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type CreateWorkspaceRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type CreateWorkspaceResponse struct {
+	Workspace *domain.Workspace `json:"workspace"`
+}
+
+type GetAllWorkspacesResponse struct {
+	Workspaces []*domain.Workspace `json:"workspaces"`
+}
+
+type GetWorkspaceByIDResponse struct {
+	Workspace *domain.Workspace `json:"workspace"`
+}
+
+type DeleteWorkspaceByIDResponse struct {
+	Message string `json:"message"`
+}
+
+type AddWorkspaceMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type AddWorkspaceMemberResponse struct {
+	Member *domain.WorkspaceMember `json:"member"`
+}
+
+type GetWorkspaceMembersResponse struct {
+	Members []*domain.WorkspaceMember `json:"members"`
+}
+
+type RemoveWorkspaceMemberResponse struct {
+	Message string `json:"message"`
+}
+
+type workspaceAPI struct {
+	wu  domain.WorkspaceUsecase
+	mid *middleware.Middleware
+	rc  *reactor.Reactor
+}
+
+func NewWorkspaceAPI(router *httprouter.Router, wu domain.WorkspaceUsecase, mid *middleware.Middleware, rc *reactor.Reactor) {
+	api := &workspaceAPI{wu: wu, mid: mid, rc: rc}
+	router.Handler(http.MethodGet, "/v1/workspaces", mid.RequireActivatedUser(http.HandlerFunc(api.GetAll)))
+	router.Handler(http.MethodPost, "/v1/workspaces", mid.RequireActivatedUser(http.HandlerFunc(api.Create)))
+	router.Handler(http.MethodGet, "/v1/workspaces/:id", mid.RequireActivatedUser(http.HandlerFunc(api.GetByID)))
+	router.Handler(http.MethodDelete, "/v1/workspaces/:id", mid.RequireActivatedUser(http.HandlerFunc(api.Delete)))
+	router.Handler(http.MethodGet, "/v1/workspaces/:id/members", mid.RequireActivatedUser(http.HandlerFunc(api.GetMembers)))
+	router.Handler(http.MethodPost, "/v1/workspaces/:id/members", mid.RequireActivatedUser(http.HandlerFunc(api.AddMember)))
+	router.Handler(http.MethodDelete, "/v1/workspaces/:id/members/:user_id", mid.RequireActivatedUser(http.HandlerFunc(api.RemoveMember)))
+}
+
+// GetAll lists the workspaces of the user.
+// @Summary List the workspaces the user is a member of.
+// @Description: None.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success 200 {object} GetAllWorkspacesResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/workspaces [get]
+func (wa *workspaceAPI) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("workspaceAPI.GetAll")
+
+	user := helpers.ContextGetUser(r)
+
+	ctx := r.Context()
+	workspaces, err := wa.wu.GetAll(ctx, user.ID)
+	if err != nil {
+		wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		return
+	}
+
+	err = wa.rc.WriteJSON(w, http.StatusOK, &GetAllWorkspacesResponse{workspaces})
+	if err != nil {
+		wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// Create creates a new workspace.
+// @Summary Create a new workspace, the user becomes its owner.
+// @Description: The slug identifies the workspace in the X-Workspace header and the /w/{slug} path prefix.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param reqBody body CreateWorkspaceRequest true "request body"
+// @Success 201 {object} CreateWorkspaceResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/workspaces [post]
+func (wa *workspaceAPI) Create(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("workspaceAPI.Create")
+
+	user := helpers.ContextGetUser(r)
+
+	var input CreateWorkspaceRequest
+
+	err := wa.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		wa.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	ws := &domain.Workspace{
+		Name: input.Name,
+		Slug: input.Slug,
+	}
+
+	v := validator.New()
+
+	if domain.ValidateWorkspace(v, ws); !v.Valid() {
+		wa.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+	err = wa.wu.Create(ctx, user.ID, ws)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindFailedValidation):
+			v.AddError("slug", "a workspace with this slug already exists")
+			wa.rc.FailedValidationResponse(w, r, v.Err())
+		default:
+			wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/v1/workspaces/%d", ws.ID))
+
+	err = wa.rc.WriteJSON(w, http.StatusCreated, &CreateWorkspaceResponse{ws})
+	if err != nil {
+		wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// GetByID gets a workspace by its id.
+// @Summary Get a workspace the user is a member of.
+// @Description: None.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param workspaceID path int true "Workspace ID"
+// @Success 200 {object} GetWorkspaceByIDResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/workspaces/{workspaceID} [get]
+func (wa *workspaceAPI) GetByID(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("workspaceAPI.GetByID")
+
+	user := helpers.ContextGetUser(r)
+
+	id, err := wa.rc.ReadIDParam(r)
+	if err != nil {
+		wa.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	ws, err := wa.wu.GetByID(ctx, user.ID, id)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			wa.rc.NotFoundResponse(w, r)
+		default:
+			wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = wa.rc.WriteJSON(w, http.StatusOK, &GetWorkspaceByIDResponse{ws})
+	if err != nil {
+		wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// Delete deletes a workspace.
+// @Summary Delete a workspace along with its tasks.
+// @Description: Only the owner of the workspace can delete it.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param workspaceID path int true "Workspace ID"
+// @Success 200 {object} DeleteWorkspaceByIDResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/workspaces/{workspaceID} [delete]
+func (wa *workspaceAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("workspaceAPI.Delete")
+
+	user := helpers.ContextGetUser(r)
+
+	id, err := wa.rc.ReadIDParam(r)
+	if err != nil {
+		wa.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	err = wa.wu.Delete(ctx, user.ID, id)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			wa.rc.NotFoundResponse(w, r)
+		case errors.KindIs(err, errors.KindNotPermitted):
+			wa.rc.NotPermittedResponse(w, r)
+		default:
+			wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = wa.rc.WriteJSON(w, http.StatusOK, &DeleteWorkspaceByIDResponse{"workspace successfully deleted"})
+	if err != nil {
+		wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// GetMembers lists the members of a workspace.
+// @Summary List the members of a workspace.
+// @Description: None.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param workspaceID path int true "Workspace ID"
+// @Success 200 {object} GetWorkspaceMembersResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/workspaces/{workspaceID}/members [get]
+func (wa *workspaceAPI) GetMembers(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("workspaceAPI.GetMembers")
+
+	user := helpers.ContextGetUser(r)
+
+	id, err := wa.rc.ReadIDParam(r)
+	if err != nil {
+		wa.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	members, err := wa.wu.GetMembers(ctx, user.ID, id)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			wa.rc.NotFoundResponse(w, r)
+		default:
+			wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = wa.rc.WriteJSON(w, http.StatusOK, &GetWorkspaceMembersResponse{members})
+	if err != nil {
+		wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// AddMember adds a user to a workspace.
+// @Summary Add a user to a workspace by email, as admin or member.
+// @Description: Only the owner and admins can add members, adding an existing member changes their role.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param workspaceID path int true "Workspace ID"
+// @Param reqBody body AddWorkspaceMemberRequest true "request body"
+// @Success 201 {object} AddWorkspaceMemberResponse
+// @Failure 400 {object} reactor.ErrorResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 422 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/workspaces/{workspaceID}/members [post]
+func (wa *workspaceAPI) AddMember(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("workspaceAPI.AddMember")
+
+	user := helpers.ContextGetUser(r)
+
+	id, err := wa.rc.ReadIDParam(r)
+	if err != nil {
+		wa.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	var input AddWorkspaceMemberRequest
+
+	err = wa.rc.ReadJSON(w, r, &input)
+	if err != nil {
+		wa.rc.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if domain.ValidateWorkspaceMember(v, input.Email, input.Role); !v.Valid() {
+		wa.rc.FailedValidationResponse(w, r, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+
+	// Look up the workspace first, so that we can tell a missing workspace apart from
+	// a missing user.
+	_, err = wa.wu.GetByID(ctx, user.ID, id)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			wa.rc.NotFoundResponse(w, r)
+		default:
+			wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	member, err := wa.wu.AddMember(ctx, user.ID, id, input.Email, input.Role)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindNotPermitted):
+			wa.rc.NotPermittedResponse(w, r)
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			v.AddError("email", "no user with this email address was found")
+			wa.rc.FailedValidationResponse(w, r, v.Err())
+		default:
+			wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = wa.rc.WriteJSON(w, http.StatusCreated, &AddWorkspaceMemberResponse{member})
+	if err != nil {
+		wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
+
+// RemoveMember removes a user from a workspace.
+// @Summary Remove a user from a workspace.
+// @Description: The owner and admins can remove anyone but the owner, other members can only leave the workspace.
+// @Produce  json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param workspaceID path int true "Workspace ID"
+// @Param userID path int true "User ID"
+// @Success 200 {object} RemoveWorkspaceMemberResponse
+// @Failure 403 {object} reactor.ErrorResponse
+// @Failure 404 {object} reactor.ErrorResponse
+// @Failure 500 {object} reactor.ErrorResponse
+// @Router /v1/workspaces/{workspaceID}/members/{userID} [delete]
+func (wa *workspaceAPI) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	const op = errors.Op("workspaceAPI.RemoveMember")
+
+	user := helpers.ContextGetUser(r)
+
+	id, err := wa.rc.ReadIDParam(r)
+	if err != nil {
+		wa.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	memberID, err := wa.rc.ReadInt64Param(r, "user_id")
+	if err != nil {
+		wa.rc.NotFoundResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	err = wa.wu.RemoveMember(ctx, user.ID, id, memberID)
+	if err != nil {
+		switch {
+		case errors.KindIs(err, errors.KindRecordNotFound):
+			wa.rc.NotFoundResponse(w, r)
+		case errors.KindIs(err, errors.KindNotPermitted):
+			wa.rc.NotPermittedResponse(w, r)
+		default:
+			wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+		}
+		return
+	}
+
+	err = wa.rc.WriteJSON(w, http.StatusOK, &RemoveWorkspaceMemberResponse{"member successfully removed"})
+	if err != nil {
+		wa.rc.ServerErrorResponse(w, r, errors.E(op, err))
+	}
+}
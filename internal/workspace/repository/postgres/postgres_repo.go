@@ -0,0 +1,317 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+)
+
+type workspaceRepo struct {
+	DB *sql.DB
+}
+
+func NewWorkspaceRepo(DB *sql.DB) domain.WorkspaceRepository {
+	return &workspaceRepo{DB}
+}
+
+// Insert inserts the workspace and adds the user with given ownerID as its owner,
+// both in the same transaction.
+// If the slug is already taken, the error with kind errors.KindFailedValidation is returned.
+func (wr *workspaceRepo) Insert(ctx context.Context, ownerID int64, ws *domain.Workspace) error {
+	const op errors.Op = "workspaceRepo.Insert"
+
+	tx, err := wr.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+	// Rollback is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO workspaces (name, slug)
+        VALUES ($1, $2)
+        RETURNING id, created_at`
+
+	err = tx.QueryRowContext(ctx, query, ws.Name, ws.Slug).Scan(&ws.ID, &ws.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "workspaces_slug_key"`:
+			return errors.E(op, errors.KindFailedValidation, domain.ErrDuplicateSlug)
+		default:
+			return errors.E(op, errors.KindDatabase, err)
+		}
+	}
+
+	query = `
+        INSERT INTO workspace_members (workspace_id, user_id, role)
+        VALUES ($1, $2, $3)`
+
+	_, err = tx.ExecContext(ctx, query, ws.ID, ownerID, domain.WorkspaceRoleOwner)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	ws.Role = domain.WorkspaceRoleOwner
+
+	return nil
+}
+
+// GetAll returns the workspaces the user with given userID is a member of.
+func (wr *workspaceRepo) GetAll(ctx context.Context, userID int64) ([]*domain.Workspace, error) {
+	const op errors.Op = "workspaceRepo.GetAll"
+
+	query := `
+        SELECT workspaces.id, workspaces.created_at, workspaces.name, workspaces.slug, workspace_members.role
+        FROM workspaces
+        INNER JOIN workspace_members
+        ON workspaces.id = workspace_members.workspace_id
+        WHERE workspace_members.user_id = $1
+        ORDER BY workspaces.id ASC`
+
+	rows, err := wr.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+	defer rows.Close()
+
+	workspaces := []*domain.Workspace{}
+
+	for rows.Next() {
+		var ws domain.Workspace
+
+		err := rows.Scan(
+			&ws.ID,
+			&ws.CreatedAt,
+			&ws.Name,
+			&ws.Slug,
+			&ws.Role,
+		)
+		if err != nil {
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+
+		workspaces = append(workspaces, &ws)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+
+	return workspaces, nil
+}
+
+// GetByID returns the workspace with given workspaceID if the user with given userID
+// is a member of it. The role of the user is stored in ws.Role.
+func (wr *workspaceRepo) GetByID(ctx context.Context, userID int64, workspaceID int64) (*domain.Workspace, error) {
+	const op errors.Op = "workspaceRepo.GetByID"
+	if workspaceID < 1 {
+		return nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	ws, err := wr.get(ctx, "workspaces.id = $2", userID, workspaceID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return ws, nil
+}
+
+// GetBySlug returns the workspace with given slug if the user with given userID
+// is a member of it. The role of the user is stored in ws.Role.
+func (wr *workspaceRepo) GetBySlug(ctx context.Context, userID int64, slug string) (*domain.Workspace, error) {
+	const op errors.Op = "workspaceRepo.GetBySlug"
+
+	ws, err := wr.get(ctx, "workspaces.slug = $2", userID, slug)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return ws, nil
+}
+
+// get returns the workspace matching the condition if the user with given userID
+// is a member of it, the condition refers to key as $2.
+func (wr *workspaceRepo) get(ctx context.Context, condition string, userID int64, key interface{}) (*domain.Workspace, error) {
+	const op errors.Op = "workspaceRepo.get"
+
+	query := `
+        SELECT workspaces.id, workspaces.created_at, workspaces.name, workspaces.slug, workspace_members.role
+        FROM workspaces
+        INNER JOIN workspace_members
+        ON workspaces.id = workspace_members.workspace_id AND workspace_members.user_id = $1
+        WHERE ` + condition
+
+	var ws domain.Workspace
+
+	err := wr.DB.QueryRowContext(ctx, query, userID, key).Scan(
+		&ws.ID,
+		&ws.CreatedAt,
+		&ws.Name,
+		&ws.Slug,
+		&ws.Role,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+		default:
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+	}
+
+	return &ws, nil
+}
+
+// Delete deletes the workspace with given workspaceID, along with its members and tasks.
+func (wr *workspaceRepo) Delete(ctx context.Context, workspaceID int64) error {
+	const op errors.Op = "workspaceRepo.Delete"
+
+	query := `
+        DELETE FROM workspaces
+        WHERE id = $1`
+
+	result, err := wr.DB.ExecContext(ctx, query, workspaceID)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// GetMembers returns all members of the workspace with given workspaceID.
+func (wr *workspaceRepo) GetMembers(ctx context.Context, workspaceID int64) ([]*domain.WorkspaceMember, error) {
+	const op errors.Op = "workspaceRepo.GetMembers"
+
+	query := `
+        SELECT workspace_members.workspace_id, workspace_members.user_id, users.email, workspace_members.role, workspace_members.created_at
+        FROM workspace_members
+        INNER JOIN users
+        ON workspace_members.user_id = users.id
+        WHERE workspace_members.workspace_id = $1
+        ORDER BY workspace_members.created_at ASC, workspace_members.user_id ASC`
+
+	rows, err := wr.DB.QueryContext(ctx, query, workspaceID)
+	if err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+	defer rows.Close()
+
+	members := []*domain.WorkspaceMember{}
+
+	for rows.Next() {
+		var member domain.WorkspaceMember
+
+		err := rows.Scan(
+			&member.WorkspaceID,
+			&member.UserID,
+			&member.Email,
+			&member.Role,
+			&member.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+
+		members = append(members, &member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.E(op, errors.KindDatabase, err)
+	}
+
+	return members, nil
+}
+
+// GetMember returns the membership of the user with given userID in the workspace
+// with given workspaceID.
+func (wr *workspaceRepo) GetMember(ctx context.Context, workspaceID int64, userID int64) (*domain.WorkspaceMember, error) {
+	const op errors.Op = "workspaceRepo.GetMember"
+
+	query := `
+        SELECT workspace_members.workspace_id, workspace_members.user_id, users.email, workspace_members.role, workspace_members.created_at
+        FROM workspace_members
+        INNER JOIN users
+        ON workspace_members.user_id = users.id
+        WHERE workspace_members.workspace_id = $1 AND workspace_members.user_id = $2`
+
+	var member domain.WorkspaceMember
+
+	err := wr.DB.QueryRowContext(ctx, query, workspaceID, userID).Scan(
+		&member.WorkspaceID,
+		&member.UserID,
+		&member.Email,
+		&member.Role,
+		&member.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+		default:
+			return nil, errors.E(op, errors.KindDatabase, err)
+		}
+	}
+
+	return &member, nil
+}
+
+// InsertMember adds the user specified in member to the workspace, if the user
+// is already a member, the role is updated instead.
+func (wr *workspaceRepo) InsertMember(ctx context.Context, member *domain.WorkspaceMember) error {
+	const op errors.Op = "workspaceRepo.InsertMember"
+
+	query := `
+        INSERT INTO workspace_members (workspace_id, user_id, role)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role
+        RETURNING created_at`
+
+	args := []interface{}{member.WorkspaceID, member.UserID, member.Role}
+
+	err := wr.DB.QueryRowContext(ctx, query, args...).Scan(&member.CreatedAt)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	return nil
+}
+
+// DeleteMember removes the user with given userID from the workspace with given workspaceID.
+func (wr *workspaceRepo) DeleteMember(ctx context.Context, workspaceID int64, userID int64) error {
+	const op errors.Op = "workspaceRepo.DeleteMember"
+
+	query := `
+        DELETE FROM workspace_members
+        WHERE workspace_id = $1 AND user_id = $2`
+
+	result, err := wr.DB.ExecContext(ctx, query, workspaceID, userID)
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.E(op, errors.KindDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.E(op, errors.KindRecordNotFound, domain.ErrRecordNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	"github.com/unknowntpo/todos/internal/testutil"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/stretchr/testify/suite"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+type WorkspaceRepoTestSuite struct {
+	suite.Suite
+	container testcontainers.Container
+	db        *sql.DB
+	mig       *migrate.Migrate
+	alice     *domain.User
+	bob       *domain.User
+}
+
+func (suite *WorkspaceRepoTestSuite) SetupSuite() {
+	ctx := context.Background()
+
+	container, db, err := testutil.CreatePostgresTestContainer(ctx, "testdb")
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.container = container
+	suite.db = db
+
+	mig, err := testutil.NewPgMigrator(db)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.mig = mig
+}
+
+// TearDownSuite tears down the test suite by closing db connection,
+// terminates container.
+func (suite *WorkspaceRepoTestSuite) TearDownSuite() {
+	defer suite.db.Close()
+	ctx := context.Background()
+	defer suite.container.Terminate(ctx)
+}
+
+// SetupTest do migration up and creates fake users for each test.
+func (suite *WorkspaceRepoTestSuite) SetupTest() {
+	err := suite.mig.Up()
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.alice = suite.insertUser("Alice Smith", "alice@example.com")
+	suite.bob = suite.insertUser("Bob Ross", "bob.ross@example.com")
+}
+
+// TearDownTest do migration down for each test to ensure the results of
+// this test won't affect to the result of next test.
+func (suite *WorkspaceRepoTestSuite) TearDownTest() {
+	err := suite.mig.Down()
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.alice = nil
+	suite.bob = nil
+}
+
+func (suite *WorkspaceRepoTestSuite) insertUser(name, email string) *domain.User {
+	user := testutil.NewFakeUser(suite.T(), name, email, "pa55word", true)
+
+	query := `
+	INSERT INTO users (name, email, password_hash, activated)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id`
+
+	err := suite.db.QueryRowContext(context.TODO(), query, user.Name, user.Email, user.Password.Hash, user.Activated).Scan(&user.ID)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	return user
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestWorkspaceRepoTestSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration tests...")
+	}
+
+	suite.Run(t, new(WorkspaceRepoTestSuite))
+}
+
+func (suite *WorkspaceRepoTestSuite) TestInsert() {
+	suite.Run("Success", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewWorkspaceRepo(suite.db)
+
+		ws := &domain.Workspace{Name: "Acme", Slug: "acme"}
+		suite.NoError(repo.Insert(ctx, suite.alice.ID, ws))
+		suite.Equal(domain.WorkspaceRoleOwner, ws.Role)
+
+		got, err := repo.GetBySlug(ctx, suite.alice.ID, "acme")
+		suite.NoError(err)
+		suite.Equal(ws.ID, got.ID)
+		suite.Equal(domain.WorkspaceRoleOwner, got.Role)
+	})
+
+	suite.Run("Duplicate slug", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewWorkspaceRepo(suite.db)
+
+		suite.NoError(repo.Insert(ctx, suite.alice.ID, &domain.Workspace{Name: "Acme", Slug: "acme"}))
+
+		err := repo.Insert(ctx, suite.bob.ID, &domain.Workspace{Name: "Acme", Slug: "acme"})
+		suite.True(errors.KindIs(err, errors.KindFailedValidation))
+	})
+}
+
+func (suite *WorkspaceRepoTestSuite) TestMembers() {
+	suite.Run("Success", func() {
+		suite.TearDownTest()
+		suite.SetupTest()
+
+		ctx := context.TODO()
+		repo := NewWorkspaceRepo(suite.db)
+
+		ws := &domain.Workspace{Name: "Acme", Slug: "acme"}
+		suite.NoError(repo.Insert(ctx, suite.alice.ID, ws))
+
+		// Bob can't see the workspace before he joins it.
+		_, err := repo.GetByID(ctx, suite.bob.ID, ws.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		member := &domain.WorkspaceMember{WorkspaceID: ws.ID, UserID: suite.bob.ID, Role: domain.WorkspaceRoleMember}
+		suite.NoError(repo.InsertMember(ctx, member))
+
+		got, err := repo.GetByID(ctx, suite.bob.ID, ws.ID)
+		suite.NoError(err)
+		suite.Equal(domain.WorkspaceRoleMember, got.Role)
+
+		workspaces, err := repo.GetAll(ctx, suite.bob.ID)
+		suite.NoError(err)
+		suite.Len(workspaces, 1)
+
+		members, err := repo.GetMembers(ctx, ws.ID)
+		suite.NoError(err)
+		suite.Len(members, 2)
+
+		gotMember, err := repo.GetMember(ctx, ws.ID, suite.bob.ID)
+		suite.NoError(err)
+		suite.Equal(suite.bob.Email, gotMember.Email)
+
+		suite.NoError(repo.DeleteMember(ctx, ws.ID, suite.bob.ID))
+
+		err = repo.DeleteMember(ctx, ws.ID, suite.bob.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+
+		suite.NoError(repo.Delete(ctx, ws.ID))
+
+		_, err = repo.GetByID(ctx, suite.alice.ID, ws.ID)
+		suite.True(errors.KindIs(err, errors.KindRecordNotFound))
+	})
+}
@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+)
+
+type workspaceUsecase struct {
+	workspaceRepo  domain.WorkspaceRepository
+	userRepo       domain.UserRepository
+	contextTimeout time.Duration
+}
+
+func NewWorkspaceUsecase(wr domain.WorkspaceRepository, ur domain.UserRepository, timeout time.Duration) domain.WorkspaceUsecase {
+	return &workspaceUsecase{
+		workspaceRepo:  wr,
+		userRepo:       ur,
+		contextTimeout: timeout,
+	}
+}
+
+// Create creates the workspace, the user with given userID becomes its owner.
+func (wu *workspaceUsecase) Create(ctx context.Context, userID int64, ws *domain.Workspace) error {
+	const op errors.Op = "workspaceUsecase.Create"
+
+	ctx, cancel := context.WithTimeout(ctx, wu.contextTimeout)
+	defer cancel()
+
+	err := wu.workspaceRepo.Insert(ctx, userID, ws)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// GetAll returns the workspaces the user is a member of.
+func (wu *workspaceUsecase) GetAll(ctx context.Context, userID int64) ([]*domain.Workspace, error) {
+	const op errors.Op = "workspaceUsecase.GetAll"
+
+	ctx, cancel := context.WithTimeout(ctx, wu.contextTimeout)
+	defer cancel()
+
+	workspaces, err := wu.workspaceRepo.GetAll(ctx, userID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return workspaces, nil
+}
+
+// GetByID returns the workspace if the user is a member of it.
+func (wu *workspaceUsecase) GetByID(ctx context.Context, userID int64, workspaceID int64) (*domain.Workspace, error) {
+	const op errors.Op = "workspaceUsecase.GetByID"
+
+	ctx, cancel := context.WithTimeout(ctx, wu.contextTimeout)
+	defer cancel()
+
+	ws, err := wu.workspaceRepo.GetByID(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return ws, nil
+}
+
+// GetBySlug returns the workspace if the user is a member of it.
+func (wu *workspaceUsecase) GetBySlug(ctx context.Context, userID int64, slug string) (*domain.Workspace, error) {
+	const op errors.Op = "workspaceUsecase.GetBySlug"
+
+	ctx, cancel := context.WithTimeout(ctx, wu.contextTimeout)
+	defer cancel()
+
+	ws, err := wu.workspaceRepo.GetBySlug(ctx, userID, slug)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return ws, nil
+}
+
+// Delete deletes the workspace along with its tasks, only the owner is permitted to do so.
+func (wu *workspaceUsecase) Delete(ctx context.Context, userID int64, workspaceID int64) error {
+	const op errors.Op = "workspaceUsecase.Delete"
+
+	ctx, cancel := context.WithTimeout(ctx, wu.contextTimeout)
+	defer cancel()
+
+	ws, err := wu.workspaceRepo.GetByID(ctx, userID, workspaceID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if !ws.IsOwner() {
+		return errors.E(op, errors.KindNotPermitted, domain.ErrNotPermitted)
+	}
+
+	err = wu.workspaceRepo.Delete(ctx, workspaceID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// GetMembers lists the members of the workspace, every member is permitted to see it.
+func (wu *workspaceUsecase) GetMembers(ctx context.Context, userID int64, workspaceID int64) ([]*domain.WorkspaceMember, error) {
+	const op errors.Op = "workspaceUsecase.GetMembers"
+
+	ctx, cancel := context.WithTimeout(ctx, wu.contextTimeout)
+	defer cancel()
+
+	// Make sure the user is a member of the workspace.
+	_, err := wu.workspaceRepo.GetByID(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	members, err := wu.workspaceRepo.GetMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return members, nil
+}
+
+// AddMember adds the user who owns the given email address to the workspace, or changes
+// the role of that user if they're already a member. Only the owner and admins are
+// permitted to do so, and the role of the owner can't be changed.
+// If there's no user with given email, the error with kind errors.KindRecordNotFound
+// is returned.
+func (wu *workspaceUsecase) AddMember(ctx context.Context, userID int64, workspaceID int64, email, role string) (*domain.WorkspaceMember, error) {
+	const op errors.Op = "workspaceUsecase.AddMember"
+
+	ctx, cancel := context.WithTimeout(ctx, wu.contextTimeout)
+	defer cancel()
+
+	ws, err := wu.workspaceRepo.GetByID(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	if !ws.CanManageMembers() {
+		return nil, errors.E(op, errors.KindNotPermitted, domain.ErrNotPermitted)
+	}
+
+	user, err := wu.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, errors.E(op, errors.UserEmail(email), err)
+	}
+
+	current, err := wu.workspaceRepo.GetMember(ctx, workspaceID, user.ID)
+	switch {
+	case err == nil:
+		if current.Role == domain.WorkspaceRoleOwner {
+			return nil, errors.E(op, errors.KindNotPermitted, domain.ErrNotPermitted)
+		}
+	case errors.KindIs(err, errors.KindRecordNotFound):
+	default:
+		return nil, errors.E(op, err)
+	}
+
+	member := &domain.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      user.ID,
+		Email:       user.Email,
+		Role:        role,
+	}
+
+	err = wu.workspaceRepo.InsertMember(ctx, member)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return member, nil
+}
+
+// RemoveMember removes the user with given memberID from the workspace. The owner and admins
+// can remove anyone but the owner, other members can only remove themselves.
+func (wu *workspaceUsecase) RemoveMember(ctx context.Context, userID int64, workspaceID int64, memberID int64) error {
+	const op errors.Op = "workspaceUsecase.RemoveMember"
+
+	ctx, cancel := context.WithTimeout(ctx, wu.contextTimeout)
+	defer cancel()
+
+	ws, err := wu.workspaceRepo.GetByID(ctx, userID, workspaceID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if !ws.CanManageMembers() && memberID != userID {
+		return errors.E(op, errors.KindNotPermitted, domain.ErrNotPermitted)
+	}
+
+	member, err := wu.workspaceRepo.GetMember(ctx, workspaceID, memberID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	// The owner has to delete the workspace instead of leaving it.
+	if member.Role == domain.WorkspaceRoleOwner {
+		return errors.E(op, errors.KindNotPermitted, domain.ErrNotPermitted)
+	}
+
+	err = wu.workspaceRepo.DeleteMember(ctx, workspaceID, memberID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/unknowntpo/todos/internal/domain"
+	"github.com/unknowntpo/todos/internal/domain/errors"
+	_repoMock "github.com/unknowntpo/todos/internal/domain/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDelete(t *testing.T) {
+	t.Run("Success as owner", func(t *testing.T) {
+		repo := new(_repoMock.WorkspaceRepository)
+
+		repo.On("GetByID", mock.Anything, int64(1), int64(1)).Return(&domain.Workspace{ID: 1, Role: domain.WorkspaceRoleOwner}, nil)
+		repo.On("Delete", mock.Anything, int64(1)).Return(nil)
+
+		workspaceUsecase := NewWorkspaceUsecase(repo, new(_repoMock.UserRepository), 3*time.Second)
+
+		assert.NoError(t, workspaceUsecase.Delete(context.TODO(), 1, 1))
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Fail as admin", func(t *testing.T) {
+		repo := new(_repoMock.WorkspaceRepository)
+
+		repo.On("GetByID", mock.Anything, int64(2), int64(1)).Return(&domain.Workspace{ID: 1, Role: domain.WorkspaceRoleAdmin}, nil)
+
+		workspaceUsecase := NewWorkspaceUsecase(repo, new(_repoMock.UserRepository), 3*time.Second)
+
+		err := workspaceUsecase.Delete(context.TODO(), 2, 1)
+		assert.True(t, errors.KindIs(err, errors.KindNotPermitted), "kind of error should be KindNotPermitted")
+
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAddMember(t *testing.T) {
+	bob := &domain.User{ID: 2, Email: "bob@example.com"}
+
+	t.Run("Success as admin", func(t *testing.T) {
+		repo := new(_repoMock.WorkspaceRepository)
+		userRepo := new(_repoMock.UserRepository)
+
+		repo.On("GetByID", mock.Anything, int64(3), int64(1)).Return(&domain.Workspace{ID: 1, Role: domain.WorkspaceRoleAdmin}, nil)
+		userRepo.On("GetByEmail", mock.Anything, bob.Email).Return(bob, nil)
+		repo.On("GetMember", mock.Anything, int64(1), bob.ID).Return(nil, errors.E(errors.KindRecordNotFound, domain.ErrRecordNotFound))
+		repo.On("InsertMember", mock.Anything, mock.AnythingOfType("*domain.WorkspaceMember")).Return(nil)
+
+		workspaceUsecase := NewWorkspaceUsecase(repo, userRepo, 3*time.Second)
+
+		member, err := workspaceUsecase.AddMember(context.TODO(), 3, 1, bob.Email, domain.WorkspaceRoleMember)
+		assert.NoError(t, err)
+		assert.Equal(t, bob.ID, member.UserID)
+		assert.Equal(t, domain.WorkspaceRoleMember, member.Role)
+
+		repo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fail as member", func(t *testing.T) {
+		repo := new(_repoMock.WorkspaceRepository)
+		userRepo := new(_repoMock.UserRepository)
+
+		repo.On("GetByID", mock.Anything, int64(3), int64(1)).Return(&domain.Workspace{ID: 1, Role: domain.WorkspaceRoleMember}, nil)
+
+		workspaceUsecase := NewWorkspaceUsecase(repo, userRepo, 3*time.Second)
+
+		_, err := workspaceUsecase.AddMember(context.TODO(), 3, 1, bob.Email, domain.WorkspaceRoleMember)
+		assert.True(t, errors.KindIs(err, errors.KindNotPermitted), "kind of error should be KindNotPermitted")
+
+		repo.AssertNotCalled(t, "InsertMember", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fail to change the role of the owner", func(t *testing.T) {
+		repo := new(_repoMock.WorkspaceRepository)
+		userRepo := new(_repoMock.UserRepository)
+
+		repo.On("GetByID", mock.Anything, int64(3), int64(1)).Return(&domain.Workspace{ID: 1, Role: domain.WorkspaceRoleAdmin}, nil)
+		userRepo.On("GetByEmail", mock.Anything, bob.Email).Return(bob, nil)
+		repo.On("GetMember", mock.Anything, int64(1), bob.ID).Return(&domain.WorkspaceMember{WorkspaceID: 1, UserID: bob.ID, Role: domain.WorkspaceRoleOwner}, nil)
+
+		workspaceUsecase := NewWorkspaceUsecase(repo, userRepo, 3*time.Second)
+
+		_, err := workspaceUsecase.AddMember(context.TODO(), 3, 1, bob.Email, domain.WorkspaceRoleMember)
+		assert.True(t, errors.KindIs(err, errors.KindNotPermitted), "kind of error should be KindNotPermitted")
+
+		repo.AssertNotCalled(t, "InsertMember", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRemoveMember(t *testing.T) {
+	t.Run("Success when member leaves", func(t *testing.T) {
+		repo := new(_repoMock.WorkspaceRepository)
+
+		repo.On("GetByID", mock.Anything, int64(2), int64(1)).Return(&domain.Workspace{ID: 1, Role: domain.WorkspaceRoleMember}, nil)
+		repo.On("GetMember", mock.Anything, int64(1), int64(2)).Return(&domain.WorkspaceMember{WorkspaceID: 1, UserID: 2, Role: domain.WorkspaceRoleMember}, nil)
+		repo.On("DeleteMember", mock.Anything, int64(1), int64(2)).Return(nil)
+
+		workspaceUsecase := NewWorkspaceUsecase(repo, new(_repoMock.UserRepository), 3*time.Second)
+
+		assert.NoError(t, workspaceUsecase.RemoveMember(context.TODO(), 2, 1, 2))
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Fail when member removes someone else", func(t *testing.T) {
+		repo := new(_repoMock.WorkspaceRepository)
+
+		repo.On("GetByID", mock.Anything, int64(2), int64(1)).Return(&domain.Workspace{ID: 1, Role: domain.WorkspaceRoleMember}, nil)
+
+		workspaceUsecase := NewWorkspaceUsecase(repo, new(_repoMock.UserRepository), 3*time.Second)
+
+		err := workspaceUsecase.RemoveMember(context.TODO(), 2, 1, 3)
+		assert.True(t, errors.KindIs(err, errors.KindNotPermitted), "kind of error should be KindNotPermitted")
+
+		repo.AssertNotCalled(t, "DeleteMember", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fail to remove the owner", func(t *testing.T) {
+		repo := new(_repoMock.WorkspaceRepository)
+
+		repo.On("GetByID", mock.Anything, int64(2), int64(1)).Return(&domain.Workspace{ID: 1, Role: domain.WorkspaceRoleAdmin}, nil)
+		repo.On("GetMember", mock.Anything, int64(1), int64(1)).Return(&domain.WorkspaceMember{WorkspaceID: 1, UserID: 1, Role: domain.WorkspaceRoleOwner}, nil)
+
+		workspaceUsecase := NewWorkspaceUsecase(repo, new(_repoMock.UserRepository), 3*time.Second)
+
+		err := workspaceUsecase.RemoveMember(context.TODO(), 2, 1, 1)
+		assert.True(t, errors.KindIs(err, errors.KindNotPermitted), "kind of error should be KindNotPermitted")
+
+		repo.AssertNotCalled(t, "DeleteMember", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS task_shares (
+    task_id bigint NOT NULL REFERENCES tasks ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    role text NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS task_shares_user_id_idx ON task_shares (user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS tasks_assignee_id_idx;
+ALTER TABLE tasks DROP COLUMN IF EXISTS assignee_id;
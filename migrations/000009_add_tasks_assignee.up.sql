@@ -0,0 +1,2 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS assignee_id bigint REFERENCES users ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS tasks_assignee_id_idx ON tasks (assignee_id);
@@ -0,0 +1,129 @@
+// Package breached checks passwords against a local database of passwords exposed in data breaches.
+//
+// The database file holds the SHA-1 hashes of the passwords in hex, one per line, optionally
+// followed by ":<count>", which is the format of the Pwned Passwords downloads. The file is
+// loaded into memory, where the hashes are indexed by their first 5 hex digits, the same prefix
+// the k-anonymity range API uses, so that a lookup only searches the few hashes sharing it.
+// It takes 20 bytes per hash, so a subset of the most common passwords is usually loaded
+// rather than the whole Pwned Passwords list.
+package breached
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+const (
+	hashSize = sha1.Size
+	// prefixBits is the length of the prefix the hashes are indexed by, 5 hex digits.
+	prefixBits = 20
+)
+
+// DB is the database of breached password hashes, it's safe for concurrent use.
+type DB struct {
+	// hashes are the sorted hashes concatenated.
+	hashes []byte
+	// index[p] is the position in hashes of the first hash with the prefix p,
+	// index[p+1] is the one after the last.
+	index []uint32
+}
+
+// Open loads the database from the file at path.
+func Open(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Load loads the database from r. Empty lines are skipped, the hashes may be in any order and case.
+func Load(r io.Reader) (*DB, error) {
+	var hashes [][hashSize]byte
+
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := bytes.TrimSpace(s.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		if i := bytes.IndexByte(text, ':'); i >= 0 {
+			text = text[:i]
+		}
+
+		var h [hashSize]byte
+		if len(text) != 2*hashSize {
+			return nil, fmt.Errorf("breached: line %d: invalid SHA-1 hash %q", line, text)
+		}
+		if _, err := hex.Decode(h[:], text); err != nil {
+			return nil, fmt.Errorf("breached: line %d: invalid SHA-1 hash %q", line, text)
+		}
+
+		hashes = append(hashes, h)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+
+	db := &DB{
+		hashes: make([]byte, 0, len(hashes)*hashSize),
+		index:  make([]uint32, 1<<prefixBits+1),
+	}
+
+	for i, h := range hashes {
+		// Skip duplicates, the list is sorted.
+		if i > 0 && h == hashes[i-1] {
+			continue
+		}
+		db.hashes = append(db.hashes, h[:]...)
+		db.index[prefix(h[:])+1]++
+	}
+
+	// Turn the counts of the hashes with each prefix into the positions.
+	for p := 1; p < len(db.index); p++ {
+		db.index[p] += db.index[p-1]
+	}
+
+	return db, nil
+}
+
+// Len returns the number of hashes in the database.
+func (db *DB) Len() int {
+	return len(db.hashes) / hashSize
+}
+
+// Contains reports whether password is in the database.
+func (db *DB) Contains(password string) bool {
+	h := sha1.Sum([]byte(password))
+
+	p := prefix(h[:])
+	lo, hi := int(db.index[p]), int(db.index[p+1])
+
+	i := sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(db.hash(lo+i), h[:]) >= 0
+	})
+
+	return lo+i < hi && bytes.Equal(db.hash(lo+i), h[:])
+}
+
+func (db *DB) hash(i int) []byte {
+	return db.hashes[i*hashSize : (i+1)*hashSize]
+}
+
+// prefix returns the first prefixBits of the hash.
+func prefix(h []byte) uint32 {
+	return (uint32(h[0])<<16 | uint32(h[1])<<8 | uint32(h[2])) >> (24 - prefixBits)
+}
@@ -0,0 +1,74 @@
+package breached
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha1Hex(s string) string {
+	h := sha1.Sum([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+func TestLoad(t *testing.T) {
+	breached := []string{"password", "123456", "qwerty", "letmein", "pa55word"}
+
+	var file strings.Builder
+	for i, p := range breached {
+		// Mix the case, counts and empty lines as in the real downloads.
+		hash := sha1Hex(p)
+		if i%2 == 0 {
+			hash = strings.ToUpper(hash)
+		}
+		fmt.Fprintf(&file, "%s:%d\r\n\n", hash, 100-i)
+	}
+	// Duplicates are ignored.
+	fmt.Fprintln(&file, sha1Hex("password"))
+
+	db, err := Load(strings.NewReader(file.String()))
+	require.NoError(t, err)
+	assert.Equal(t, len(breached), db.Len())
+
+	for _, p := range breached {
+		assert.True(t, db.Contains(p), p)
+	}
+
+	for _, p := range []string{"correct horse battery staple", "Password", "", "pa55wor"} {
+		assert.False(t, db.Contains(p), p)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, file := range []string{
+		"not a hash\n",
+		sha1Hex("password")[:39] + "\n",
+		sha1Hex("password") + "00\n",
+	} {
+		_, err := Load(strings.NewReader(file))
+		assert.Error(t, err, file)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(sha1Hex("letmein")+":3\n"), 0o600))
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	assert.True(t, db.Contains("letmein"))
+
+	empty, err := Load(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.False(t, empty.Contains("letmein"))
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,192 @@
+// Package clientip resolves the IP address of the client a request comes from.
+//
+// The headers naming the client, Forwarded (RFC 7239), X-Forwarded-For and X-Real-IP, can be set
+// by anyone, so only the one the trusted proxies set is believed, and only when the request comes
+// from one of them. The others are passed through by the proxies as the client sent them. The
+// addresses in the Forwarded or X-Forwarded-For chain are walked from the nearest hop, and the
+// first one which isn't a trusted proxy is the client.
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// The headers the trusted proxies can set.
+const (
+	HeaderForwarded     = "Forwarded"
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-Ip"
+)
+
+// Resolver resolves the client IP of requests coming through the trusted proxies.
+// The zero value trusts no proxy.
+type Resolver struct {
+	header  string
+	trusted []*net.IPNet
+}
+
+// NewResolver returns the resolver trusting the header set by the proxies, which are CIDR blocks,
+// e.g. "10.0.0.0/8", or single addresses. The header is Forwarded, X-Forwarded-For or X-Real-IP,
+// X-Forwarded-For if it's empty.
+func NewResolver(header string, proxies []string) (*Resolver, error) {
+	res := &Resolver{header: http.CanonicalHeaderKey(header)}
+
+	switch res.header {
+	case "":
+		res.header = HeaderXForwardedFor
+	case HeaderForwarded, HeaderXForwardedFor, HeaderXRealIP:
+	default:
+		return nil, fmt.Errorf("clientip: unknown header %q, must be Forwarded, X-Forwarded-For or X-Real-IP", header)
+	}
+
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("clientip: invalid proxy address %q", p)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+
+			res.trusted = append(res.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("clientip: invalid proxy network %q", p)
+		}
+
+		res.trusted = append(res.trusted, network)
+	}
+
+	return res, nil
+}
+
+// ClientIP returns the IP address of the client the request comes from. If the request doesn't
+// come from a trusted proxy, it's the address of the peer, otherwise it's the first address in
+// the forwarding chain of the trusted header which isn't a trusted proxy. An entry which isn't
+// an IP address, e.g. "unknown" or an obfuscated identifier, ends the walk at the hop which has
+// added it.
+func (res *Resolver) ClientIP(r *http.Request) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+
+	if !res.isTrusted(peer) {
+		return peer
+	}
+
+	var chain []string
+
+	switch res.header {
+	case HeaderForwarded:
+		chain = forwardedFor(r.Header.Values(HeaderForwarded))
+	case HeaderXForwardedFor:
+		chain = xForwardedFor(r.Header.Values(HeaderXForwardedFor))
+	case HeaderXRealIP:
+		if realIP := strings.TrimSpace(r.Header.Get(HeaderXRealIP)); net.ParseIP(realIP) != nil {
+			return realIP
+		}
+	}
+
+	if len(chain) == 0 {
+		return peer
+	}
+
+	client := peer
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := net.ParseIP(chain[i])
+		if ip == nil {
+			break
+		}
+
+		client = ip.String()
+
+		if !res.isTrusted(client) {
+			break
+		}
+	}
+
+	return client
+}
+
+func (res *Resolver) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range res.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forwardedFor returns the addresses of the "for" parameters of the Forwarded headers in order,
+// with the ports and the brackets around IPv6 addresses removed.
+//
+//	Forwarded: for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"
+func forwardedFor(headers []string) []string {
+	var chain []string
+
+	for _, h := range headers {
+		for _, element := range strings.Split(h, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				i := strings.IndexByte(pair, '=')
+				if i < 0 || !strings.EqualFold(strings.TrimSpace(pair[:i]), "for") {
+					continue
+				}
+
+				value := strings.Trim(strings.TrimSpace(pair[i+1:]), `"`)
+				chain = append(chain, stripPort(value))
+			}
+		}
+	}
+
+	return chain
+}
+
+// xForwardedFor returns the addresses of the X-Forwarded-For headers in order.
+//
+//	X-Forwarded-For: 192.0.2.60, 203.0.113.43
+func xForwardedFor(headers []string) []string {
+	var chain []string
+
+	for _, h := range headers {
+		for _, addr := range strings.Split(h, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				chain = append(chain, stripPort(addr))
+			}
+		}
+	}
+
+	return chain
+}
+
+// stripPort removes the port from "192.0.2.60:4711" or "[2001:db8::17]:4711", and the brackets
+// from "[2001:db8::17]". Bare IPv6 addresses are returned as is.
+func stripPort(addr string) string {
+	if strings.HasPrefix(addr, "[") {
+		if i := strings.IndexByte(addr, ']'); i > 0 {
+			return addr[1:i]
+		}
+		return addr
+	}
+
+	if strings.Count(addr, ":") == 1 {
+		return addr[:strings.IndexByte(addr, ':')]
+	}
+
+	return addr
+}
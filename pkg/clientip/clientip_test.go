@@ -0,0 +1,132 @@
+package clientip
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies := []string{"10.0.0.0/8", "2001:db8:ffff::/48", "198.51.100.7"}
+
+	tests := []struct {
+		name       string
+		header     string
+		remoteAddr string
+		headers    map[string][]string
+		want       string
+	}{
+		{
+			name:       "untrusted peer can't spoof",
+			remoteAddr: "203.0.113.9:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"192.0.2.1"}, "X-Real-Ip": {"192.0.2.1"}},
+			want:       "203.0.113.9",
+		},
+		{
+			name:       "trusted proxy without headers",
+			remoteAddr: "10.1.2.3:51234",
+			want:       "10.1.2.3",
+		},
+		{
+			name:       "X-Forwarded-For through trusted proxies",
+			remoteAddr: "10.1.2.3:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"192.0.2.1, 203.0.113.9", "10.4.5.6"}},
+			want:       "203.0.113.9",
+		},
+		{
+			name:       "client can't spoof Forwarded through proxy setting X-Forwarded-For",
+			remoteAddr: "10.1.2.3:51234",
+			headers: map[string][]string{
+				"Forwarded":       {"for=192.0.2.1"},
+				"X-Forwarded-For": {"203.0.113.9"},
+			},
+			want: "203.0.113.9",
+		},
+		{
+			name:       "client can't spoof X-Real-IP through proxy setting X-Forwarded-For",
+			remoteAddr: "10.1.2.3:51234",
+			headers:    map[string][]string{"X-Real-Ip": {"192.0.2.1"}},
+			want:       "10.1.2.3",
+		},
+		{
+			name:       "X-Real-IP from trusted proxy",
+			header:     "X-Real-IP",
+			remoteAddr: "198.51.100.7:51234",
+			headers:    map[string][]string{"X-Real-Ip": {"192.0.2.1"}, "X-Forwarded-For": {"203.0.113.9"}},
+			want:       "192.0.2.1",
+		},
+		{
+			name:       "Forwarded from trusted proxy",
+			header:     "Forwarded",
+			remoteAddr: "10.1.2.3:51234",
+			headers: map[string][]string{
+				"Forwarded":       {`for=192.0.2.60;proto=http;by=203.0.113.43, For="[2001:db8:cafe::17]:4711"`},
+				"X-Forwarded-For": {"192.0.2.1"},
+			},
+			want: "2001:db8:cafe::17",
+		},
+		{
+			name:       "Forwarded with port through trusted IPv6 proxy",
+			header:     "forwarded",
+			remoteAddr: "[2001:db8:ffff::1]:443",
+			headers:    map[string][]string{"Forwarded": {`for="192.0.2.60:4711"`, `for="[2001:db8:ffff::2]"`}},
+			want:       "192.0.2.60",
+		},
+		{
+			name:       "unknown hop stops the walk",
+			header:     "Forwarded",
+			remoteAddr: "10.1.2.3:51234",
+			headers:    map[string][]string{"Forwarded": {"for=192.0.2.60, for=unknown, for=10.4.5.6"}},
+			want:       "10.4.5.6",
+		},
+		{
+			name:       "all hops trusted",
+			remoteAddr: "10.1.2.3:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.7.7.7"}},
+			want:       "10.7.7.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewResolver(tt.header, proxies)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := http.NewRequest(http.MethodGet, "/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.RemoteAddr = tt.remoteAddr
+			for k, vs := range tt.headers {
+				for _, v := range vs {
+					r.Header.Add(k, v)
+				}
+			}
+
+			assert.Equal(t, tt.want, res.ClientIP(r))
+		})
+	}
+}
+
+func TestNewResolver(t *testing.T) {
+	_, err := NewResolver("", []string{"10.0.0.0/33"})
+	assert.Error(t, err)
+
+	_, err = NewResolver("", []string{"proxy.local"})
+	assert.Error(t, err)
+
+	_, err = NewResolver("True-Client-IP", []string{"10.0.0.0/8"})
+	assert.Error(t, err)
+
+	r, err := http.NewRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.RemoteAddr = "10.1.2.3:51234"
+	r.Header.Set("X-Forwarded-For", "192.0.2.1")
+
+	assert.Equal(t, "10.1.2.3", new(Resolver).ClientIP(r), "zero value should trust no proxy")
+}
@@ -0,0 +1,148 @@
+// Package health runs the named checks of the dependencies a service needs to serve requests,
+// e.g. its database, to tell whether it's ready to.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckFunc checks a dependency, it returns an error if the dependency can't be used.
+type CheckFunc func(ctx context.Context) error
+
+// The statuses of the checks.
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+)
+
+// Result is the result of a check.
+type Result struct {
+	Name   string
+	Status string
+	// Critical reports whether the service isn't ready if the check fails.
+	Critical bool
+	Latency  time.Duration
+	// Err is the error of the failed check.
+	Err error
+}
+
+// Report is the result of all the checks.
+type Report struct {
+	// Ready reports whether all the critical checks pass and the service isn't shutting down.
+	Ready bool
+	// ShuttingDown reports whether the service is shutting down, the checks aren't run then.
+	ShuttingDown bool
+	// Results are the results of the checks, sorted by name.
+	Results []Result
+}
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Registry holds the checks of the service.
+type Registry struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+
+	shuttingDown int32 // accessed atomically
+}
+
+// NewRegistry returns the registry, whose checks fail if they take longer than timeout.
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// Register adds the check of the name. The service isn't ready if a critical check fails, the
+// failures of the other checks are only reported.
+func (r *Registry) Register(name string, critical bool, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = append(r.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Shutdown marks the service as shutting down, it's never ready afterwards, so that the load
+// balancers stop sending it requests before it stops accepting them.
+func (r *Registry) Shutdown() {
+	atomic.StoreInt32(&r.shuttingDown, 1)
+}
+
+// Check runs all the checks concurrently, each one with the timeout of the registry.
+func (r *Registry) Check(ctx context.Context) *Report {
+	if atomic.LoadInt32(&r.shuttingDown) == 1 {
+		return &Report{ShuttingDown: true}
+	}
+
+	r.mu.RLock()
+	checks := make([]check, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := &Report{Ready: true, Results: results}
+	for _, res := range results {
+		if res.Critical && res.Status == StatusFail {
+			report.Ready = false
+		}
+	}
+
+	return report
+}
+
+// run runs the check, which fails if it doesn't return within the timeout. A check which
+// ignores the context is left running, its result is dropped.
+func (r *Registry) run(ctx context.Context, c check) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	res := Result{Name: c.name, Critical: c.critical}
+	start := time.Now()
+
+	errc := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				errc <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		errc <- c.fn(ctx)
+	}()
+
+	select {
+	case res.Err = <-errc:
+	case <-ctx.Done():
+		res.Err = fmt.Errorf("check timed out: %v", ctx.Err())
+	}
+
+	res.Latency = time.Since(start)
+
+	res.Status = StatusPass
+	if res.Err != nil {
+		res.Status = StatusFail
+	}
+
+	return res
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	pass := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("connection refused") }
+	hang := func(ctx context.Context) error { select {} }
+
+	t.Run("all critical checks pass", func(t *testing.T) {
+		reg := NewRegistry(50 * time.Millisecond)
+		reg.Register("database", true, pass)
+		reg.Register("smtp", false, fail)
+
+		report := reg.Check(context.Background())
+		assert.True(t, report.Ready, "the failure of a non-critical check shouldn't make the service not ready")
+
+		if assert.Len(t, report.Results, 2) {
+			assert.Equal(t, "database", report.Results[0].Name)
+			assert.Equal(t, StatusPass, report.Results[0].Status)
+			assert.NoError(t, report.Results[0].Err)
+
+			assert.Equal(t, "smtp", report.Results[1].Name)
+			assert.Equal(t, StatusFail, report.Results[1].Status)
+			assert.EqualError(t, report.Results[1].Err, "connection refused")
+		}
+	})
+
+	t.Run("critical check fails", func(t *testing.T) {
+		reg := NewRegistry(50 * time.Millisecond)
+		reg.Register("pool", true, pass)
+		reg.Register("database", true, fail)
+
+		report := reg.Check(context.Background())
+		assert.False(t, report.Ready)
+	})
+
+	t.Run("check times out", func(t *testing.T) {
+		reg := NewRegistry(10 * time.Millisecond)
+		reg.Register("database", true, hang)
+
+		report := reg.Check(context.Background())
+		assert.False(t, report.Ready)
+		if assert.Len(t, report.Results, 1) {
+			assert.Equal(t, StatusFail, report.Results[0].Status)
+			assert.GreaterOrEqual(t, int64(report.Results[0].Latency), int64(10*time.Millisecond))
+		}
+	})
+
+	t.Run("check panics", func(t *testing.T) {
+		reg := NewRegistry(50 * time.Millisecond)
+		reg.Register("database", true, func(ctx context.Context) error { panic("nil pointer") })
+
+		report := reg.Check(context.Background())
+		assert.False(t, report.Ready)
+	})
+
+	t.Run("shutting down", func(t *testing.T) {
+		reg := NewRegistry(50 * time.Millisecond)
+		reg.Register("database", true, pass)
+		reg.Shutdown()
+
+		report := reg.Check(context.Background())
+		assert.False(t, report.Ready)
+		assert.True(t, report.ShuttingDown)
+		assert.Empty(t, report.Results, "the checks shouldn't run while shutting down")
+	})
+}
@@ -0,0 +1,243 @@
+// Package jwt implements the subset of JSON Web Tokens (RFC 7519) used for stateless
+// authentication tokens: compact tokens signed with EdDSA (Ed25519) or HS256, and a
+// JSON Web Key Set (RFC 7517) publishing the public keys.
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Supported signing algorithms.
+const (
+	AlgEdDSA = "EdDSA"
+	AlgHS256 = "HS256"
+)
+
+// minHMACSecretLength is the minimum length of the HS256 secret in bytes.
+const minHMACSecretLength = 32
+
+var (
+	ErrInvalidToken = errors.New("jwt: invalid token")
+	ErrExpiredToken = errors.New("jwt: token has expired")
+	ErrUnknownKey   = errors.New("jwt: unknown signing key")
+)
+
+// Claims are the claims carried by a token. Times are seconds since the Unix epoch.
+type Claims struct {
+	Subject   string `json:"sub"`
+	Scope     string `json:"scope,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// Key is a signing key identified by its ID, which is put in the kid header of the tokens it signs.
+type Key struct {
+	id         string
+	alg        string
+	secret     []byte
+	privateKey ed25519.PrivateKey
+}
+
+// NewKey returns the key with given id for the algorithm. For AlgEdDSA, secret is the
+// 32-byte Ed25519 seed, for AlgHS256, it's the shared secret of at least 32 bytes.
+func NewKey(id, alg string, secret []byte) (*Key, error) {
+	if id == "" {
+		return nil, errors.New("jwt: key id must be provided")
+	}
+
+	switch alg {
+	case AlgEdDSA:
+		if len(secret) != ed25519.SeedSize {
+			return nil, errors.New("jwt: Ed25519 seed must be 32 bytes long")
+		}
+		return &Key{id: id, alg: alg, privateKey: ed25519.NewKeyFromSeed(secret)}, nil
+	case AlgHS256:
+		if len(secret) < minHMACSecretLength {
+			return nil, errors.New("jwt: HMAC secret must be at least 32 bytes long")
+		}
+		return &Key{id: id, alg: alg, secret: secret}, nil
+	default:
+		return nil, errors.New("jwt: unsupported algorithm " + alg)
+	}
+}
+
+func (k *Key) sign(signingInput []byte) []byte {
+	if k.alg == AlgEdDSA {
+		return ed25519.Sign(k.privateKey, signingInput)
+	}
+
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write(signingInput)
+	return mac.Sum(nil)
+}
+
+func (k *Key) verify(signingInput, signature []byte) bool {
+	if k.alg == AlgEdDSA {
+		return ed25519.Verify(k.privateKey.Public().(ed25519.PublicKey), signingInput, signature)
+	}
+
+	return hmac.Equal(k.sign(signingInput), signature)
+}
+
+// KeySet signs tokens with its first key and verifies tokens with any of its keys,
+// so that a key can be rotated by putting a new one in front of it, and removing it
+// once the tokens it signed have expired.
+type KeySet struct {
+	keys []*Key
+}
+
+// NewKeySet returns the key set of given keys, the first one is used to sign tokens.
+func NewKeySet(keys ...*Key) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("jwt: at least one key must be provided")
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if seen[k.id] {
+			return nil, errors.New("jwt: duplicate key id " + k.id)
+		}
+		seen[k.id] = true
+	}
+
+	return &KeySet{keys: keys}, nil
+}
+
+// Sign returns the compact serialization of the token carrying claims,
+// signed by the current key.
+func (ks *KeySet) Sign(claims *Claims) (string, error) {
+	k := ks.keys[0]
+
+	h, err := json.Marshal(&header{Algorithm: k.alg, KeyID: k.id, Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encode(h) + "." + encode(c)
+	signature := k.sign([]byte(signingInput))
+
+	return signingInput + "." + encode(signature), nil
+}
+
+// Verify checks the signature and expiry of the token at now and returns its claims.
+// The algorithm in the token header must match the one of the key it refers to.
+func (ks *KeySet) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeJSON(parts[0], &h); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	k := ks.key(h.KeyID)
+	if k == nil {
+		return nil, ErrUnknownKey
+	}
+
+	// Never let the token choose the algorithm, e.g. HS256 with the public key as secret.
+	if h.Algorithm != k.alg {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if !k.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeJSON(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+
+	return &claims, nil
+}
+
+func (ks *KeySet) key(id string) *Key {
+	for _, k := range ks.keys {
+		if k.id == id {
+			return k
+		}
+	}
+
+	return nil
+}
+
+// JWK is the public part of a signing key.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the key set. HS256 keys are secret, so they're left out.
+func (ks *KeySet) JWKS() *JWKS {
+	set := &JWKS{Keys: []JWK{}}
+
+	for _, k := range ks.keys {
+		if k.alg != AlgEdDSA {
+			continue
+		}
+
+		set.Keys = append(set.Keys, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         encode(k.privateKey.Public().(ed25519.PublicKey)),
+			KeyID:     k.id,
+			Algorithm: k.alg,
+			Use:       "sig",
+		})
+	}
+
+	return set
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeJSON(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
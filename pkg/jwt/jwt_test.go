@@ -0,0 +1,104 @@
+package jwt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T, id, alg string, b byte) *Key {
+	k, err := NewKey(id, alg, bytes.Repeat([]byte{b}, 32))
+	require.NoError(t, err)
+	return k
+}
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Now()
+	claims := &Claims{Subject: "1", Scope: "authentication", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+
+	for _, alg := range []string{AlgEdDSA, AlgHS256} {
+		t.Run(alg, func(t *testing.T) {
+			ks, err := NewKeySet(newKey(t, "k1", alg, 1))
+			require.NoError(t, err)
+
+			token, err := ks.Sign(claims)
+			require.NoError(t, err)
+
+			got, err := ks.Verify(token, now)
+			assert.NoError(t, err)
+			assert.Equal(t, claims, got)
+
+			_, err = ks.Verify(token, now.Add(time.Minute))
+			assert.Equal(t, ErrExpiredToken, err)
+
+			parts := strings.Split(token, ".")
+			tampered := parts[0] + "." + encode([]byte(`{"sub":"2","exp":9999999999}`)) + "." + parts[2]
+			_, err = ks.Verify(tampered, now)
+			assert.Equal(t, ErrInvalidToken, err)
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Now()
+	claims := &Claims{Subject: "1", ExpiresAt: now.Add(time.Minute).Unix()}
+
+	old := newKey(t, "old", AlgEdDSA, 1)
+	oldSet, err := NewKeySet(old)
+	require.NoError(t, err)
+
+	token, err := oldSet.Sign(claims)
+	require.NoError(t, err)
+
+	// The tokens signed by the old key stay valid while the new key signs.
+	rotated, err := NewKeySet(newKey(t, "new", AlgEdDSA, 2), old)
+	require.NoError(t, err)
+
+	_, err = rotated.Verify(token, now)
+	assert.NoError(t, err)
+
+	newToken, err := rotated.Sign(claims)
+	require.NoError(t, err)
+
+	_, err = oldSet.Verify(newToken, now)
+	assert.Equal(t, ErrUnknownKey, err)
+
+	jwks := rotated.JWKS()
+	if assert.Len(t, jwks.Keys, 2) {
+		assert.Equal(t, "new", jwks.Keys[0].KeyID)
+		assert.Equal(t, "OKP", jwks.Keys[0].KeyType)
+	}
+}
+
+func TestAlgorithmConfusion(t *testing.T) {
+	now := time.Now()
+	claims := &Claims{Subject: "1", ExpiresAt: now.Add(time.Minute).Unix()}
+
+	edSet, err := NewKeySet(newKey(t, "k1", AlgEdDSA, 1))
+	require.NoError(t, err)
+	hmacSet, err := NewKeySet(newKey(t, "k1", AlgHS256, 1))
+	require.NoError(t, err)
+
+	token, err := hmacSet.Sign(claims)
+	require.NoError(t, err)
+
+	_, err = edSet.Verify(token, now)
+	assert.Equal(t, ErrInvalidToken, err)
+
+	assert.Empty(t, hmacSet.JWKS().Keys, "HMAC secrets must not be published")
+}
+
+func TestNewKey(t *testing.T) {
+	_, err := NewKey("k1", AlgHS256, []byte("too short"))
+	assert.Error(t, err)
+
+	_, err = NewKey("k1", "none", bytes.Repeat([]byte{1}, 32))
+	assert.Error(t, err)
+
+	_, err = NewKeySet(newKey(t, "k1", AlgEdDSA, 1), newKey(t, "k1", AlgEdDSA, 2))
+	assert.Error(t, err)
+}
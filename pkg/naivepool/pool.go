@@ -3,12 +3,18 @@ package naivepool
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 )
 
 // jobFunc represents the function that will be executed by workers.
 type jobFunc func()
 
 type Pool struct {
+	// queued and busy are accessed atomically, they come first to be 64-bit aligned.
+	queued  int64 // the number of jobs scheduled but not yet picked up by a worker.
+	busy    int64 // the number of workers running a job.
+	running int32 // 1 from Start until the context of Start is done, accessed atomically.
+
 	jobChan        chan jobFunc    // We use jobChan to communicate between caller of Pool and Pool.
 	workers        chan workerChan // workers conatains channel to communicate with each worker.
 	maxJobs        int
@@ -17,6 +23,13 @@ type Pool struct {
 	wg             sync.WaitGroup // Use waitgroup to wait for workers done its job and retire.
 }
 
+// Stats is a snapshot of the load of the pool.
+type Stats struct {
+	Workers     int // Workers is the number of workers.
+	BusyWorkers int // BusyWorkers is the number of workers running a job.
+	QueuedJobs  int // QueuedJobs is the number of jobs waiting for a worker.
+}
+
 // New inits goroutine pool with capacity of jobchan and workerchan.
 func New(maxJobs, maxWorkers, workerChanSize int) *Pool {
 	p := &Pool{
@@ -38,9 +51,11 @@ func (p *Pool) Start(ctx context.Context) {
 		w := NewWorker(p.workerChanSize)
 		// set up channel between pool and worker
 		p.workers <- w.c
-		go w.work(ctx, &p.wg)
+		go w.work(ctx, p)
 	}
 
+	atomic.StoreInt32(&p.running, 1)
+
 	go func() {
 		for {
 			select {
@@ -54,6 +69,7 @@ func (p *Pool) Start(ctx context.Context) {
 				// put him back to p.workers
 				p.workers <- wc
 			case <-ctx.Done():
+				atomic.StoreInt32(&p.running, 0)
 				close(p.workers)
 				return
 			}
@@ -68,5 +84,21 @@ func (p *Pool) Wait() {
 
 // Schedule sends the job the p.jobChan.
 func (p *Pool) Schedule(job jobFunc) {
+	atomic.AddInt64(&p.queued, 1)
 	p.jobChan <- job
 }
+
+// Running reports whether the pool is started and dispatches the jobs scheduled to the workers.
+func (p *Pool) Running() bool {
+	return atomic.LoadInt32(&p.running) == 1
+}
+
+// Stats returns the number of busy workers and queued jobs, so that the load of the pool can be
+// monitored.
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Workers:     p.maxWorkers,
+		BusyWorkers: int(atomic.LoadInt64(&p.busy)),
+		QueuedJobs:  int(atomic.LoadInt64(&p.queued)),
+	}
+}
@@ -0,0 +1,63 @@
+package naivepool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	pool := New(10, 2, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool.Start(ctx)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{}, 3)
+
+	for i := 0; i < 3; i++ {
+		pool.Schedule(func() {
+			started <- struct{}{}
+			<-release
+			done <- struct{}{}
+		})
+	}
+
+	// Both workers are blocked, the third job waits for one of them.
+	<-started
+	<-started
+
+	assert.Equal(t, Stats{Workers: 2, BusyWorkers: 2, QueuedJobs: 1}, pool.Stats())
+
+	close(release)
+	<-started
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+
+	// The workers are done with the jobs right after they return.
+	assert.Eventually(t, func() bool {
+		return pool.Stats() == Stats{Workers: 2}
+	}, time.Second, time.Millisecond)
+}
+
+func TestRunning(t *testing.T) {
+	pool := New(10, 2, 1)
+	assert.False(t, pool.Running(), "the pool shouldn't run before it's started")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+	assert.True(t, pool.Running())
+
+	cancel()
+	pool.Wait()
+
+	assert.Eventually(t, func() bool {
+		return !pool.Running()
+	}, time.Second, time.Millisecond, "the pool should stop running along with the context")
+}
@@ -2,7 +2,7 @@ package naivepool
 
 import (
 	"context"
-	"sync"
+	"sync/atomic"
 )
 
 // workerChan is the channel connecting between pool and worker, each worker uses 1 workerChan.
@@ -22,12 +22,15 @@ func NewWorker(chanSize int) worker {
 }
 
 // worker is the worker that execute the job received from p.workerChan.
-func (w *worker) work(ctx context.Context, wg *sync.WaitGroup) {
-	defer wg.Done()
+func (w *worker) work(ctx context.Context, p *Pool) {
+	defer p.wg.Done()
 	for {
 		select {
 		case f := <-w.c:
+			atomic.AddInt64(&p.queued, -1)
+			atomic.AddInt64(&p.busy, 1)
 			f()
+			atomic.AddInt64(&p.busy, -1)
 		case <-ctx.Done():
 			return
 		}
@@ -0,0 +1,176 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/unknowntpo/todos/pkg/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var alice = oidctest.User{Subject: "alice-id", Email: "alice@example.com", EmailVerified: true, Name: "Alice Smith"}
+
+func newTestProvider(t *testing.T) (*oidctest.Server, *Provider) {
+	idp, err := oidctest.NewServer("todos", "s3cret", alice)
+	require.NoError(t, err)
+	t.Cleanup(idp.Close)
+
+	p := NewProvider(Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     "todos",
+		ClientSecret: "s3cret",
+		RedirectURL:  "http://localhost:4000/v1/auth/oidc/idp/callback",
+		Scopes:       []string{"email", "profile"},
+	}, nil)
+
+	return idp, p
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	idp, p := newTestProvider(t)
+	ctx := context.Background()
+
+	verifier, err := GenerateRandom()
+	require.NoError(t, err)
+
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", verifier)
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+	assert.Equal(t, Challenge(verifier), u.Query().Get("code_challenge"))
+	assert.Empty(t, u.Query().Get("code_verifier"), "the verifier must not leave the relying party before the exchange")
+
+	code, state, err := idp.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state", state)
+
+	claims, err := p.Exchange(ctx, code, verifier, "nonce")
+	require.NoError(t, err)
+	assert.Equal(t, "alice-id", claims.Subject)
+	assert.Equal(t, "alice@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+
+	// A code can only be exchanged once.
+	_, err = p.Exchange(ctx, code, verifier, "nonce")
+	assert.Equal(t, ErrInvalidGrant, err)
+}
+
+func TestExchangeWithWrongVerifier(t *testing.T) {
+	idp, p := newTestProvider(t)
+	ctx := context.Background()
+
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", "verifier")
+	require.NoError(t, err)
+
+	code, _, err := idp.Authorize(authURL)
+	require.NoError(t, err)
+
+	_, err = p.Exchange(ctx, code, "another verifier", "nonce")
+	assert.Equal(t, ErrInvalidGrant, err)
+}
+
+func TestVerify(t *testing.T) {
+	idp, p := newTestProvider(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":   idp.Issuer(),
+			"sub":   "alice-id",
+			"aud":   "todos",
+			"exp":   now.Add(5 * time.Minute).Unix(),
+			"iat":   now.Unix(),
+			"nonce": "nonce",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c map[string]interface{})
+		ok     bool
+	}{
+		{"Valid", func(c map[string]interface{}) {}, true},
+		{"Audience array", func(c map[string]interface{}) { c["aud"] = []string{"todos"} }, true},
+		{"Multiple audiences without azp", func(c map[string]interface{}) { c["aud"] = []string{"todos", "other"} }, false},
+		{"Wrong audience", func(c map[string]interface{}) { c["aud"] = "other" }, false},
+		{"Wrong issuer", func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }, false},
+		{"Wrong nonce", func(c map[string]interface{}) { c["nonce"] = "other" }, false},
+		{"Expired", func(c map[string]interface{}) { c["exp"] = now.Add(-2 * time.Minute).Unix() }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.modify(claims)
+
+			token, err := idp.SignIDToken(claims)
+			require.NoError(t, err)
+
+			_, err = p.Verify(ctx, token, "nonce", now)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, ErrInvalidIDToken, err)
+			}
+		})
+	}
+
+	t.Run("Tampered", func(t *testing.T) {
+		token, err := idp.SignIDToken(valid())
+		require.NoError(t, err)
+
+		_, err = p.Verify(ctx, token[:len(token)-4]+"AAAA", "nonce", now)
+		assert.Equal(t, ErrInvalidIDToken, err)
+	})
+}
+
+func TestKeyCaching(t *testing.T) {
+	idp, p := newTestProvider(t)
+	ctx := context.Background()
+
+	sign := func() string {
+		token, err := idp.SignIDToken(map[string]interface{}{
+			"iss": idp.Issuer(), "sub": "alice-id", "aud": "todos", "exp": time.Now().Add(time.Minute).Unix(),
+		})
+		require.NoError(t, err)
+		return token
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := p.Verify(ctx, sign(), "", time.Now())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, idp.JWKSFetches(), "the JWKS should be cached")
+
+	// The provider rotates its key, but the JWKS has been fetched just now, so tokens with
+	// unknown key IDs don't make it be fetched again.
+	require.NoError(t, idp.RotateKey())
+	_, err := p.Verify(ctx, sign(), "", time.Now())
+	assert.Equal(t, ErrInvalidIDToken, err)
+	assert.Equal(t, 1, idp.JWKSFetches())
+
+	// Once the minimum refresh interval has passed, the new key is fetched.
+	p.mu.Lock()
+	p.keysFetchedAt = p.keysFetchedAt.Add(-minRefreshInterval)
+	p.mu.Unlock()
+
+	_, err = p.Verify(ctx, sign(), "", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, idp.JWKSFetches())
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp, _ := newTestProvider(t)
+
+	p := NewProvider(Config{Issuer: idp.Issuer() + "/other", ClientID: "todos"}, nil)
+
+	_, err := p.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	assert.Error(t, err)
+}
@@ -0,0 +1,215 @@
+// Package passhash hashes passwords with Argon2id or bcrypt.
+//
+// Argon2id hashes are encoded in the PHC string format,
+//
+//	$argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+//
+// and bcrypt hashes in their own format, $2a$<cost>$<salt and key>, so that the algorithm and
+// the parameters a hash was made with can always be told from the hash itself. That's what lets
+// Verify check hashes made with any parameters, and NeedsRehash tell the hashes made with
+// outdated ones.
+package passhash
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// The supported algorithms.
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+const (
+	saltLength = 16
+	keyLength  = 32
+
+	// bcryptMaxLength is the length after which bcrypt ignores the rest of the password.
+	bcryptMaxLength = 72
+	// maxLength bounds the passwords hashed with Argon2id, which has no limit of its own.
+	maxLength = 256
+)
+
+var (
+	// ErrUnknownFormat is returned when the hash isn't made by this package.
+	ErrUnknownFormat = errors.New("passhash: unknown hash format")
+	// ErrPasswordTooLong is returned when the password is longer than MaxLength.
+	ErrPasswordTooLong = errors.New("passhash: password too long")
+)
+
+// Params are the algorithm and its parameters to hash passwords with. Memory is in KiB,
+// Memory, Iterations and Parallelism apply to Argon2id, Cost applies to bcrypt.
+type Params struct {
+	Algorithm   string
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	Cost        int
+}
+
+// DefaultParams follow the OWASP recommendation for Argon2id, 19 MiB of memory and 2 iterations.
+var DefaultParams = Params{
+	Algorithm:   Argon2id,
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	Cost:        12,
+}
+
+// Validate reports whether the parameters can be used to hash passwords.
+func (p Params) Validate() error {
+	switch p.Algorithm {
+	case Argon2id:
+		if p.Memory < 8*uint32(p.Parallelism) || p.Iterations < 1 || p.Parallelism < 1 {
+			return fmt.Errorf("passhash: invalid argon2id parameters m=%d,t=%d,p=%d", p.Memory, p.Iterations, p.Parallelism)
+		}
+	case Bcrypt:
+		if p.Cost < bcrypt.MinCost || p.Cost > bcrypt.MaxCost {
+			return fmt.Errorf("passhash: invalid bcrypt cost %d", p.Cost)
+		}
+	default:
+		return fmt.Errorf("passhash: unknown algorithm %q", p.Algorithm)
+	}
+
+	return nil
+}
+
+// MaxLength returns the maximum length of the passwords in bytes which can be hashed
+// with the parameters.
+func (p Params) MaxLength() int {
+	if p.Algorithm == Bcrypt {
+		return bcryptMaxLength
+	}
+	return maxLength
+}
+
+// Hash returns the hash of password made with the parameters p.
+func Hash(password []byte, p Params) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(password) > p.MaxLength() {
+		return nil, ErrPasswordTooLong
+	}
+
+	if p.Algorithm == Bcrypt {
+		return bcrypt.GenerateFromPassword(password, p.Cost)
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey(password, salt, p.Iterations, p.Memory, p.Parallelism, keyLength)
+
+	return encodeArgon2id(p, salt, key), nil
+}
+
+// Verify reports whether password matches hash, which is made with any parameters.
+func Verify(hash, password []byte) (bool, error) {
+	if isBcrypt(hash) {
+		err := bcrypt.CompareHashAndPassword(hash, password)
+		switch {
+		case err == nil:
+			return true, nil
+		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	p, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	if len(password) > maxLength {
+		return false, nil
+	}
+
+	other := argon2.IDKey(password, salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash reports whether hash is made with another algorithm or other parameters than p,
+// in which case the password should be hashed again once it's known.
+func NeedsRehash(hash []byte, p Params) bool {
+	if isBcrypt(hash) {
+		if p.Algorithm != Bcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost(hash)
+		return err != nil || cost != p.Cost
+	}
+
+	if p.Algorithm != Argon2id {
+		return true
+	}
+
+	hp, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	return hp.Memory != p.Memory || hp.Iterations != p.Iterations || hp.Parallelism != p.Parallelism ||
+		len(salt) != saltLength || len(key) != keyLength
+}
+
+func isBcrypt(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte("$2"))
+}
+
+var b64 = base64.RawStdEncoding
+
+func encodeArgon2id(p Params, salt, key []byte) []byte {
+	return []byte(fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		Argon2id, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		b64.EncodeToString(salt), b64.EncodeToString(key)))
+}
+
+func decodeArgon2id(hash []byte) (p Params, salt, key []byte, err error) {
+	// The hash starts with "$", so the first part is empty.
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != Argon2id {
+		return Params{}, nil, nil, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Params{}, nil, nil, ErrUnknownFormat
+	}
+
+	p.Algorithm = Argon2id
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return Params{}, nil, nil, ErrUnknownFormat
+	}
+
+	if err := p.Validate(); err != nil {
+		return Params{}, nil, nil, ErrUnknownFormat
+	}
+
+	salt, err = b64.DecodeString(parts[4])
+	if err != nil {
+		return Params{}, nil, nil, ErrUnknownFormat
+	}
+
+	key, err = b64.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Params{}, nil, nil, ErrUnknownFormat
+	}
+
+	return p, salt, key, nil
+}
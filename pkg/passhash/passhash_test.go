@@ -0,0 +1,102 @@
+package passhash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fast are cheap Argon2id parameters to keep the tests quick.
+var fast = Params{Algorithm: Argon2id, Memory: 64, Iterations: 1, Parallelism: 1}
+
+func TestHashAndVerify(t *testing.T) {
+	for _, p := range []Params{fast, {Algorithm: Bcrypt, Cost: bcrypt.MinCost}} {
+		t.Run(p.Algorithm, func(t *testing.T) {
+			hash, err := Hash([]byte("pa55word"), p)
+			require.NoError(t, err)
+
+			match, err := Verify(hash, []byte("pa55word"))
+			assert.NoError(t, err)
+			assert.True(t, match)
+
+			match, err = Verify(hash, []byte("pa55wore"))
+			assert.NoError(t, err)
+			assert.False(t, match)
+
+			assert.False(t, NeedsRehash(hash, p))
+		})
+	}
+
+	t.Run("Argon2id format", func(t *testing.T) {
+		hash, err := Hash([]byte("pa55word"), fast)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(hash), "$argon2id$v=19$m=64,t=1,p=1$"), string(hash))
+
+		other, err := Hash([]byte("pa55word"), fast)
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, other, "hashes should be salted")
+	})
+
+	t.Run("Password too long", func(t *testing.T) {
+		_, err := Hash(make([]byte, 73), Params{Algorithm: Bcrypt, Cost: bcrypt.MinCost})
+		assert.ErrorIs(t, err, ErrPasswordTooLong)
+
+		_, err = Hash(make([]byte, 256), fast)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		for _, hash := range []string{
+			"",
+			"pa55word",
+			"$argon2i$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$a2V5",
+			"$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHQ$a2V5",
+			"$argon2id$v=19$m=0,t=1,p=1$c2FsdHNhbHQ$a2V5",
+			"$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$",
+			"$argon2id$v=19$m=64,t=1,p=1$!!$a2V5",
+		} {
+			_, err := Verify([]byte(hash), []byte("pa55word"))
+			assert.ErrorIs(t, err, ErrUnknownFormat, hash)
+		}
+	})
+}
+
+func TestNeedsRehash(t *testing.T) {
+	argon2Hash, err := Hash([]byte("pa55word"), fast)
+	require.NoError(t, err)
+	bcryptHash, err := Hash([]byte("pa55word"), Params{Algorithm: Bcrypt, Cost: bcrypt.MinCost})
+	require.NoError(t, err)
+
+	stronger := fast
+	stronger.Iterations = 2
+
+	tests := []struct {
+		name string
+		hash []byte
+		p    Params
+		want bool
+	}{
+		{"Same argon2id parameters", argon2Hash, fast, false},
+		{"Other argon2id parameters", argon2Hash, stronger, true},
+		{"Bcrypt to argon2id", bcryptHash, fast, true},
+		{"Argon2id to bcrypt", argon2Hash, Params{Algorithm: Bcrypt, Cost: bcrypt.MinCost}, true},
+		{"Other bcrypt cost", bcryptHash, Params{Algorithm: Bcrypt, Cost: bcrypt.MinCost + 1}, true},
+		{"Unknown format", []byte("pa55word"), fast, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NeedsRehash(tt.hash, tt.p))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, DefaultParams.Validate())
+	assert.Error(t, Params{Algorithm: "scrypt"}.Validate())
+	assert.Error(t, Params{Algorithm: Argon2id, Memory: 64, Iterations: 0, Parallelism: 1}.Validate())
+	assert.Error(t, Params{Algorithm: Bcrypt, Cost: 100}.Validate())
+}
@@ -0,0 +1,67 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secret is the SHA1 secret of the test vectors in RFC 6238 appendix B, "12345678901234567890".
+var secret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// The test vectors have 8 digits, the last 6 of them are the 6-digit codes.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(secret, Step(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "code at %d", tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	step, ok := Validate(secret, "081804", now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// The code of the previous time step is still accepted.
+	_, ok = Validate(secret, "081804", now.Add(period*time.Second))
+	assert.True(t, ok)
+
+	_, ok = Validate(secret, "081804", now.Add(2*period*time.Second))
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "000000", now)
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "81804", now)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	s, err := GenerateSecret()
+	require.NoError(t, err)
+
+	u, err := url.Parse(URI("TODOs", "alice@example.com", s))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/TODOs:alice@example.com", u.Path)
+	assert.Equal(t, s, u.Query().Get("secret"))
+	assert.Equal(t, "TODOs", u.Query().Get("issuer"))
+}
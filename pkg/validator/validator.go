@@ -11,6 +11,8 @@ import (
 // note further down the page.
 var (
 	EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
+	// SlugRX matches URL friendly identifiers, e.g. "acme-corp".
+	SlugRX = regexp.MustCompile("^[a-z0-9][a-z0-9-]{1,48}[a-z0-9]$")
 )
 
 // Define a new Validator type which contains a map of validation errors.